@@ -0,0 +1,122 @@
+package redis
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+type (
+	// AdminAuthorizer authorize a request of admin endpoints, requests are rejected with 403 by an error
+	AdminAuthorizer func(req *http.Request) error
+)
+
+var (
+	// ErrAdminUnauthorized admin request without valid credentials
+	ErrAdminUnauthorized = errors.New("redis: admin request is not authorized")
+)
+
+// SetAdminAuthorizer authorize requests of admin endpoints by fn instead of adminSecret, e.g. by the app's session or mTLS identity.
+// Call it before serving.
+func (r *Redis) SetAdminAuthorizer(fn AdminAuthorizer) {
+	r.adminAuthorizer = fn
+}
+
+// AdminHandler admin http endpoints of the instance, mount it under a path prefix with http.StripPrefix.
+// Responses carry the instance in InstanceHeader. Requests are authorized by SetAdminAuthorizer or adminSecret,
+// without both only GET requests are served. Operation tokens are granted by OperationsHandler.
+//
+//	GET  /toggles                         current toggles
+//	POST /toggles?name=x&value=y          set toggle of this process
+//	POST /toggles?name=x&value=y&persist=1 set toggle of all instances via controlKey
+//	GET  /parts                           subsystem tree and health
+//	GET  /metrics                         metrics snapshot
+//	GET  /latency?prefix=x                latency quantiles by key prefix, all prefixes without prefix
+//	GET  /keys?pattern=x&sample=n&top=n   largest and hottest sampled keys by prefix
+func (r *Redis) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/toggles", r.handleToggles)
+	mux.HandleFunc("/parts", r.handleParts)
+	mux.HandleFunc("/metrics", r.handleMetrics)
+	mux.HandleFunc("/latency", r.handleLatency)
+	mux.HandleFunc("/keys", r.handleKeys)
+
+	return r.instanceHandler(r.adminAuth(mux, false))
+}
+
+// OperationsHandler operation token endpoints, mount it apart from AdminHandler, e.g. on an internal listener.
+// Every request must be authorized by SetAdminAuthorizer or adminSecret, it is not served without both.
+//
+//	POST   /operations?op=x&ttl=5m&uses=1&reason=y grant an operation token
+//	DELETE /operations?token=x                      revoke an operation token
+func (r *Redis) OperationsHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/operations", r.handleOperations)
+
+	return r.instanceHandler(r.adminAuth(mux, true))
+}
+
+// adminAuth serve requests authorized by the authorizer or adminSecret.
+// Without both, requests of restricted endpoints are rejected and GET requests of others are served.
+func (r *Redis) adminAuth(h http.Handler, restricted bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if err := r.authorizeAdmin(req, restricted); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+
+		h.ServeHTTP(w, req)
+	})
+}
+
+func (r *Redis) authorizeAdmin(req *http.Request, restricted bool) error {
+	if r.adminAuthorizer != nil {
+		return r.adminAuthorizer(req)
+	}
+
+	if r.AdminSecret == "" {
+		if restricted || req.Method != http.MethodGet {
+			return ErrAdminUnauthorized
+		}
+		return nil
+	}
+
+	secret := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(secret), []byte(r.AdminSecret)) != 1 {
+		return ErrAdminUnauthorized
+	}
+
+	return nil
+}
+
+func (r *Redis) handleToggles(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+	case http.MethodPost, http.MethodPut:
+		name, value := req.FormValue("name"), req.FormValue("value")
+
+		var err error
+		if req.FormValue("persist") == "1" {
+			err = r.PublishToggle(req.Context(), name, value)
+		} else {
+			err = r.SetToggle(name, value)
+		}
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	writeJSON(w, r.Toggles())
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
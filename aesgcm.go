@@ -0,0 +1,142 @@
+package redis
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+type (
+	// KeyProvider keys of AES-GCM encryption by key id, e.g. backed by a KMS or secret manager.
+	// Values are encrypted by the current key, rotate keys by changing the current key id while keeping old keys readable.
+	KeyProvider interface {
+		CurrentKey() (id string, key []byte, err error)
+		Key(id string) ([]byte, error)
+	}
+
+	// StaticKeys key provider of fixed keys, keys are 16, 24 or 32 bytes (AES-128, AES-192 or AES-256)
+	StaticKeys struct {
+		Current string
+		Keys    map[string][]byte
+	}
+
+	// aesGCM AES-GCM encryptor, the key id is written in the header of values
+	aesGCM struct {
+		provider KeyProvider
+		mu       sync.RWMutex
+		aeads    map[string]cipher.AEAD
+	}
+)
+
+var (
+	// ErrUnknownKey key id of a value is not provided
+	ErrUnknownKey = errors.New("redis: unknown encryption key")
+)
+
+// NewAESGCM new an AES-GCM encryptor of keys of provider, register it by RegisterEncryptor for namespace policies, e.g.
+//
+//	RegisterEncryptor("aes-gcm", 1, NewAESGCM(&StaticKeys{Current: "2024-01", Keys: keys}))
+//
+// Values are nonce-prefixed with the key id in the header: <len(id)><id><nonce><ciphertext>.
+func NewAESGCM(provider KeyProvider) Encryptor {
+	return &aesGCM{
+		provider: provider,
+		aeads:    make(map[string]cipher.AEAD),
+	}
+}
+
+// CurrentKey key of Current
+func (s *StaticKeys) CurrentKey() (string, []byte, error) {
+	key, err := s.Key(s.Current)
+
+	return s.Current, key, err
+}
+
+// Key key of id
+func (s *StaticKeys) Key(id string) ([]byte, error) {
+	key, ok := s.Keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrUnknownKey, id)
+	}
+
+	return key, nil
+}
+
+func (a *aesGCM) Encrypt(data []byte) ([]byte, error) {
+	id, key, err := a.provider.CurrentKey()
+	if err != nil {
+		return nil, err
+	}
+	if len(id) > 255 {
+		return nil, fmt.Errorf("redis: encryption key id %s is longer than 255 bytes", id)
+	}
+
+	aead, err := a.aead(id, key)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 1+len(id)+aead.NonceSize(), 1+len(id)+aead.NonceSize()+len(data)+aead.Overhead())
+	out[0] = byte(len(id))
+	copy(out[1:], id)
+
+	nonce := out[1+len(id):]
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(out, nonce, data, []byte(id)), nil
+}
+
+func (a *aesGCM) Decrypt(data []byte) ([]byte, error) {
+	if len(data) < 1 || len(data) < 1+int(data[0]) {
+		return nil, errors.New("redis: encrypted value is truncated")
+	}
+
+	id := string(data[1 : 1+int(data[0])])
+	data = data[1+len(id):]
+
+	aead, err := a.aead(id, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("redis: encrypted value is truncated")
+	}
+
+	return aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], []byte(id))
+}
+
+// aead cipher of key id, key is loaded from the provider when it is nil
+func (a *aesGCM) aead(id string, key []byte) (cipher.AEAD, error) {
+	a.mu.RLock()
+	aead, ok := a.aeads[id]
+	a.mu.RUnlock()
+	if ok {
+		return aead, nil
+	}
+
+	if key == nil {
+		var err error
+		if key, err = a.provider.Key(id); err != nil {
+			return nil, err
+		}
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("redis: encryption key %s: %w", id, err)
+	}
+	if aead, err = cipher.NewGCM(block); err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	a.aeads[id] = aead
+	a.mu.Unlock()
+
+	return aead, nil
+}
@@ -0,0 +1,258 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	principalKey struct{}
+
+	// auditHook record processed commands to the audit sink, added after hooks failing commands only when audit is enabled
+	auditHook struct {
+		r *Redis
+	}
+
+	// rejectHook hook rejecting commands by BeforeProcess. go-redis skips every AfterProcess of rejected commands,
+	// so their timeout is released here and they are audited when audit is enabled.
+	rejectHook struct {
+		redis.Hook
+		r *Redis
+	}
+
+	// auditSinkValue AuditSink stored in an atomic.Value
+	auditSinkValue struct {
+		AuditSink
+	}
+
+	// AuditRecord an audited command, keys and args are redacted
+	AuditRecord struct {
+		At        time.Time `json:"at"`
+		Principal string    `json:"principal,omitempty"`
+		Caller    string    `json:"caller,omitempty"`
+		Command   string    `json:"command"`
+		Keys      []string  `json:"keys,omitempty"`
+		Args      []string  `json:"args,omitempty"` // arguments after the command name, only when auditValues is enabled
+		Status    string    `json:"status"`
+		Error     string    `json:"error,omitempty"`
+	}
+
+	// AuditSink receive audit records, e.g. a compliance log shipper. Audit is called synchronously after each command.
+	AuditSink interface {
+		Audit(ctx context.Context, record AuditRecord)
+	}
+
+	// AuditSinkFunc function as AuditSink
+	AuditSinkFunc func(ctx context.Context, record AuditRecord)
+)
+
+// Audit statuses
+const (
+	AuditOK       = "ok"
+	AuditNil      = "nil"
+	AuditError    = "error"
+	AuditRejected = "rejected" // rejected before sent, e.g. by guards, key patterns or the limiter
+)
+
+// auditRedacted replacement of redacted text
+const auditRedacted = "[redacted]"
+
+// WithPrincipal audit commands of ctx as issued by principal, e.g. the authenticated user or service account
+func WithPrincipal(ctx context.Context, principal string) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom principal of ctx, empty when it is not set
+func PrincipalFrom(ctx context.Context) string {
+	principal, _ := ctx.Value(principalKey{}).(string)
+
+	return principal
+}
+
+// Audit call f
+func (f AuditSinkFunc) Audit(ctx context.Context, record AuditRecord) {
+	f(ctx, record)
+}
+
+// SetAuditSink send audit records to sink instead of the logger, requires audit
+func (r *Redis) SetAuditSink(sink AuditSink) {
+	r.auditSink.Store(auditSinkValue{sink})
+}
+
+// compileAuditRedact compile auditRedact patterns, invalid patterns are logged and ignored
+func (r *Redis) compileAuditRedact() {
+	patterns := make([]*regexp.Regexp, 0, len(r.AuditRedact))
+	for _, expr := range r.AuditRedact {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			r.logf("auditRedact %q is ignored: %v", expr, err)
+			continue
+		}
+		patterns = append(patterns, re)
+	}
+
+	r.auditRedact = patterns
+}
+
+func (r *Redis) audited(cmd redis.Cmder) bool {
+	if len(r.AuditCommands) == 0 {
+		return true
+	}
+
+	for _, name := range r.AuditCommands {
+		if strings.EqualFold(name, cmd.Name()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// auditCmd record cmd to the sink, rejected when it is not sent
+func (r *Redis) auditCmd(ctx context.Context, cmd redis.Cmder, rejected bool) {
+	if !r.audited(cmd) {
+		return
+	}
+
+	record := AuditRecord{
+		At:        time.Now(),
+		Principal: PrincipalFrom(ctx),
+		Caller:    CallerFrom(ctx),
+		Command:   strings.ToLower(cmd.Name()),
+		Status:    AuditOK,
+	}
+
+	for _, key := range auditKeys(cmd) {
+		record.Keys = append(record.Keys, r.redact(key))
+	}
+
+	if r.AuditValues {
+		for _, arg := range cmd.Args()[1:] {
+			record.Args = append(record.Args, r.redact(fmt.Sprint(arg)))
+		}
+	}
+
+	switch err := cmd.Err(); {
+	case rejected:
+		record.Status = AuditRejected
+		if err != nil {
+			record.Error = r.redact(err.Error())
+		}
+	case err == nil:
+	case err == redis.Nil:
+		record.Status = AuditNil
+	default:
+		record.Status = AuditError
+		record.Error = r.redact(err.Error())
+	}
+
+	if sink, _ := r.auditSink.Load().(auditSinkValue); sink.AuditSink != nil {
+		sink.Audit(ctx, record)
+		return
+	}
+
+	r.logf("audit: principal=%s caller=%s cmd=%s keys=%v status=%s %s", record.Principal, record.Caller, record.Command, record.Keys, record.Status, record.Error)
+}
+
+func (r *Redis) redact(s string) string {
+	for _, re := range r.auditRedact {
+		s = re.ReplaceAllString(s, auditRedacted)
+	}
+
+	return s
+}
+
+// auditKeys keys of cmd: all keys of common multi-key commands, the first key of others
+func auditKeys(cmd redis.Cmder) []string {
+	args := cmd.Args()
+
+	switch strings.ToLower(cmd.Name()) {
+	case "del", "unlink", "exists", "touch", "mget", "watch", "sunion", "sinter", "sdiff", "pfcount":
+		keys := make([]string, 0, len(args)-1)
+		for _, arg := range args[1:] {
+			keys = append(keys, fmt.Sprint(arg))
+		}
+		return keys
+	case "mset", "msetnx":
+		keys := make([]string, 0, len(args)/2)
+		for i := 1; i < len(args); i += 2 {
+			keys = append(keys, fmt.Sprint(args[i]))
+		}
+		return keys
+	case "eval", "evalsha", "fcall", "fcall_ro":
+		if len(args) < 3 {
+			return nil
+		}
+		n, _ := strconv.Atoi(fmt.Sprint(args[2]))
+		keys := make([]string, 0, n)
+		for i := 3; i < len(args) && i < 3+n; i++ {
+			keys = append(keys, fmt.Sprint(args[i]))
+		}
+		return keys
+	}
+
+	if key, ok := commandKey(cmd); ok {
+		return []string{key}
+	}
+
+	return nil
+}
+
+func (h auditHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (h auditHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	h.r.auditCmd(ctx, cmd, false)
+
+	return nil
+}
+
+func (h auditHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (h auditHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	for _, cmd := range cmds {
+		h.r.auditCmd(ctx, cmd, false)
+	}
+
+	return nil
+}
+
+func (h rejectHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	next, err := h.Hook.BeforeProcess(ctx, cmd)
+	if err != nil {
+		releaseTimeout(ctx)
+
+		if h.r.Audit {
+			cmd.SetErr(err)
+			h.r.auditCmd(h.r.withDetectedCaller(ctx), cmd, true)
+		}
+	}
+
+	return next, err
+}
+
+func (h rejectHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	next, err := h.Hook.BeforeProcessPipeline(ctx, cmds)
+	if err != nil {
+		releaseTimeout(ctx)
+
+		if h.r.Audit {
+			caller := h.r.withDetectedCaller(ctx)
+			for _, cmd := range cmds {
+				cmd.SetErr(err)
+				h.r.auditCmd(caller, cmd, true)
+			}
+		}
+	}
+
+	return next, err
+}
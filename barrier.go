@@ -0,0 +1,165 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	// Barrier distributed barrier, Await blocks until parties callers across instances arrived, then the barrier resets.
+	Barrier struct {
+		redis   *Redis
+		name    string
+		parties int
+	}
+
+	// WaitGroup distributed wait group, Wait blocks until the counter is zero
+	WaitGroup struct {
+		redis *Redis
+		name  string
+	}
+)
+
+// barrierTTL abandoned barriers and wait groups are expired after it
+const barrierTTL = 24 * time.Hour
+
+// barrierPoll waiters poll state in case notifications are lost
+const barrierPoll = time.Second
+
+var (
+	// arrive at barrier. KEYS[1]: count, KEYS[2]: generation. ARGV: parties, ttl ms, channel.
+	// Returns the generation arrived at, the generation is increased when barrier trips
+	barrierArriveScript = newScript(`
+local generation = tonumber(redis.call('get', KEYS[2]) or '0')
+local n = redis.call('incr', KEYS[1])
+if n >= tonumber(ARGV[1]) then
+	redis.call('del', KEYS[1])
+	redis.call('set', KEYS[2], generation + 1, 'px', ARGV[2])
+	redis.call('publish', ARGV[3], generation + 1)
+	return generation
+end
+redis.call('pexpire', KEYS[1], ARGV[2])
+redis.call('set', KEYS[2], generation, 'px', ARGV[2])
+return generation
+`)
+
+	// add delta to wait group. KEYS[1]: counter. ARGV: delta, ttl ms, channel. Returns the counter
+	waitGroupAddScript = newScript(`
+local n = redis.call('incrby', KEYS[1], ARGV[1])
+if n <= 0 then
+	redis.call('del', KEYS[1])
+	redis.call('publish', ARGV[3], n)
+	return n
+end
+redis.call('pexpire', KEYS[1], ARGV[2])
+return n
+`)
+)
+
+// NewBarrier new a barrier name of parties
+func NewBarrier(r *Redis, name string, parties int) *Barrier {
+	return &Barrier{
+		redis:   r,
+		name:    name,
+		parties: parties,
+	}
+}
+
+// Await arrive at the barrier and wait for other parties, ctx should have a deadline
+func (b *Barrier) Await(ctx context.Context) error {
+	r := b.redis
+	generationKey := "{" + b.name + "}:generation"
+	arrived := int64(0)
+
+	// subscribe before arriving, the trip is published by the last party
+	return r.waitSignal(ctx, b.channel(), func(first bool) (bool, error) {
+		if first {
+			generation, err := barrierArriveScript.run(ctx, r, []string{"{" + b.name + "}:count", generationKey},
+				b.parties, int64(barrierTTL/time.Millisecond), b.channel()).Int64()
+			if err != nil {
+				return false, err
+			}
+			arrived = generation
+		}
+
+		current, err := r.DoContext(ForceMaster(ctx), "get", generationKey).Int64()
+		if err == redis.Nil {
+			return true, nil
+		}
+
+		return err == nil && current > arrived, err
+	})
+}
+
+func (b *Barrier) channel() string {
+	return "{" + b.name + "}:barrier"
+}
+
+// NewWaitGroup new a wait group name
+func NewWaitGroup(r *Redis, name string) *WaitGroup {
+	return &WaitGroup{
+		redis: r,
+		name:  name,
+	}
+}
+
+// Add delta to the counter, waiters are released when it is zero
+func (wg *WaitGroup) Add(ctx context.Context, delta int64) error {
+	return waitGroupAddScript.run(ctx, wg.redis, []string{wg.key()}, delta, int64(barrierTTL/time.Millisecond), wg.channel()).Err()
+}
+
+// Done decrease the counter by one
+func (wg *WaitGroup) Done(ctx context.Context) error {
+	return wg.Add(ctx, -1)
+}
+
+// Wait block until the counter is zero, ctx should have a deadline
+func (wg *WaitGroup) Wait(ctx context.Context) error {
+	return wg.redis.waitSignal(ctx, wg.channel(), func(bool) (bool, error) {
+		n, err := wg.redis.DoContext(ForceMaster(ctx), "get", wg.key()).Int64()
+		if err == redis.Nil {
+			return true, nil
+		}
+
+		return err == nil && n <= 0, err
+	})
+}
+
+func (wg *WaitGroup) key() string {
+	return "{" + wg.name + "}:waitgroup"
+}
+
+func (wg *WaitGroup) channel() string {
+	return "{" + wg.name + "}:waitgroup:done"
+}
+
+// waitSignal subscribe channel and call check until it is done, on each message and every barrierPoll.
+// first is true on the first check made after subscribed
+func (r *Redis) waitSignal(ctx context.Context, channel string, check func(first bool) (bool, error)) error {
+	ps := r.Subscribe(channel)
+	defer ps.Close()
+
+	if _, err := ps.Receive(); err != nil {
+		return err
+	}
+	messages := ps.Channel()
+
+	ticker := time.NewTicker(barrierPoll)
+	defer ticker.Stop()
+
+	for first := true; ; first = false {
+		done, err := check(first)
+		if err != nil || done {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-messages:
+		case <-ticker.C:
+		}
+	}
+}
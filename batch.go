@@ -0,0 +1,116 @@
+package redis
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v7"
+)
+
+// MGetBatch MGET keys in chunks of batchSize in one pipeline, chunks never cross cluster slots.
+// Values are aligned with keys, missing keys are nil.
+func (r *Redis) MGetBatch(ctx context.Context, keys []string, batchSize int) ([]interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	values := make([]interface{}, len(keys))
+	if len(keys) == 0 {
+		return values, nil
+	}
+
+	type chunk struct {
+		cmd     *redis.SliceCmd
+		indexes []int
+	}
+	chunks := make([]chunk, 0)
+
+	_, err := r.Pipelined(func(pipe redis.Pipeliner) error {
+		for _, indexes := range r.chunkKeys(keys, batchSize) {
+			chunkKeys := make([]string, len(indexes))
+			for i, index := range indexes {
+				chunkKeys[i] = keys[index]
+			}
+
+			chunks = append(chunks, chunk{cmd: pipe.MGet(chunkKeys...), indexes: indexes})
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	for _, c := range chunks {
+		for i, val := range c.cmd.Val() {
+			values[c.indexes[i]] = val
+		}
+	}
+
+	return values, nil
+}
+
+// MSetBatch MSET pairs in chunks of batchSize in one pipeline, chunks never cross cluster slots.
+func (r *Redis) MSetBatch(ctx context.Context, pairs map[string]interface{}, batchSize int) error {
+	if len(pairs) == 0 {
+		return ctx.Err()
+	}
+
+	keys := make([]string, 0, len(pairs))
+	for key := range pairs {
+		keys = append(keys, key)
+	}
+
+	_, err := r.Pipelined(func(pipe redis.Pipeliner) error {
+		for _, indexes := range r.chunkKeys(keys, batchSize) {
+			values := make([]interface{}, 0, len(indexes)*2)
+			for _, index := range indexes {
+				values = append(values, keys[index], pairs[keys[index]])
+			}
+
+			pipe.MSet(values...)
+		}
+		return nil
+	})
+
+	return err
+}
+
+// chunkKeys split indexes of keys into chunks of at most batchSize, grouped by slot for cluster clients
+func (r *Redis) chunkKeys(keys []string, batchSize int) [][]int {
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+
+	groups := [][]int{}
+	if r.isCluster() {
+		bySlot := map[int]int{}
+		for i, key := range keys {
+			slot := keySlot(key)
+			g, ok := bySlot[slot]
+			if !ok {
+				g = len(groups)
+				bySlot[slot] = g
+				groups = append(groups, nil)
+			}
+			groups[g] = append(groups[g], i)
+		}
+	} else {
+		all := make([]int, len(keys))
+		for i := range keys {
+			all[i] = i
+		}
+		groups = append(groups, all)
+	}
+
+	chunks := [][]int{}
+	for _, group := range groups {
+		for len(group) > batchSize {
+			chunks = append(chunks, group[:batchSize])
+			group = group[batchSize:]
+		}
+		if len(group) > 0 {
+			chunks = append(chunks, group)
+		}
+	}
+
+	return chunks
+}
@@ -0,0 +1,153 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	// BatchPipeliner buffer commands and send them in a pipeline when size commands are buffered
+	// or interval passed since the first buffered command.
+	BatchPipeliner struct {
+		redis    *Redis
+		size     int
+		interval time.Duration
+
+		mu      sync.Mutex
+		cmds    []redis.Cmder
+		futures []*Future
+		timer   *time.Timer
+		closed  bool
+		wg      sync.WaitGroup
+	}
+
+	// Future result of a buffered command, ready after its pipeline is sent
+	Future struct {
+		cmd  redis.Cmder
+		err  error
+		done chan struct{}
+	}
+)
+
+var (
+	// ErrPipelinerClosed command queued after the pipeliner is closed
+	ErrPipelinerClosed = errors.New("redis: batch pipeliner closed")
+)
+
+// NewBatchPipeliner new a batch pipeliner, size is 100 and interval is 10ms if they are not positive.
+func NewBatchPipeliner(r *Redis, size int, interval time.Duration) *BatchPipeliner {
+	if size <= 0 {
+		size = 100
+	}
+	if interval <= 0 {
+		interval = 10 * time.Millisecond
+	}
+
+	return &BatchPipeliner{
+		redis:    r,
+		size:     size,
+		interval: interval,
+	}
+}
+
+// Do buffer a command of args
+func (bp *BatchPipeliner) Do(args ...interface{}) *Future {
+	return bp.Queue(redis.NewCmd(args...))
+}
+
+// Queue buffer cmd, typed cmds (e.g. redis.NewIntCmd) are accepted
+func (bp *BatchPipeliner) Queue(cmd redis.Cmder) *Future {
+	f := &Future{cmd: cmd, done: make(chan struct{})}
+
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+
+	if bp.closed {
+		f.err = ErrPipelinerClosed
+		close(f.done)
+		return f
+	}
+
+	bp.cmds = append(bp.cmds, cmd)
+	bp.futures = append(bp.futures, f)
+
+	if len(bp.cmds) >= bp.size {
+		bp.flushLocked()
+	} else if bp.timer == nil {
+		bp.timer = time.AfterFunc(bp.interval, bp.Flush)
+	}
+
+	return f
+}
+
+// Flush send buffered commands now
+func (bp *BatchPipeliner) Flush() {
+	bp.mu.Lock()
+	bp.flushLocked()
+	bp.mu.Unlock()
+}
+
+// Close flush buffered commands and wait for sent pipelines, commands queued afterwards fail
+func (bp *BatchPipeliner) Close() error {
+	bp.mu.Lock()
+	bp.closed = true
+	bp.flushLocked()
+	bp.mu.Unlock()
+
+	bp.wg.Wait()
+
+	return nil
+}
+
+func (bp *BatchPipeliner) flushLocked() {
+	if bp.timer != nil {
+		bp.timer.Stop()
+		bp.timer = nil
+	}
+
+	if len(bp.cmds) == 0 {
+		return
+	}
+
+	cmds, futures := bp.cmds, bp.futures
+	bp.cmds, bp.futures = nil, nil
+
+	bp.wg.Add(1)
+	go func() {
+		defer bp.wg.Done()
+
+		pipe := bp.redis.Pipeline()
+		for _, cmd := range cmds {
+			pipe.Process(cmd)
+		}
+
+		// errors are set on each cmd
+		pipe.Exec()
+
+		for _, f := range futures {
+			close(f.done)
+		}
+	}()
+}
+
+// Wait until the command is sent or ctx is done, returns the cmd and its error
+func (f *Future) Wait(ctx context.Context) (redis.Cmder, error) {
+	select {
+	case <-f.done:
+		if f.err != nil {
+			return f.cmd, f.err
+		}
+		return f.cmd, f.cmd.Err()
+	case <-ctx.Done():
+		return f.cmd, ctx.Err()
+	}
+}
+
+// Done closed when the command is sent
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
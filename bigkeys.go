@@ -0,0 +1,300 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type (
+	// AnalyzeOptions options of AnalyzeKeys
+	AnalyzeOptions struct {
+		Pattern  string   // SCAN MATCH pattern of sampled keys, default is *
+		Sample   int      // max keys sampled, default is 10000
+		Top      int      // largest and hottest keys reported per prefix, default is 10
+		Prefixes []string // report keys by these prefixes, default is the analyzePrefixes config
+		Rate     int      // max keys sampled per second, default is unlimited
+	}
+
+	// KeyStats memory and access frequency of a sampled key
+	KeyStats struct {
+		Key   string `json:"key"`
+		Type  string `json:"type"`
+		Bytes int64  `json:"bytes"`
+		Freq  int64  `json:"freq"`
+	}
+
+	// KeyReport sampled keys of a prefix
+	KeyReport struct {
+		Prefix  string     `json:"prefix"`
+		Keys    int64      `json:"keys"`
+		Bytes   int64      `json:"bytes"`
+		Largest []KeyStats `json:"largest"`
+		Hottest []KeyStats `json:"hottest,omitempty"` // empty unless maxmemory-policy is LFU
+	}
+)
+
+// errSampled stop scanning when enough keys sampled
+var errSampled = errors.New("redis: keys sampled")
+
+// AnalyzeKeys sample keys by SCAN, MEMORY USAGE and OBJECT FREQ, report the largest and hottest keys by prefix.
+// OBJECT FREQ requires an LFU maxmemory-policy, hottest keys are omitted otherwise.
+// Reports are sorted by sampled bytes desc, keys matching no prefix are reported as "other".
+func (r *Redis) AnalyzeKeys(ctx context.Context, opts ...AnalyzeOptions) ([]KeyReport, error) {
+	opt := AnalyzeOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	if opt.Pattern == "" {
+		opt.Pattern = "*"
+	}
+	if opt.Sample <= 0 {
+		opt.Sample = 10000
+	}
+	if opt.Top <= 0 {
+		opt.Top = 10
+	}
+	if opt.Prefixes == nil {
+		opt.Prefixes = r.AnalyzePrefixes
+	}
+
+	prefixes := append([]string(nil), opt.Prefixes...)
+	sort.Slice(prefixes, func(i, j int) bool {
+		return len(prefixes[i]) > len(prefixes[j])
+	})
+
+	var (
+		reports = map[string]*KeyReport{}
+		batch   = make([]string, 0, 100)
+		sampled int
+		lfu     = true
+		start   = time.Now()
+	)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		stats, freqOK, err := r.keyStats(batch, lfu)
+		batch = batch[:0]
+		if err != nil {
+			return err
+		}
+		lfu = lfu && freqOK
+
+		for _, s := range stats {
+			prefix := matchPrefix(prefixes, s.Key)
+			report, ok := reports[prefix]
+			if !ok {
+				report = &KeyReport{Prefix: prefix}
+				reports[prefix] = report
+			}
+
+			report.Keys++
+			report.Bytes += s.Bytes
+			report.Largest = topKeys(report.Largest, s, opt.Top, func(a, b KeyStats) bool { return a.Bytes > b.Bytes })
+			if lfu {
+				report.Hottest = topKeys(report.Hottest, s, opt.Top, func(a, b KeyStats) bool { return a.Freq > b.Freq })
+			}
+		}
+
+		if opt.Rate > 0 {
+			expected := time.Duration(float64(sampled) / float64(opt.Rate) * float64(time.Second))
+			if wait := expected - time.Since(start); wait > 0 {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(wait):
+				}
+			}
+		}
+
+		return nil
+	}
+
+	err := r.ScanKeys(ctx, opt.Pattern, 100, func(key string) error {
+		if sampled >= opt.Sample {
+			return errSampled
+		}
+		sampled++
+
+		batch = append(batch, key)
+		if len(batch) >= cap(batch) {
+			return flush()
+		}
+
+		return nil
+	})
+	if err == nil || err == errSampled {
+		err = flush()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]KeyReport, 0, len(reports))
+	for _, report := range reports {
+		if !lfu {
+			report.Hottest = nil
+		}
+		result = append(result, *report)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Bytes > result[j].Bytes
+	})
+
+	return result, nil
+}
+
+// keyStats memory usage, type and access frequency of keys, freqOK is false when OBJECT FREQ is not available
+func (r *Redis) keyStats(keys []string, freq bool) (stats []KeyStats, freqOK bool, err error) {
+	usages := make([]*redis.IntCmd, len(keys))
+	types := make([]*redis.StatusCmd, len(keys))
+	freqs := make([]*redis.Cmd, len(keys))
+
+	// keys may be in different slots, the cluster pipeline sends them to their nodes
+	_, err = r.Pipelined(func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			usages[i] = pipe.MemoryUsage(key)
+			types[i] = pipe.Type(key)
+			if freq {
+				freqs[i] = pipe.Do("object", "freq", key)
+			}
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil && !strings.Contains(err.Error(), "LFU") {
+		return nil, false, err
+	}
+
+	freqOK = freq
+	stats = make([]KeyStats, 0, len(keys))
+	for i, key := range keys {
+		// key expired or deleted since scanned
+		if usages[i].Err() != nil {
+			continue
+		}
+
+		s := KeyStats{Key: key, Type: types[i].Val(), Bytes: usages[i].Val()}
+		if freqOK {
+			if n, err := freqs[i].Int64(); err == nil {
+				s.Freq = n
+			} else if strings.Contains(err.Error(), "LFU") {
+				freqOK = false
+			}
+		}
+
+		stats = append(stats, s)
+	}
+
+	return stats, freqOK, nil
+}
+
+// topKeys insert s into top keys sorted by less, keep at most n keys
+func topKeys(top []KeyStats, s KeyStats, n int, less func(a, b KeyStats) bool) []KeyStats {
+	i := sort.Search(len(top), func(i int) bool {
+		return less(s, top[i])
+	})
+	if i >= n {
+		return top
+	}
+
+	top = append(top, KeyStats{})
+	copy(top[i+1:], top[i:])
+	top[i] = s
+
+	if len(top) > n {
+		top = top[:n]
+	}
+
+	return top
+}
+
+// analyzeKeys export largest key, hottest key frequency and sampled bytes by prefix periodically until shutdown
+func (r *Redis) analyzeKeys(done <-chan struct{}) {
+	labels := []string{"redis_instance", "prefix"}
+	largest := mustRegister(prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: r.metrics.Namespace,
+			Subsystem: r.metrics.Subsystem,
+			Name:      "redis_key_largest_bytes",
+			Help:      "redis memory usage of the largest sampled key by prefix",
+		},
+		labels,
+	)).(*prometheus.GaugeVec)
+	hottest := mustRegister(prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: r.metrics.Namespace,
+			Subsystem: r.metrics.Subsystem,
+			Name:      "redis_key_hottest_freq",
+			Help:      "redis logarithmic access frequency of the hottest sampled key by prefix, requires LFU maxmemory-policy",
+		},
+		labels,
+	)).(*prometheus.GaugeVec)
+	sampled := mustRegister(prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: r.metrics.Namespace,
+			Subsystem: r.metrics.Subsystem,
+			Name:      "redis_key_sampled_bytes",
+			Help:      "redis memory usage of sampled keys by prefix",
+		},
+		labels,
+	)).(*prometheus.GaugeVec)
+
+	ticker := time.NewTicker(r.AnalyzeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		if r.Paused() {
+			continue
+		}
+
+		reports, err := r.AnalyzeKeys(context.Background(), AnalyzeOptions{Sample: r.AnalyzeSample, Top: 1, Rate: r.AnalyzeRate})
+		if err != nil {
+			r.logf("analyze keys: %v", err)
+			continue
+		}
+
+		for _, report := range reports {
+			sampled.WithLabelValues(r.Instance(), report.Prefix).Set(float64(report.Bytes))
+			if len(report.Largest) > 0 {
+				largest.WithLabelValues(r.Instance(), report.Prefix).Set(float64(report.Largest[0].Bytes))
+			}
+			if len(report.Hottest) > 0 {
+				hottest.WithLabelValues(r.Instance(), report.Prefix).Set(float64(report.Hottest[0].Freq))
+			}
+		}
+	}
+}
+
+func (r *Redis) handleKeys(w http.ResponseWriter, req *http.Request) {
+	opt := AnalyzeOptions{Pattern: req.FormValue("pattern")}
+	opt.Sample, _ = strconv.Atoi(req.FormValue("sample"))
+	opt.Top, _ = strconv.Atoi(req.FormValue("top"))
+	if opt.Rate = r.AnalyzeRate; opt.Rate <= 0 {
+		opt.Rate = 1000
+	}
+
+	reports, err := r.AnalyzeKeys(req.Context(), opt)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, reports)
+}
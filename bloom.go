@@ -0,0 +1,301 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	moduleUnknown int32 = iota
+	moduleLoaded
+	moduleMissing
+
+	defaultBloomCapacity  = 100
+	defaultBloomErrorRate = 0.01
+	// bitmaps are limited to 2^32 bits
+	maxBloomBits = 1 << 32
+)
+
+var (
+	// ErrFilterUnsupported operation is not supported by the bitmap fallback
+	ErrFilterUnsupported = errors.New("redis: filter operation requires the RedisBloom module")
+	// ErrFilterExists filter is already reserved
+	ErrFilterExists = errors.New("redis: filter exists")
+
+	// bloom filter on a bitmap. KEYS[1]: bitmap, KEYS[2]: meta.
+	// ARGV[1]: bits, ARGV[2]: hashes, ARGV[3]: 1 add, 0 exists, ARGV[4...]: h1, h2 of each item.
+	// Return 1 for each item added (add) or found (exists).
+	bloomScript = newScript(`
+local m = tonumber(redis.call('hget', KEYS[2], 'm'))
+local k = tonumber(redis.call('hget', KEYS[2], 'k'))
+local add = ARGV[3] == '1'
+if not m then
+	m, k = tonumber(ARGV[1]), tonumber(ARGV[2])
+	if add then
+		redis.call('hset', KEYS[2], 'm', m, 'k', k)
+	end
+end
+local res = {}
+for i = 4, #ARGV, 2 do
+	local h1, h2 = tonumber(ARGV[i]), tonumber(ARGV[i + 1])
+	local hit = add and 0 or 1
+	for j = 0, k - 1 do
+		local pos = (h1 + j * h2) % m
+		if add then
+			if redis.call('setbit', KEYS[1], pos, 1) == 0 then
+				hit = 1
+			end
+		elseif redis.call('getbit', KEYS[1], pos) == 0 then
+			hit = 0
+			break
+		end
+	end
+	res[#res + 1] = hit
+end
+return res
+`)
+
+	// KEYS[1]: meta. ARGV[1]: bits, ARGV[2]: hashes
+	bloomReserveScript = newScript(`
+if redis.call('exists', KEYS[1]) == 1 then
+	return 0
+end
+redis.call('hset', KEYS[1], 'm', ARGV[1], 'k', ARGV[2])
+return 1
+`)
+)
+
+// BFReserve create bloom filter key for capacity items at errorRate false positives.
+// Without the RedisBloom module the filter is a bitmap at key "{key}" with its parameters at "{key}:meta", the same for filters below.
+func (r *Redis) BFReserve(ctx context.Context, key string, errorRate float64, capacity int64) error {
+	if r.bloomModule() {
+		err := r.DoContext(ctx, "bf.reserve", key, errorRate, capacity).Err()
+		if !r.moduleMissing(err) {
+			if err != nil && strings.Contains(err.Error(), "exists") {
+				return ErrFilterExists
+			}
+			return err
+		}
+	}
+
+	return r.reserveBitmap(ctx, key, errorRate, capacity)
+}
+
+// BFAdd add item to bloom filter key, added is false when it may have been added before.
+// The filter is created with capacity 100 and error rate 0.01 when it does not exist.
+func (r *Redis) BFAdd(ctx context.Context, key string, item interface{}) (added bool, err error) {
+	res, err := r.BFMAdd(ctx, key, item)
+	if err != nil {
+		return false, err
+	}
+
+	return res[0], nil
+}
+
+// BFMAdd add items to bloom filter key
+func (r *Redis) BFMAdd(ctx context.Context, key string, items ...interface{}) ([]bool, error) {
+	return r.filterMulti(ctx, "bf.madd", key, true, items)
+}
+
+// BFExists whether item may be in bloom filter key, false is definite.
+func (r *Redis) BFExists(ctx context.Context, key string, item interface{}) (bool, error) {
+	res, err := r.BFMExists(ctx, key, item)
+	if err != nil {
+		return false, err
+	}
+
+	return res[0], nil
+}
+
+// BFMExists whether items may be in bloom filter key
+func (r *Redis) BFMExists(ctx context.Context, key string, items ...interface{}) ([]bool, error) {
+	return r.filterMulti(ctx, "bf.mexists", key, false, items)
+}
+
+// CFReserve create cuckoo filter key for capacity items.
+// The bitmap fallback is a bloom filter at error rate 0.01, which can not delete items.
+func (r *Redis) CFReserve(ctx context.Context, key string, capacity int64) error {
+	if r.bloomModule() {
+		err := r.DoContext(ctx, "cf.reserve", key, capacity).Err()
+		if !r.moduleMissing(err) {
+			if err != nil && strings.Contains(err.Error(), "exists") {
+				return ErrFilterExists
+			}
+			return err
+		}
+	}
+
+	return r.reserveBitmap(ctx, key, defaultBloomErrorRate, capacity)
+}
+
+// CFAddNX add item to cuckoo filter key unless it may have been added, added is false then.
+func (r *Redis) CFAddNX(ctx context.Context, key string, item interface{}) (added bool, err error) {
+	if r.bloomModule() {
+		n, err := r.DoContext(ctx, "cf.addnx", key, item).Int64()
+		if !r.moduleMissing(err) {
+			return n == 1, err
+		}
+	}
+
+	res, err := r.bitmapFilter(ctx, key, true, []interface{}{item})
+	if err != nil {
+		return false, err
+	}
+
+	return res[0], nil
+}
+
+// CFExists whether item may be in cuckoo filter key, false is definite.
+func (r *Redis) CFExists(ctx context.Context, key string, item interface{}) (bool, error) {
+	if r.bloomModule() {
+		n, err := r.DoContext(ctx, "cf.exists", key, item).Int64()
+		if !r.moduleMissing(err) {
+			return n == 1, err
+		}
+	}
+
+	res, err := r.bitmapFilter(ctx, key, false, []interface{}{item})
+	if err != nil {
+		return false, err
+	}
+
+	return res[0], nil
+}
+
+// CFDel delete one occurrence of item from cuckoo filter key, deleted is false when it is not found.
+// It returns ErrFilterUnsupported without the RedisBloom module.
+func (r *Redis) CFDel(ctx context.Context, key string, item interface{}) (deleted bool, err error) {
+	if r.bloomModule() {
+		n, err := r.DoContext(ctx, "cf.del", key, item).Int64()
+		if !r.moduleMissing(err) {
+			return n == 1, err
+		}
+	}
+
+	return false, ErrFilterUnsupported
+}
+
+func (r *Redis) filterMulti(ctx context.Context, command, key string, add bool, items []interface{}) ([]bool, error) {
+	if len(items) == 0 {
+		return nil, fmt.Errorf("redis: %s %s: no items", command, key)
+	}
+
+	if r.bloomModule() {
+		args := append([]interface{}{command, key}, items...)
+		v, err := r.DoContext(ctx, args...).Result()
+		if !r.moduleMissing(err) {
+			if err != nil {
+				return nil, err
+			}
+			return bools(v)
+		}
+	}
+
+	return r.bitmapFilter(ctx, key, add, items)
+}
+
+func (r *Redis) reserveBitmap(ctx context.Context, key string, errorRate float64, capacity int64) error {
+	m, k := bloomParams(errorRate, capacity)
+
+	n, err := bloomReserveScript.run(ctx, r, []string{bloomKey(key) + ":meta"}, m, k).Int64()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrFilterExists
+	}
+
+	return nil
+}
+
+func (r *Redis) bitmapFilter(ctx context.Context, key string, add bool, items []interface{}) ([]bool, error) {
+	m, k := bloomParams(defaultBloomErrorRate, defaultBloomCapacity)
+
+	mode := "0"
+	if add {
+		mode = "1"
+	}
+
+	args := make([]interface{}, 0, 3+2*len(items))
+	args = append(args, m, k, mode)
+	for _, item := range items {
+		h := fnv.New64a()
+		fmt.Fprint(h, item)
+		sum := h.Sum64()
+
+		// double hashing, h2 is odd so positions do not repeat with power of two sizes
+		args = append(args, uint32(sum), uint32(sum>>32)|1)
+	}
+
+	v, err := bloomScript.run(ctx, r, []string{bloomKey(key), bloomKey(key) + ":meta"}, args...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	return bools(v)
+}
+
+// bloomModule whether filter commands of the RedisBloom module may be used
+func (r *Redis) bloomModule() bool {
+	return atomic.LoadInt32(&r.bloom) != moduleMissing
+}
+
+// moduleMissing record whether err reports the RedisBloom module is not loaded
+func (r *Redis) moduleMissing(err error) bool {
+	if err != nil && strings.HasPrefix(err.Error(), "ERR unknown command") {
+		if atomic.SwapInt32(&r.bloom, moduleMissing) != moduleMissing {
+			r.logf("RedisBloom module is not loaded, filters fall back to bitmaps")
+		}
+		return true
+	}
+
+	if err == nil {
+		atomic.CompareAndSwapInt32(&r.bloom, moduleUnknown, moduleLoaded)
+	}
+
+	return false
+}
+
+// bloomParams bits and hashes of a bloom filter
+func bloomParams(errorRate float64, capacity int64) (m, k int64) {
+	if errorRate <= 0 || errorRate >= 1 {
+		errorRate = defaultBloomErrorRate
+	}
+	if capacity <= 0 {
+		capacity = defaultBloomCapacity
+	}
+
+	bits := math.Ceil(-float64(capacity) * math.Log(errorRate) / (math.Ln2 * math.Ln2))
+	if bits > maxBloomBits {
+		bits = maxBloomBits
+	}
+
+	m = int64(bits)
+	k = int64(math.Max(1, math.Round(bits/float64(capacity)*math.Ln2)))
+
+	return m, k
+}
+
+func bloomKey(key string) string {
+	return "{" + key + "}"
+}
+
+func bools(v interface{}) ([]bool, error) {
+	values, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("redis: unexpected reply %T", v)
+	}
+
+	res := make([]bool, len(values))
+	for i, value := range values {
+		n, _ := value.(int64)
+		res[i] = n == 1
+	}
+
+	return res, nil
+}
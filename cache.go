@@ -0,0 +1,317 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type (
+	// Cache helper storing encoded values, values are compressed by the box compression config
+	// or processed by the policy of the declared namespace of the key.
+	Cache struct {
+		redis  *Redis
+		prefix string
+		codec  Codec
+		chains []codecChain
+
+		integrity   []integrityPolicy
+		corruptions int64
+
+		ttlJitter float64
+
+		missTTL         time.Duration
+		negativeHits    int64
+		negativeCounter *prometheus.CounterVec
+
+		revalidating sync.Map // keys being refreshed in background by GetOrLoadStale
+	}
+
+	codecChain struct {
+		prefix  string
+		primary Codec
+		legacy  []Codec
+	}
+
+	// Codec encode and decode cache values
+	Codec interface {
+		Marshal(v interface{}) ([]byte, error)
+		Unmarshal(data []byte, v interface{}) error
+	}
+
+	// Loader load value from the source of truth when the cache missed
+	Loader func(ctx context.Context) (interface{}, error)
+
+	jsonCodec struct{}
+)
+
+var (
+	// JSONCodec encoding/json codec, the default codec of cache
+	JSONCodec Codec = jsonCodec{}
+)
+
+// NewCache new a cache helper, keys are prefixed by prefix, codec is JSONCodec if it is nil.
+func NewCache(r *Redis, prefix string, codec Codec) *Cache {
+	if codec == nil {
+		codec = JSONCodec
+	}
+
+	return &Cache{
+		redis:  r,
+		prefix: prefix,
+		codec:  codec,
+	}
+}
+
+// Get decode value of key into v, ok is false when key is missed or cached as not found.
+func (c *Cache) Get(ctx context.Context, key string, v interface{}) (ok bool, err error) {
+	ok, err = c.lookup(ctx, key, v)
+	if err == ErrNotFound {
+		return false, nil
+	}
+
+	return ok, err
+}
+
+// lookup as Get, ErrNotFound is returned for keys cached as not found
+func (c *Cache) lookup(ctx context.Context, key string, v interface{}) (ok bool, err error) {
+	// DoContext coalesces concurrent reads of hot keys
+	text, err := c.redis.DoContext(ctx, "get", c.key(key)).Text()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if text == missMarker {
+		c.countNegativeHit()
+		return false, ErrNotFound
+	}
+
+	data := []byte(text)
+
+	err = c.decodeKey(ctx, key, data, v)
+	if err == ErrCorrupted && c.failOpen(key) {
+		c.redis.DoContext(ctx, "del", c.key(key))
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// SetCodecChain decode keys starting with prefix by primary, fall back to legacy codecs in order.
+// Codec chains take precedence over the codec of the declared namespace.
+// Values decoded by a legacy codec are rewritten by primary, so formats migrate online.
+// Keys of other prefixes use the cache codec. Call it before using the cache.
+func (c *Cache) SetCodecChain(prefix string, primary Codec, legacy ...Codec) {
+	c.chains = append(c.chains, codecChain{prefix: prefix, primary: primary, legacy: legacy})
+
+	sort.SliceStable(c.chains, func(i, j int) bool {
+		return len(c.chains[i].prefix) > len(c.chains[j].prefix)
+	})
+}
+
+// Set encode v and store it with ttl, tagged by WithTags.
+func (c *Cache) Set(ctx context.Context, key string, v interface{}, ttl time.Duration, opts ...SetOption) error {
+	var options setOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if len(options.tags) == 0 {
+		_, err := c.store(ctx, key, v, ttl)
+		return err
+	}
+
+	data, err := c.encode(key, v)
+	if err != nil {
+		return err
+	}
+
+	return c.setTagged(ctx, key, data, c.ttl(ttl), options.tags)
+}
+
+// SetTTLJitter randomize ttl of writes by ±fraction (0-1) of it, so values loaded together do not expire together.
+// Call it before using the cache.
+func (c *Cache) SetTTLJitter(fraction float64) {
+	c.ttlJitter = fraction
+}
+
+// Delete keys.
+func (c *Cache) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, 0, len(keys)+1)
+	args = append(args, "del")
+	for _, key := range keys {
+		args = append(args, c.key(key))
+	}
+
+	return c.redis.DoContext(ctx, args...).Err()
+}
+
+// GetOrLoad get value of key into v, load and store it with ttl when missed.
+// Loaders are rate limited and ttl is reduced when redis is warming up after a cold start.
+// Not found results of loaders are cached for the miss ttl, ErrNotFound is returned for them.
+func (c *Cache) GetOrLoad(ctx context.Context, key string, v interface{}, ttl time.Duration, loader Loader) error {
+	if ok, err := c.lookup(ctx, key, v); err != nil || ok {
+		return err
+	}
+
+	if err := c.redis.waitLoad(ctx); err != nil {
+		return err
+	}
+
+	val, err := loader(ctx)
+	if err != nil {
+		return c.storeMiss(ctx, key, err)
+	}
+
+	data, err := c.store(ctx, key, val, ttl)
+	if err != nil {
+		return err
+	}
+
+	return c.decode(key, data, v)
+}
+
+// store encode v and store it with ttl, the encoded data is returned
+func (c *Cache) store(ctx context.Context, key string, v interface{}, ttl time.Duration) ([]byte, error) {
+	data, err := c.encode(key, v)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, c.redis.ProcessContext(ctx, redis.NewStatusCmd(setArgs(c.key(key), data, c.ttl(ttl))...))
+}
+
+func (c *Cache) encode(key string, v interface{}) ([]byte, error) {
+	primary, _ := c.codecs(key)
+
+	data, err := primary.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.wrap(key, data)
+}
+
+func (c *Cache) decode(key string, raw []byte, v interface{}) error {
+	primary, _ := c.codecs(key)
+
+	data, err := c.unwrap(key, raw)
+	if err != nil {
+		return err
+	}
+
+	return primary.Unmarshal(data, v)
+}
+
+// wrap encoded data: compress and encrypt by the namespace policy then seal
+func (c *Cache) wrap(key string, data []byte) ([]byte, error) {
+	data, err := c.redis.encode(c.key(key), data)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.seal(key, data), nil
+}
+
+// unwrap stored value: verify then decrypt and decompress
+func (c *Cache) unwrap(key string, raw []byte) ([]byte, error) {
+	data, err := c.unseal(key, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.redis.decode(data)
+}
+
+// decodeKey decode stored value of key, rewrite it by the primary codec when decoded by a legacy codec
+func (c *Cache) decodeKey(ctx context.Context, key string, raw []byte, v interface{}) error {
+	primary, legacy := c.codecs(key)
+
+	data, err := c.unwrap(key, raw)
+	if err != nil {
+		return err
+	}
+
+	err = primary.Unmarshal(data, v)
+	if err == nil || len(legacy) == 0 {
+		return err
+	}
+
+	for _, codec := range legacy {
+		if codec.Unmarshal(data, v) != nil {
+			continue
+		}
+
+		if rewritten, err := c.encode(key, v); err == nil {
+			casScript.run(ctx, c.redis, []string{c.key(key)}, raw, rewritten)
+		}
+
+		return nil
+	}
+
+	return err
+}
+
+func (c *Cache) codecs(key string) (Codec, []Codec) {
+	for _, chain := range c.chains {
+		if strings.HasPrefix(key, chain.prefix) {
+			return chain.primary, chain.legacy
+		}
+	}
+
+	if policy := c.redis.schemas.namespace(c.key(key)); policy != nil && policy.Codec != "" {
+		if codec, ok := lookupCodec(policy.Codec); ok {
+			return codec, nil
+		}
+	}
+
+	return c.codec, nil
+}
+
+// ttl of writes: reduced in warm-up, then jittered
+func (c *Cache) ttl(ttl time.Duration) time.Duration {
+	return jitterTTL(c.redis.warmupTTL(ttl), c.ttlJitter)
+}
+
+func (c *Cache) key(key string) string {
+	if c.prefix == "" {
+		return key
+	}
+
+	return c.prefix + ":" + key
+}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// setArgs SET command args with optional ttl
+func setArgs(key string, value interface{}, ttl time.Duration) []interface{} {
+	args := []interface{}{"set", key, value}
+
+	if ttl > 0 {
+		if ttl < time.Second || ttl%time.Second != 0 {
+			return append(args, "px", int64(ttl/time.Millisecond))
+		}
+		return append(args, "ex", int64(ttl/time.Second))
+	}
+
+	return args
+}
@@ -0,0 +1,50 @@
+package redis
+
+import (
+	"context"
+	"runtime"
+	"strings"
+)
+
+type (
+	callerKey struct{}
+)
+
+// WithCaller label command metrics of ctx by the logical caller, e.g. a service or feature name
+func WithCaller(ctx context.Context, caller string) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// CallerFrom caller of ctx, empty when it is not tagged
+func CallerFrom(ctx context.Context) string {
+	caller, _ := ctx.Value(callerKey{}).(string)
+
+	return caller
+}
+
+// withDetectedCaller tag ctx by the calling function when callerDetect is enabled and ctx is not tagged
+func (r *Redis) withDetectedCaller(ctx context.Context) context.Context {
+	if !r.CallerDetect || CallerFrom(ctx) != "" {
+		return ctx
+	}
+
+	return WithCaller(ctx, detectCaller())
+}
+
+// detectCaller first function out of this package and go-redis, e.g. checkout.(*Service).Pay
+func detectCaller() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	for {
+		frame, more := frames.Next()
+		fn := frame.Function
+		if fn != "" && !strings.HasPrefix(fn, "github.com/boxgo/redis") && !strings.HasPrefix(fn, "github.com/go-redis/redis") {
+			return fn[strings.LastIndex(fn, "/")+1:]
+		}
+		if !more {
+			return ""
+		}
+	}
+}
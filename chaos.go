@@ -0,0 +1,200 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	// chaosHook fault injection for resilience testing, added only when chaos is enabled
+	chaosHook struct {
+		r *Redis
+	}
+
+	// ChaosProfile named bundle of faults, so that game-day exercises are the same on every service
+	ChaosProfile struct {
+		Percent      float64       `config:"percent" help:"Percent (0-100) of commands affected"`
+		Latency      time.Duration `config:"latency" help:"Base latency injected into affected commands"`
+		Jitter       time.Duration `config:"jitter" help:"Extra latency uniformly distributed in [0, jitter)"`
+		SpikeRate    float64       `config:"spikeRate" help:"Fraction (0-1) of affected commands delayed by spike instead"`
+		Spike        time.Duration `config:"spike" help:"Latency of spikes, e.g. a stalled node"`
+		ErrorRate    float64       `config:"errorRate" help:"Fraction (0-1) of affected commands failed before sent"`
+		DropRate     float64       `config:"dropRate" help:"Fraction (0-1) of affected commands whose response is dropped"`
+		Commands     []string      `config:"commands" help:"Only affect these commands, default is all"`
+		commandIndex map[string]bool
+	}
+
+	chaosDropKey struct{}
+)
+
+const (
+	// ToggleChaosProfile active chaos profile, empty uses chaos* config
+	ToggleChaosProfile = "chaosProfile"
+
+	// ChaosDegradedAZ one availability zone is slow: moderate jittered latency, rare stalls and errors
+	ChaosDegradedAZ = "degraded-az"
+	// ChaosFailoverDrill primary is failing over: most commands fail or stall for seconds
+	ChaosFailoverDrill = "failover-drill"
+	// ChaosSlowNetwork every command is slower, no errors
+	ChaosSlowNetwork = "slow-network"
+	// ChaosFlaky few commands drop responses, exercising retries and idempotency
+	ChaosFlaky = "flaky"
+)
+
+var (
+	// ErrChaos error injected by chaos mode
+	ErrChaos = errors.New("redis: chaos injected error")
+	// ErrChaosDropped response dropped by chaos mode, the command may have been executed
+	ErrChaosDropped = errors.New("redis: chaos dropped response")
+
+	// builtin profiles, chaosProfiles config overrides them by name
+	chaosProfiles = map[string]ChaosProfile{
+		ChaosDegradedAZ: {
+			Percent:   30,
+			Latency:   20 * time.Millisecond,
+			Jitter:    30 * time.Millisecond,
+			SpikeRate: 0.02,
+			Spike:     500 * time.Millisecond,
+			ErrorRate: 0.01,
+		},
+		ChaosFailoverDrill: {
+			Percent:   100,
+			Spike:     2 * time.Second,
+			SpikeRate: 0.2,
+			ErrorRate: 0.6,
+			DropRate:  0.05,
+		},
+		ChaosSlowNetwork: {
+			Percent: 100,
+			Latency: 5 * time.Millisecond,
+			Jitter:  10 * time.Millisecond,
+		},
+		ChaosFlaky: {
+			Percent:  5,
+			DropRate: 0.5,
+		},
+	}
+)
+
+// applyChaosProfile select profile name, empty uses chaos* config
+func (r *Redis) applyChaosProfile(name string) error {
+	if name == "" {
+		r.chaosProfile.Store(&ChaosProfile{
+			Percent:   r.ChaosPercent,
+			Latency:   r.ChaosLatency,
+			ErrorRate: r.ChaosErrorRate,
+			DropRate:  r.ChaosDropRate,
+		})
+		return nil
+	}
+
+	profile, ok := r.ChaosProfiles[name]
+	if !ok {
+		if profile, ok = chaosProfiles[name]; !ok {
+			return fmt.Errorf("unknown chaos profile")
+		}
+	}
+
+	if len(profile.Commands) > 0 {
+		profile.commandIndex = make(map[string]bool, len(profile.Commands))
+		for _, name := range profile.Commands {
+			profile.commandIndex[strings.ToLower(name)] = true
+		}
+	}
+
+	r.chaosProfile.Store(&profile)
+	r.logf("chaos profile %q selected", name)
+
+	return nil
+}
+
+func (h chaosHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return h.before(ctx, cmd)
+}
+
+// AfterProcess fail dropped cmd, the error is set on cmd so that later hooks see it
+func (h chaosHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	err := h.after(ctx)
+	if err != nil {
+		cmd.SetErr(err)
+	}
+
+	return err
+}
+
+func (h chaosHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	var cmd redis.Cmder
+	if len(cmds) > 0 {
+		cmd = cmds[0]
+	}
+
+	return h.before(ctx, cmd)
+}
+
+func (h chaosHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	err := h.after(ctx)
+	if err != nil {
+		for _, cmd := range cmds {
+			cmd.SetErr(err)
+		}
+	}
+
+	return err
+}
+
+func (h chaosHook) before(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	p, _ := h.r.chaosProfile.Load().(*ChaosProfile)
+	if p == nil || rand.Float64()*100 >= p.Percent {
+		return ctx, nil
+	}
+
+	if p.commandIndex != nil && (cmd == nil || !p.commandIndex[cmd.Name()]) {
+		return ctx, nil
+	}
+
+	if latency := p.latency(); latency > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx, ctx.Err()
+		case <-time.After(latency):
+		}
+	}
+
+	n := rand.Float64()
+	switch {
+	case n < p.ErrorRate:
+		return ctx, ErrChaos
+	case n < p.ErrorRate+p.DropRate:
+		return context.WithValue(ctx, chaosDropKey{}, true), nil
+	}
+
+	return ctx, nil
+}
+
+func (h chaosHook) after(ctx context.Context) error {
+	if ctx.Value(chaosDropKey{}) != nil {
+		return ErrChaosDropped
+	}
+
+	return nil
+}
+
+// latency injected into an affected command
+func (p *ChaosProfile) latency() time.Duration {
+	if p.SpikeRate > 0 && rand.Float64() < p.SpikeRate {
+		return p.Spike
+	}
+
+	latency := p.Latency
+	if p.Jitter > 0 {
+		latency += time.Duration(rand.Int63n(int64(p.Jitter)))
+	}
+
+	return latency
+}
@@ -0,0 +1,91 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	// coalescer in-flight reads by command
+	coalescer struct {
+		mu      sync.Mutex
+		flights map[string]*flight
+	}
+
+	flight struct {
+		done chan struct{}
+		cmd  redis.Cmder
+	}
+)
+
+// coalesce run read once for concurrent identical commands of keys matching coalesce patterns, others wait and share its cmd.
+// Reads of ctx forcing the master or reading its own writes are not coalesced, they must not share a replica read.
+// Waiting callers are bounded by their own ctx, failed builds their cmd failing with its error, and they read
+// by themselves when the first caller's ctx is done.
+func (r *Redis) coalesce(ctx context.Context, args []interface{}, read func() redis.Cmder, failed func(err error) redis.Cmder) redis.Cmder {
+	if len(r.Coalesce) == 0 || len(args) < 2 || !r.rolledOut(FeatureCoalesce) || !r.coalescible(fmt.Sprint(args[1])) ||
+		ctx.Value(forceMasterKey{}) != nil || ctx.Value(consistencyKey{}) != nil {
+		return read()
+	}
+
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		parts[i] = fmt.Sprint(arg)
+	}
+	parts[0] = strings.ToLower(parts[0])
+	key := strings.Join(parts, "\x00")
+
+	c := &r.coalescer
+	c.mu.Lock()
+	if f, ok := c.flights[key]; ok {
+		c.mu.Unlock()
+
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			return failed(ctx.Err())
+		}
+
+		if err := f.cmd.Err(); errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return read()
+		}
+
+		if r.coalesced != nil {
+			r.coalesced.WithLabelValues(r.Instance(), parts[0]).Inc()
+		}
+		return f.cmd
+	}
+
+	if c.flights == nil {
+		c.flights = map[string]*flight{}
+	}
+	f := &flight{done: make(chan struct{})}
+	c.flights[key] = f
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.flights, key)
+		c.mu.Unlock()
+		close(f.done)
+	}()
+
+	f.cmd = read()
+
+	return f.cmd
+}
+
+func (r *Redis) coalescible(key string) bool {
+	for _, pattern := range r.Coalesce {
+		if matchKey(pattern, key) {
+			return true
+		}
+	}
+
+	return false
+}
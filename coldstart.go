@@ -0,0 +1,177 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// pacer let at most one caller through per interval
+	pacer struct {
+		mu   sync.Mutex
+		next time.Time
+	}
+)
+
+const (
+	defaultColdStartCheck = 10 * time.Second
+	defaultWarmupDuration = 5 * time.Minute
+
+	// coldStartMarkerTTL ttl of the marker key, refreshed by every check of every process
+	coldStartMarkerTTL = 24 * time.Hour
+)
+
+var (
+	// get the creation time of the marker, create it now when it is missing. KEYS[1]: marker. ARGV: now ms, ttl ms
+	coldStartMarkerScript = newScript(`
+local created = redis.call('get', KEYS[1])
+if not created then
+	created = ARGV[1]
+	redis.call('set', KEYS[1], created, 'px', ARGV[2])
+else
+	redis.call('pexpire', KEYS[1], ARGV[2])
+end
+return created
+`)
+)
+
+// ColdStart redis is detected restarted empty recently, the cache is in warm-up mode
+func (r *Redis) ColdStart() bool {
+	return time.Now().UnixNano() < atomic.LoadInt64(&r.warmUntil)
+}
+
+// watchColdStart check redis for cold start until shutdown
+func (r *Redis) watchColdStart(done <-chan struct{}) {
+	interval := r.ColdStartCheck
+	if interval <= 0 {
+		interval = defaultColdStartCheck
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.checkColdStart(context.Background()); err != nil {
+			r.logf("cold start check: %v", err)
+		}
+
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkColdStart start warm-up when the marker key is created recently or uptime is below coldStartUptime.
+// Each process decides by itself: the marker holds its creation time, a missing marker is created by the first
+// process checking, and every process seeing a marker created within warmupDuration warms up until then.
+func (r *Redis) checkColdStart(ctx context.Context) error {
+	cold := false
+
+	duration := r.WarmupDuration
+	if duration <= 0 {
+		duration = defaultWarmupDuration
+	}
+	warmUntil := time.Now().Add(duration)
+
+	if r.ColdStartKey != "" {
+		created, err := coldStartMarkerScript.run(ctx, r, []string{r.ColdStartKey}, unixMilli(time.Now()), int64(coldStartMarkerTTL/time.Millisecond)).Int64()
+		if err != nil {
+			return err
+		}
+
+		// the same marker is detected once per process
+		if created != atomic.SwapInt64(&r.coldMarker, created) {
+			createdAt := time.Unix(0, created*int64(time.Millisecond))
+			if time.Since(createdAt) < duration {
+				cold = true
+				warmUntil = createdAt.Add(duration)
+			}
+		}
+	}
+
+	if r.ColdStartUptime > 0 {
+		info, err := r.DoContext(ctx, "info", "server").Text()
+		if err != nil {
+			return err
+		}
+
+		uptime, err := strconv.ParseInt(infoField(info, "uptime_in_seconds"), 10, 64)
+		if err != nil {
+			return err
+		}
+
+		// the same restart is detected once
+		restartAt := time.Now().Add(-time.Duration(uptime) * time.Second).Unix()
+		if time.Duration(uptime)*time.Second < r.ColdStartUptime && restartAt > atomic.LoadInt64(&r.restartAt)+1 {
+			atomic.StoreInt64(&r.restartAt, restartAt)
+			cold = true
+		}
+	}
+
+	if cold && r.Warmup && !r.ColdStart() {
+		atomic.StoreInt64(&r.warmUntil, warmUntil.UnixNano())
+		r.logf("cold start detected, cache warms up until %s", warmUntil.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// warmupTTL ttl of cache writes, reduced by warmupTTLFactor in warm-up
+func (r *Redis) warmupTTL(ttl time.Duration) time.Duration {
+	if ttl <= 0 || r.WarmupTTLFactor <= 0 || r.WarmupTTLFactor >= 1 || !r.ColdStart() {
+		return ttl
+	}
+
+	return time.Duration(float64(ttl) * r.WarmupTTLFactor)
+}
+
+// waitLoad wait for a loader slot in warm-up, loaders are limited to warmupLoadRate per second
+func (r *Redis) waitLoad(ctx context.Context) error {
+	if r.WarmupLoadRate <= 0 || !r.ColdStart() {
+		return nil
+	}
+
+	return r.loadPacer.wait(ctx, time.Second/time.Duration(r.WarmupLoadRate))
+}
+
+func (p *pacer) wait(ctx context.Context, interval time.Duration) error {
+	p.mu.Lock()
+	now := time.Now()
+	if p.next.Before(now) {
+		p.next = now
+	}
+	delay := p.next.Sub(now)
+	p.next = p.next.Add(interval)
+	p.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// infoField value of field in INFO reply
+func infoField(info, field string) string {
+	for _, line := range strings.Split(info, "\n") {
+		if strings.HasPrefix(line, field+":") {
+			return strings.TrimSpace(line[len(field)+1:])
+		}
+	}
+
+	return ""
+}
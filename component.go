@@ -0,0 +1,193 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/boxgo/box/minibox"
+)
+
+type (
+	// Component base of optional subsystem boxes, it depends on a *Redis box.
+	// Subsystem boxes are opt-in: mount them into the app to load their config and run their lifecycles.
+	// The limiter and the metrics collectors are not components, they stay part of the core *Redis box.
+	Component struct {
+		name  string
+		redis *Redis
+	}
+
+	// CacheBox cache helper as a box
+	CacheBox struct {
+		Prefix            string        `config:"prefix" help:"Key prefix of cache"`
+		IntegritySecret   string        `config:"integritySecret" help:"HMAC-SHA256 secret of values, CRC32 is used when it is empty"`
+		IntegrityPrefix   string        `config:"integrityPrefix" help:"Protect keys with this prefix, default is disabled"`
+		IntegrityFailOpen bool          `config:"integrityFailOpen" help:"Treat corrupted values as cache misses"`
+		TTLJitter         float64       `config:"ttlJitter" default:"0" help:"Randomize ttl of writes by this fraction (0-1) of it, default is 0 (exact ttl)"`
+		MissTTL           time.Duration `config:"missTTL" default:"0" help:"Cache not found results of loaders for this ttl, default is 0 (disabled)"`
+
+		Component
+		*Cache
+		codec Codec
+	}
+
+	// LockerBox locker as a box, prefix and ttl changes apply on restart
+	LockerBox struct {
+		Prefix string        `config:"prefix" help:"Key prefix of locks"`
+		TTL    time.Duration `config:"ttl" default:"10s" help:"Lock ttl, default is 10s"`
+
+		Component
+		*Locker
+	}
+
+	// QueueBox priority queue as a box, changes apply on restart
+	QueueBox struct {
+		Queue     string        `config:"queue" help:"Queue name, default is <name>"`
+		AgingRate float64       `config:"agingRate" default:"0" help:"Priority gained per second waited, default is 0 (strict priority)"`
+		MinPoll   time.Duration `config:"minPoll" default:"10ms" help:"Poll interval of Dequeue after an item, doubled while empty, default is 10ms"`
+		MaxPoll   time.Duration `config:"maxPoll" default:"1s" help:"Max poll interval of Dequeue, default is 1s"`
+
+		Component
+		*PriorityQueue
+	}
+
+	// RefreshBox refresh coordinator as a box, it stops listening when shutdown
+	RefreshBox struct {
+		Prefix string `config:"prefix" help:"Key and channel prefix of refresh coordination"`
+
+		Component
+		*RefreshCoordinator
+	}
+)
+
+// NewComponent new a component named name depending on r, it is listed in parts of r
+func NewComponent(name string, r *Redis) Component {
+	r.componentsMu.Lock()
+	r.components = append(r.components, name)
+	r.componentsMu.Unlock()
+
+	return Component{
+		name:  name,
+		redis: r,
+	}
+}
+
+// Name config prefix
+func (c *Component) Name() string {
+	return c.name
+}
+
+// Exts depend on redis box, it is loaded and served before the component
+func (c *Component) Exts() []minibox.MiniBox {
+	return []minibox.MiniBox{c.redis}
+}
+
+// Redis the depended redis
+func (c *Component) Redis() *Redis {
+	return c.redis
+}
+
+// NewCacheBox new a cache box, codec is JSONCodec if it is nil
+func NewCacheBox(name string, r *Redis, codec Codec) *CacheBox {
+	return &CacheBox{
+		Component: NewComponent(name, r),
+		codec:     codec,
+	}
+}
+
+// ConfigWillLoad config will load
+func (cb *CacheBox) ConfigWillLoad(context.Context) {
+
+}
+
+// ConfigDidLoad build cache from config
+func (cb *CacheBox) ConfigDidLoad(context.Context) {
+	cb.Cache = NewCache(cb.Component.redis, cb.Prefix, cb.codec)
+	cb.SetTTLJitter(cb.TTLJitter)
+	cb.SetMissTTL(cb.MissTTL)
+
+	if cb.IntegrityPrefix != "" {
+		cb.Protect(cb.IntegrityPrefix, IntegrityOptions{
+			Secret:   []byte(cb.IntegritySecret),
+			FailOpen: cb.IntegrityFailOpen,
+		})
+	}
+}
+
+// NewLockerBox new a locker box
+func NewLockerBox(name string, r *Redis) *LockerBox {
+	return &LockerBox{
+		Component: NewComponent(name, r),
+	}
+}
+
+// ConfigWillLoad config will load
+func (lb *LockerBox) ConfigWillLoad(context.Context) {
+
+}
+
+// ConfigDidLoad build locker from config
+func (lb *LockerBox) ConfigDidLoad(context.Context) {
+	if lb.Locker == nil {
+		lb.Locker = NewLocker(lb.Component.redis, lb.Prefix, lb.TTL)
+	}
+}
+
+// NewQueueBox new a priority queue box
+func NewQueueBox(name string, r *Redis) *QueueBox {
+	return &QueueBox{
+		Component: NewComponent(name, r),
+	}
+}
+
+// ConfigWillLoad config will load
+func (qb *QueueBox) ConfigWillLoad(context.Context) {
+
+}
+
+// ConfigDidLoad build queue from config
+func (qb *QueueBox) ConfigDidLoad(context.Context) {
+	if qb.PriorityQueue != nil {
+		return
+	}
+
+	if qb.Queue == "" {
+		qb.Queue = qb.Component.name
+	}
+
+	qb.PriorityQueue = NewPriorityQueue(qb.Component.redis, qb.Queue, PriorityQueueOptions{
+		AgingRate: qb.AgingRate,
+		MinPoll:   qb.MinPoll,
+		MaxPoll:   qb.MaxPoll,
+	})
+}
+
+// NewRefreshBox new a refresh coordinator box
+func NewRefreshBox(name string, r *Redis) *RefreshBox {
+	return &RefreshBox{
+		Component: NewComponent(name, r),
+	}
+}
+
+// ConfigWillLoad config will load
+func (rb *RefreshBox) ConfigWillLoad(context.Context) {
+
+}
+
+// ConfigDidLoad build coordinator from config
+func (rb *RefreshBox) ConfigDidLoad(context.Context) {
+	rb.RefreshCoordinator = NewRefreshCoordinator(rb.Component.redis, rb.Prefix)
+}
+
+// Serve nothing to serve, coordinator listens lazily
+func (rb *RefreshBox) Serve(context.Context) error {
+	return nil
+}
+
+// Shutdown stop listening done notifications
+func (rb *RefreshBox) Shutdown(context.Context) error {
+	if rb.RefreshCoordinator == nil {
+		return nil
+	}
+
+	return rb.Close()
+}
@@ -0,0 +1,147 @@
+package redis
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"sync"
+)
+
+type (
+	// Compressor compress values stored by the cache helper
+	Compressor interface {
+		Compress(data []byte) ([]byte, error)
+		Decompress(data []byte) ([]byte, error)
+	}
+
+	gzipCompressor  struct{}
+	flateCompressor struct{}
+
+	compressorEntry struct {
+		name string
+		id   byte
+		Compressor
+	}
+)
+
+// compressMagic prefix of compressed values, followed by compressor id
+const compressMagic = "\xffZ"
+
+var (
+	compressorsMu sync.RWMutex
+	compressors   = map[string]*compressorEntry{}
+	compressorIDs = map[byte]*compressorEntry{}
+)
+
+func init() {
+	RegisterCompressor("gzip", 1, gzipCompressor{})
+	RegisterCompressor("flate", 2, flateCompressor{})
+}
+
+// RegisterCompressor register compressor by name, id is written to value header for format detection.
+// gzip(1) and flate(2) are builtin, register snappy/zstd etc. with other ids.
+func RegisterCompressor(name string, id byte, c Compressor) {
+	compressorsMu.Lock()
+	defer compressorsMu.Unlock()
+
+	entry := &compressorEntry{name: name, id: id, Compressor: c}
+	compressors[name] = entry
+	compressorIDs[id] = entry
+}
+
+// compress data with the named compressor when it is not smaller than minBytes
+func (r *Redis) compress(name string, minBytes int, data []byte) ([]byte, error) {
+	if name == "" || len(data) < minBytes || !r.rolledOut(FeatureCompression) {
+		return data, nil
+	}
+
+	compressorsMu.RLock()
+	entry, ok := compressors[name]
+	compressorsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("redis: unknown compression %s", name)
+	}
+
+	compressed, err := entry.Compress(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.compressRatio != nil && len(compressed) > 0 {
+		r.compressRatio.WithLabelValues(r.Instance(), entry.name).Observe(float64(len(data)) / float64(len(compressed)))
+	}
+
+	out := make([]byte, 0, len(compressMagic)+1+len(compressed))
+	out = append(out, compressMagic...)
+	out = append(out, entry.id)
+
+	return append(out, compressed...), nil
+}
+
+// decompress data written by compress, data without header is returned as is
+func decompress(data []byte) ([]byte, error) {
+	if len(data) <= len(compressMagic) || string(data[:len(compressMagic)]) != compressMagic {
+		return data, nil
+	}
+
+	id := data[len(compressMagic)]
+
+	compressorsMu.RLock()
+	entry, ok := compressorIDs[id]
+	compressorsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("redis: unknown compressor id %d", id)
+	}
+
+	return entry.Decompress(data[len(compressMagic)+1:])
+}
+
+func (gzipCompressor) Compress(data []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w := gzip.NewWriter(buf)
+
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (gzipCompressor) Decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return ioutil.ReadAll(r)
+}
+
+func (flateCompressor) Compress(data []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+
+	w, err := flate.NewWriter(buf, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (flateCompressor) Decompress(data []byte) ([]byte, error) {
+	r := flate.NewReader(bytes.NewReader(data))
+	defer r.Close()
+
+	return ioutil.ReadAll(r)
+}
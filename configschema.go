@@ -0,0 +1,84 @@
+package redis
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+type (
+	// ConfigField schema of a config field
+	ConfigField struct {
+		Key     string   `json:"key"`
+		Type    string   `json:"type"`
+		Default string   `json:"default,omitempty"`
+		Help    string   `json:"help"`
+		Modes   []string `json:"modes"`
+	}
+)
+
+var (
+	allModes = []string{"standalone", "cluster", "failover"}
+
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// ConfigSchema schema of all config fields, keys are prefixed by the instance name.
+// Defaults and mode applicability (standalone, cluster, failover) are declared by default and modes tags of fields,
+// fields without modes tag apply to all modes.
+func (r *Redis) ConfigSchema() []ConfigField {
+	t := reflect.TypeOf(r).Elem()
+	fields := make([]ConfigField, 0, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		key := sf.Tag.Get("config")
+		if key == "" {
+			continue
+		}
+
+		fields = append(fields, ConfigField{
+			Key:     r.name + "." + key,
+			Type:    configType(sf.Type),
+			Default: sf.Tag.Get("default"),
+			Help:    sf.Tag.Get("help"),
+			Modes:   configModes(sf.Tag.Get("modes")),
+		})
+	}
+
+	return fields
+}
+
+// ConfigSchemaJSON ConfigSchema encoded as json
+func (r *Redis) ConfigSchemaJSON() ([]byte, error) {
+	return json.MarshalIndent(r.ConfigSchema(), "", "  ")
+}
+
+func configType(t reflect.Type) string {
+	if t == durationType {
+		return "duration"
+	}
+
+	switch t.Kind() {
+	case reflect.Slice:
+		return "[]" + configType(t.Elem())
+	case reflect.Map:
+		return "map[" + configType(t.Key()) + "]" + configType(t.Elem())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "int"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	}
+
+	return t.Kind().String()
+}
+
+func configModes(tag string) []string {
+	if tag == "" {
+		return append([]string(nil), allModes...)
+	}
+
+	return strings.Split(tag, ",")
+}
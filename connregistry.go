@@ -0,0 +1,136 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	// ConnectionRegistry maps users to the gateway instances holding their connections,
+	// messages published to a user are routed to those instances only.
+	ConnectionRegistry struct {
+		redis    *Redis
+		prefix   string
+		instance string
+	}
+
+	// RoutedMessage message delivered to a gateway instance
+	RoutedMessage struct {
+		User    string `json:"user"`
+		Payload []byte `json:"payload"`
+	}
+)
+
+var (
+	// register member until expireAt, the key ttl is only extended to cover it. KEYS[1]: key. ARGV: member, expireAt ms, ttl ms
+	registerConnScript = newScript(`
+redis.call('zadd', KEYS[1], ARGV[2], ARGV[1])
+local ttl = redis.call('pttl', KEYS[1])
+if ttl < tonumber(ARGV[3]) then
+	redis.call('pexpire', KEYS[1], ARGV[3])
+end
+return 1
+`)
+)
+
+// NewConnectionRegistry new a registry for gateway instance, keys are prefixed by prefix.
+func NewConnectionRegistry(r *Redis, prefix, instance string) *ConnectionRegistry {
+	return &ConnectionRegistry{
+		redis:    r,
+		prefix:   prefix,
+		instance: instance,
+	}
+}
+
+// Register user connected to this instance for ttl, call it again before ttl to keep alive.
+// The key ttl is only extended, longer registrations of other instances are kept.
+func (c *ConnectionRegistry) Register(ctx context.Context, user string, ttl time.Duration) error {
+	expireAt := unixMilli(time.Now().Add(ttl))
+
+	return registerConnScript.run(ctx, c.redis, []string{c.userKey(user)}, c.instance, expireAt, int64(ttl/time.Millisecond)).Err()
+}
+
+// Unregister user disconnected from this instance.
+func (c *ConnectionRegistry) Unregister(ctx context.Context, user string) error {
+	return c.redis.DoContext(ctx, "zrem", c.userKey(user), c.instance).Err()
+}
+
+// Instances holding connections of user.
+func (c *ConnectionRegistry) Instances(ctx context.Context, user string) ([]string, error) {
+	key := c.userKey(user)
+	now := unixMilli(time.Now())
+
+	if err := c.redis.DoContext(ctx, "zremrangebyscore", key, "-inf", now).Err(); err != nil {
+		return nil, err
+	}
+
+	// read from the master, a replica may still list the expired members removed above
+	cmd := redis.NewStringSliceCmd("zrange", key, 0, -1)
+	if err := c.redis.ProcessContext(ForceMaster(ctx), cmd); err != nil {
+		return nil, err
+	}
+
+	return cmd.Val(), nil
+}
+
+// Publish payload to the instances holding connections of user, return the number of routed instances.
+func (c *ConnectionRegistry) Publish(ctx context.Context, user string, payload []byte) (int, error) {
+	instances, err := c.Instances(ctx, user)
+	if err != nil {
+		return 0, err
+	}
+
+	data, err := json.Marshal(RoutedMessage{User: user, Payload: payload})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, instance := range instances {
+		if err := c.redis.DoContext(ctx, "publish", c.channel(instance), data).Err(); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(instances), nil
+}
+
+// Listen messages routed to this instance and call handler, block until ctx done.
+func (c *ConnectionRegistry) Listen(ctx context.Context, handler func(RoutedMessage)) error {
+	ps := c.redis.Subscribe(c.channel(c.instance))
+	defer ps.Close()
+
+	if _, err := ps.Receive(); err != nil {
+		return err
+	}
+
+	ch := ps.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-ch:
+			if !ok {
+				return nil
+			}
+
+			routed := RoutedMessage{}
+			if err := json.Unmarshal([]byte(msg.Payload), &routed); err != nil {
+				continue
+			}
+
+			handler(routed)
+		}
+	}
+}
+
+func (c *ConnectionRegistry) userKey(user string) string {
+	return c.prefix + ":conn:" + user
+}
+
+func (c *ConnectionRegistry) channel(instance string) string {
+	return fmt.Sprintf("%s:gateway:%s", c.prefix, instance)
+}
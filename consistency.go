@@ -0,0 +1,121 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	consistencyKey struct{}
+
+	// replicaOffset replication offset of a replica as of the last query
+	replicaOffset struct {
+		offset int64
+		at     int64 // unix ms of the query
+	}
+)
+
+// replicaOffsetTTL replica offsets are queried again after it
+const replicaOffsetTTL = 100 * time.Millisecond
+
+var (
+	// ErrConsistencyCluster AfterWrite of a cluster client, offsets of shards are not comparable, use ForceMaster
+	ErrConsistencyCluster = errors.New("redis: read-your-writes tokens are not supported by cluster clients, use ForceMaster")
+)
+
+// AfterWrite record the master replication offset after the writes of the caller, reads of the returned ctx are served
+// by replicas caught up with it, or by the master. Carry ConsistencyToken of it across requests of a session.
+// Cluster clients fail with ErrConsistencyCluster, every shard has its own offset.
+func (r *Redis) AfterWrite(ctx context.Context) (context.Context, error) {
+	if len(r.replicas) == 0 {
+		return ctx, nil
+	}
+	if r.isCluster() {
+		return ctx, ErrConsistencyCluster
+	}
+
+	info, err := r.UniversalClient.DoContext(ctx, "info", "replication").Text()
+	if err != nil {
+		return ctx, err
+	}
+
+	offset, err := strconv.ParseInt(infoField(info, "master_repl_offset"), 10, 64)
+	if err != nil {
+		return ctx, err
+	}
+
+	return context.WithValue(ctx, consistencyKey{}, offset), nil
+}
+
+// ConsistencyToken token of the writes recorded in ctx by AfterWrite, empty when nothing is recorded
+func ConsistencyToken(ctx context.Context) string {
+	offset, ok := ctx.Value(consistencyKey{}).(int64)
+	if !ok {
+		return ""
+	}
+
+	return strconv.FormatInt(offset, 10)
+}
+
+// WithConsistencyToken context of a session reading its own writes recorded by a token of ConsistencyToken,
+// invalid tokens are ignored
+func WithConsistencyToken(ctx context.Context, token string) context.Context {
+	offset, err := strconv.ParseInt(token, 10, 64)
+	if err != nil {
+		return ctx
+	}
+
+	return context.WithValue(ctx, consistencyKey{}, offset)
+}
+
+// consistentReplica a replica caught up with the offset recorded in ctx starting from the i-th, nil when none is.
+// ok is false when ctx has no offset recorded. Reads of cluster clients with a token go to the master.
+func (r *Redis) consistentReplica(ctx context.Context, i int) (replica redis.UniversalClient, ok bool) {
+	offset, ok := ctx.Value(consistencyKey{}).(int64)
+	if !ok {
+		return nil, false
+	}
+	if r.isCluster() {
+		return nil, true
+	}
+
+	for n := 0; n < len(r.replicas); n++ {
+		index := (i + n) % len(r.replicas)
+		if r.replicaOffset(ctx, index) >= offset {
+			return r.replicas[index], true
+		}
+	}
+
+	return nil, true
+}
+
+// replicaOffset replication offset of the i-th replica, queried at most every replicaOffsetTTL, -1 when unknown
+func (r *Redis) replicaOffset(ctx context.Context, i int) int64 {
+	now := unixMilli(time.Now())
+
+	v, _ := r.replicaOffsets.LoadOrStore(i, &replicaOffset{offset: -1})
+	cached := v.(*replicaOffset)
+	if now-atomic.LoadInt64(&cached.at) < int64(replicaOffsetTTL/time.Millisecond) {
+		return atomic.LoadInt64(&cached.offset)
+	}
+
+	info, err := r.replicas[i].DoContext(ctx, "info", "replication").Text()
+	if err != nil {
+		return -1
+	}
+
+	offset, err := strconv.ParseInt(infoField(info, "slave_repl_offset"), 10, 64)
+	if err != nil {
+		return -1
+	}
+
+	atomic.StoreInt64(&cached.offset, offset)
+	atomic.StoreInt64(&cached.at, now)
+
+	return offset
+}
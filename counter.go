@@ -0,0 +1,253 @@
+package redis
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	// Counter distributed counters, increments of a counter are spread over shard keys (in different cluster slots)
+	// so hot counters do not overload one node. With a flush callback, counters are read-and-reset periodically
+	// and the deltas are handed to the callback, e.g. to persist them to a database.
+	Counter struct {
+		redis   *Redis
+		prefix  string
+		options CounterOptions
+		names   sync.Map // names registered to the names set by this instance
+
+		done   chan struct{}
+		closed sync.Once
+		wg     sync.WaitGroup
+	}
+
+	// CounterOptions options of counter
+	CounterOptions struct {
+		Shards        int                                                    // shard keys per counter, default 8
+		FlushInterval time.Duration                                          // flush interval, default 10s
+		Flush         func(ctx context.Context, deltas []CounterDelta) error // persist deltas, nil disables flushing
+	}
+
+	// CounterDelta delta of a counter shard taken by a flush. A failed flush is retried with the same ID and delta,
+	// persist deltas idempotently by ID for exactly-once counting.
+	CounterDelta struct {
+		Name  string
+		Shard int
+		ID    string
+		Delta int64
+	}
+)
+
+var (
+	// move the shard value to the flushing key unless a previous flush is unacknowledged.
+	// KEYS[1]: shard, KEYS[2]: flushing. ARGV: flush id. Returns "id:delta" or nil
+	counterFlushScript = newScript(`
+local pending = redis.call('get', KEYS[2])
+if pending then
+	return pending
+end
+local v = redis.call('get', KEYS[1])
+if not v or tonumber(v) == 0 then
+	return false
+end
+redis.call('del', KEYS[1])
+pending = ARGV[1] .. ':' .. v
+redis.call('set', KEYS[2], pending)
+return pending
+`)
+
+	// acknowledge a flush. KEYS[1]: flushing. ARGV: flush id
+	counterAckScript = newScript(`
+local pending = redis.call('get', KEYS[1])
+if pending and string.sub(pending, 1, #ARGV[1] + 1) == ARGV[1] .. ':' then
+	return redis.call('del', KEYS[1])
+end
+return 0
+`)
+)
+
+// NewCounter new counters, keys are prefixed by prefix. Close it to stop the flusher.
+func NewCounter(r *Redis, prefix string, opts ...CounterOptions) *Counter {
+	var options CounterOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	if options.Shards <= 0 {
+		options.Shards = 8
+	}
+	if options.FlushInterval <= 0 {
+		options.FlushInterval = 10 * time.Second
+	}
+
+	c := &Counter{
+		redis:   r,
+		prefix:  prefix,
+		options: options,
+		done:    make(chan struct{}),
+	}
+
+	if options.Flush != nil {
+		c.wg.Add(1)
+		go c.flushLoop()
+	}
+
+	return c
+}
+
+// Incr increase counter name by delta on a random shard
+func (c *Counter) Incr(ctx context.Context, name string, delta int64) error {
+	shard := c.shardKey(name, rand.Intn(c.options.Shards))
+
+	if _, ok := c.names.Load(name); ok {
+		return c.redis.DoContext(ctx, "incrby", shard, delta).Err()
+	}
+
+	if err := c.redis.DoContext(ctx, "sadd", c.namesKey(), name).Err(); err != nil {
+		return err
+	}
+	c.names.Store(name, struct{}{})
+
+	return c.redis.DoContext(ctx, "incrby", shard, delta).Err()
+}
+
+// Value of counter name not persisted yet, i.e. increments since the last acknowledged flush.
+// It is the total of the counter when flushing is disabled.
+func (c *Counter) Value(ctx context.Context, name string) (int64, error) {
+	keys := make([]string, 0, c.options.Shards*2)
+	for shard := 0; shard < c.options.Shards; shard++ {
+		keys = append(keys, c.shardKey(name, shard), c.flushingKey(name, shard))
+	}
+
+	values, err := c.redis.MGetBatch(ctx, keys, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	total := int64(0)
+	for _, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		if i := strings.LastIndexByte(s, ':'); i >= 0 {
+			s = s[i+1:]
+		}
+
+		n, _ := strconv.ParseInt(s, 10, 64)
+		total += n
+	}
+
+	return total, nil
+}
+
+// Flush take deltas of all counters and hand them to the flush callback, deltas are acknowledged when it succeeded.
+// Deltas of failed flushes are kept and handed again by next flushes.
+func (c *Counter) Flush(ctx context.Context) error {
+	if c.options.Flush == nil {
+		return nil
+	}
+
+	names, err := c.redis.DoContext(ForceMaster(ctx), "smembers", c.namesKey()).Result()
+	if err != nil {
+		return err
+	}
+
+	id := randomID()
+	deltas := make([]CounterDelta, 0)
+
+	members, _ := names.([]interface{})
+	for _, member := range members {
+		name, _ := member.(string)
+
+		for shard := 0; shard < c.options.Shards; shard++ {
+			pending, err := counterFlushScript.run(ctx, c.redis, []string{c.shardKey(name, shard), c.flushingKey(name, shard)}, id).Text()
+			if err == redis.Nil {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			i := strings.LastIndexByte(pending, ':')
+			if i < 0 {
+				continue
+			}
+			delta, _ := strconv.ParseInt(pending[i+1:], 10, 64)
+
+			deltas = append(deltas, CounterDelta{Name: name, Shard: shard, ID: pending[:i], Delta: delta})
+		}
+	}
+
+	if len(deltas) == 0 {
+		return nil
+	}
+
+	if err := c.options.Flush(ctx, deltas); err != nil {
+		return err
+	}
+
+	for _, delta := range deltas {
+		if err := counterAckScript.run(ctx, c.redis, []string{c.flushingKey(delta.Name, delta.Shard)}, delta.ID).Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close stop the flusher and flush once
+func (c *Counter) Close(ctx context.Context) error {
+	if c.options.Flush == nil {
+		return nil
+	}
+
+	c.closed.Do(func() {
+		close(c.done)
+	})
+	c.wg.Wait()
+
+	return c.Flush(ctx)
+}
+
+func (c *Counter) flushLoop() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(c.options.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+
+		if c.redis.Paused() {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), c.options.FlushInterval)
+		if err := c.Flush(ctx); err != nil {
+			c.redis.logf("counter %s: flush: %v", c.prefix, err)
+		}
+		cancel()
+	}
+}
+
+// shardKey key of a shard, the shard and its flushing key share a hash tag
+func (c *Counter) shardKey(name string, shard int) string {
+	return c.prefix + ":{" + name + ":" + strconv.Itoa(shard) + "}"
+}
+
+func (c *Counter) flushingKey(name string, shard int) string {
+	return c.shardKey(name, shard) + ":flushing"
+}
+
+func (c *Counter) namesKey() string {
+	return c.prefix + ":names"
+}
@@ -0,0 +1,102 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+type (
+	// CredentialsProvider fetch the password at connect time, instead of storing it in plain config.
+	// It is fetched again when redis rejects the cached one.
+	CredentialsProvider interface {
+		Password(ctx context.Context) (string, error)
+	}
+
+	// CredentialsProviderFunc func as CredentialsProvider
+	CredentialsProviderFunc func(ctx context.Context) (string, error)
+
+	fileCredentials string
+	envCredentials  string
+)
+
+// FileCredentials read password from file, surrounding whitespace is trimmed (e.g. mounted secrets)
+func FileCredentials(path string) CredentialsProvider {
+	return fileCredentials(path)
+}
+
+// EnvCredentials read password from environment variable
+func EnvCredentials(name string) CredentialsProvider {
+	return envCredentials(name)
+}
+
+// Password call f
+func (f CredentialsProviderFunc) Password(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+func (path fileCredentials) Password(context.Context) (string, error) {
+	data, err := ioutil.ReadFile(string(path))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+func (name envCredentials) Password(context.Context) (string, error) {
+	password, ok := os.LookupEnv(string(name))
+	if !ok {
+		return "", fmt.Errorf("redis: environment variable %s is not set", string(name))
+	}
+
+	return password, nil
+}
+
+// SetCredentialsProvider set password provider of the instance, it overrides password and passwordProvider config.
+// Call it before config loaded.
+func (r *Redis) SetCredentialsProvider(p CredentialsProvider) {
+	r.credentials = p
+}
+
+// parseCredentials provider from passwordProvider config
+func parseCredentials(s string) (CredentialsProvider, error) {
+	i := strings.Index(s, ":")
+	if i < 0 {
+		return nil, fmt.Errorf("redis: invalid password provider %q, file:<path> or env:<name> is expected", s)
+	}
+
+	switch scheme, value := s[:i], s[i+1:]; scheme {
+	case "file":
+		return FileCredentials(value), nil
+	case "env":
+		return EnvCredentials(value), nil
+	default:
+		return nil, fmt.Errorf("redis: unknown password provider %q", scheme)
+	}
+}
+
+// fetchPassword password from provider or config
+func (r *Redis) fetchPassword(ctx context.Context) (string, error) {
+	if r.credentials == nil {
+		return r.Password, nil
+	}
+
+	password, err := r.credentials.Password(ctx)
+	if err != nil {
+		return "", fmt.Errorf("redis: fetch password of %s: %w", r.Instance(), err)
+	}
+
+	return password, nil
+}
+
+// isAuthError redis rejected the password
+func isAuthError(err error) bool {
+	msg := err.Error()
+
+	return strings.HasPrefix(msg, "WRONGPASS") ||
+		strings.HasPrefix(msg, "NOAUTH") ||
+		strings.Contains(msg, "invalid password")
+}
@@ -0,0 +1,277 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type (
+	// Cron distributed cron scheduler as a box. Every replica registers the same jobs,
+	// each tick of a job is claimed by SET NX so that it fires once across the fleet.
+	// Ticks not fired because no replica was running are detected and counted as missed, they are not run again.
+	Cron struct {
+		Prefix string        `config:"prefix" help:"Key prefix of cron ticks, default is the component name"`
+		Jitter time.Duration `config:"jitter" default:"0" help:"Max random delay before running a claimed tick, default is 0"`
+
+		Component
+		mu       sync.Mutex
+		jobs     []*cronJob
+		done     chan struct{}
+		wg       sync.WaitGroup
+		runs     *prometheus.CounterVec
+		duration *prometheus.SummaryVec
+	}
+
+	// CronFunc job of a tick
+	CronFunc func(ctx context.Context, tick time.Time) error
+
+	// CronOptions options of a cron job
+	CronOptions struct {
+		Jitter   time.Duration  // default is jitter of the scheduler, negative disables it
+		Timeout  time.Duration  // ctx timeout of a run, default is unlimited
+		Location *time.Location // location of the spec, default is UTC
+	}
+
+	cronJob struct {
+		name     string
+		schedule *cronSchedule
+		fn       CronFunc
+		opt      CronOptions
+	}
+)
+
+// Cron run results of the execution metrics
+const (
+	CronOK     = "ok"
+	CronError  = "error"
+	CronMissed = "missed"
+)
+
+// NewCron new a cron scheduler box named name
+func NewCron(name string, r *Redis) *Cron {
+	return &Cron{
+		Component: NewComponent(name, r),
+	}
+}
+
+// Register job name running fn on spec, register jobs before serve.
+// spec is "minute hour day-of-month month day-of-week", @hourly, @daily, @weekly, @monthly, @yearly or "@every 5m".
+func (c *Cron) Register(name, spec string, fn CronFunc, opts ...CronOptions) error {
+	schedule, err := parseCron(spec)
+	if err != nil {
+		return err
+	}
+
+	opt := CronOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Location == nil {
+		opt.Location = time.UTC
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, job := range c.jobs {
+		if job.name == name {
+			return fmt.Errorf("redis: cron job %s is registered", name)
+		}
+	}
+
+	c.jobs = append(c.jobs, &cronJob{name: name, schedule: schedule, fn: fn, opt: opt})
+
+	return nil
+}
+
+// ConfigWillLoad config will load
+func (c *Cron) ConfigWillLoad(context.Context) {
+
+}
+
+// ConfigDidLoad register execution metrics
+func (c *Cron) ConfigDidLoad(context.Context) {
+	if c.Prefix == "" {
+		c.Prefix = c.name
+	}
+
+	r := c.Component.redis
+	if !r.Metrics {
+		return
+	}
+
+	c.runs = mustRegister(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: r.metrics.Namespace,
+			Subsystem: r.metrics.Subsystem,
+			Name:      "redis_cron_runs_total",
+			Help:      "redis cron job ticks by result total",
+		},
+		[]string{"redis_instance", "job", "result"},
+	)).(*prometheus.CounterVec)
+	c.duration = mustRegister(prometheus.NewSummaryVec(
+		prometheus.SummaryOpts{
+			Namespace: r.metrics.Namespace,
+			Subsystem: r.metrics.Subsystem,
+			Name:      "redis_cron_seconds",
+			Help:      "redis cron job run duration summary",
+		},
+		[]string{"redis_instance", "job"},
+	)).(*prometheus.SummaryVec)
+}
+
+// Serve start scheduling registered jobs
+func (c *Cron) Serve(context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.done = make(chan struct{})
+	for _, job := range c.jobs {
+		c.wg.Add(1)
+		go c.loop(job, c.done)
+	}
+
+	return nil
+}
+
+// Shutdown stop scheduling and wait for running jobs
+func (c *Cron) Shutdown(context.Context) error {
+	c.mu.Lock()
+	if c.done != nil {
+		close(c.done)
+		c.done = nil
+	}
+	c.mu.Unlock()
+
+	c.wg.Wait()
+
+	return nil
+}
+
+func (c *Cron) loop(job *cronJob, done <-chan struct{}) {
+	defer c.wg.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-done
+		cancel()
+	}()
+
+	for {
+		tick := job.schedule.next(time.Now().In(job.opt.Location))
+		if tick.IsZero() {
+			c.Component.redis.logf("cron job %s never fires", job.name)
+			return
+		}
+
+		timer := time.NewTimer(time.Until(tick))
+		select {
+		case <-done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		// ticks skipped while paused by the kill switch are detected as missed
+		if c.Component.redis.Paused() {
+			continue
+		}
+
+		c.fire(ctx, job, tick)
+	}
+}
+
+// fire claim tick and run job when claimed
+func (c *Cron) fire(ctx context.Context, job *cronJob, tick time.Time) {
+	r := c.Component.redis
+
+	// the claim outlives the tick interval, so replicas with skewed clocks do not fire it again
+	ttl := 2 * job.schedule.next(tick).Sub(tick)
+	if ttl < time.Minute {
+		ttl = time.Minute
+	}
+	if ttl > 24*time.Hour {
+		ttl = 24 * time.Hour
+	}
+
+	claim := fmt.Sprintf("%s:cron:%s:%d", c.Prefix, job.name, unixMilli(tick))
+	err := r.DoContext(ctx, append(setArgs(claim, r.Instance(), ttl), "nx")...).Err()
+	if err != nil {
+		if err != redis.Nil {
+			r.logf("cron job %s claim tick %s: %v", job.name, tick.Format(time.RFC3339), err)
+		}
+		return
+	}
+
+	c.detectMissed(ctx, job, tick)
+
+	jitter := job.opt.Jitter
+	if jitter == 0 {
+		jitter = c.Jitter
+	}
+	if jitter > 0 {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(rand.Int63n(int64(jitter)))):
+		}
+	}
+
+	runCtx := ctx
+	if job.opt.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, job.opt.Timeout)
+		defer cancel()
+	}
+
+	started := time.Now()
+	err = job.fn(runCtx, tick)
+
+	result := CronOK
+	if err != nil {
+		result = CronError
+		r.logf("cron job %s tick %s failed: %v", job.name, tick.Format(time.RFC3339), err)
+	}
+
+	if c.runs != nil {
+		c.runs.WithLabelValues(r.Instance(), job.name, result).Inc()
+		c.duration.WithLabelValues(r.Instance(), job.name).Observe(time.Since(started).Seconds())
+	}
+}
+
+// detectMissed count ticks between the last fired tick and tick as missed, and record tick as the last
+func (c *Cron) detectMissed(ctx context.Context, job *cronJob, tick time.Time) {
+	r := c.Component.redis
+	key := c.Prefix + ":cron:last"
+
+	// read from the master, a replica may lag behind the last hset
+	last, err := r.DoContext(ForceMaster(ctx), "hget", key, job.name).Int64()
+	r.DoContext(ctx, "hset", key, job.name, strconv.FormatInt(unixMilli(tick), 10))
+	if err != nil || last <= 0 {
+		return
+	}
+
+	missed := 0
+	for t := time.Unix(0, last*int64(time.Millisecond)).In(job.opt.Location); missed < 1000; missed++ {
+		t = job.schedule.next(t)
+		if t.IsZero() || !t.Before(tick) {
+			break
+		}
+	}
+	if missed == 0 {
+		return
+	}
+
+	r.logf("cron job %s missed %d ticks before %s", job.name, missed, tick.Format(time.RFC3339))
+	if c.runs != nil {
+		c.runs.WithLabelValues(r.Instance(), job.name, CronMissed).Add(float64(missed))
+	}
+}
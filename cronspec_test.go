@@ -0,0 +1,87 @@
+package redis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"60/5 * * * *",
+		"@every 500ms",
+		"@every soon",
+	} {
+		if _, err := parseCron(spec); err == nil {
+			t.Errorf("parseCron(%q) expected an error", spec)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	at := func(s string) time.Time {
+		v, err := time.Parse("2006-01-02 15:04:05", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	tests := []struct {
+		spec, from, want string
+	}{
+		{"* * * * *", "2026-10-14 10:07:30", "2026-10-14 10:08:00"},
+		{"*/15 * * * *", "2026-10-14 10:07:00", "2026-10-14 10:15:00"},
+		{"*/15 * * * *", "2026-10-14 10:45:00", "2026-10-14 11:00:00"},
+		{"5/15 * * * *", "2026-10-14 10:07:00", "2026-10-14 10:20:00"},
+		{"5/15 * * * *", "2026-10-14 10:50:00", "2026-10-14 11:05:00"},
+		{"10-20 * * * *", "2026-10-14 10:21:00", "2026-10-14 11:10:00"},
+		{"10-40/10 * * * *", "2026-10-14 10:31:00", "2026-10-14 10:40:00"},
+		{"0 9-17/4 * * *", "2026-10-14 10:00:00", "2026-10-14 13:00:00"},
+		{"0,30 8,20 * * *", "2026-10-14 10:00:00", "2026-10-14 20:00:00"},
+		// day of month or day of week when both are restricted
+		{"0 0 13 * 5", "2026-10-03 00:00:00", "2026-10-09 00:00:00"},
+		{"0 0 13 * 5", "2026-10-10 00:00:00", "2026-10-13 00:00:00"},
+		{"0 0 13 * *", "2026-10-03 00:00:00", "2026-10-13 00:00:00"},
+		{"0 0 * * 5", "2026-10-03 00:00:00", "2026-10-09 00:00:00"},
+		{"0 0 * * 7", "2026-10-01 00:00:00", "2026-10-04 00:00:00"},
+		{"0 0 ? * 0", "2026-10-01 00:00:00", "2026-10-04 00:00:00"},
+		// year rollover
+		{"0 0 1 1 *", "2026-12-31 23:59:00", "2027-01-01 00:00:00"},
+		{"@yearly", "2026-06-01 00:00:00", "2027-01-01 00:00:00"},
+		{"0 0 29 2 *", "2026-03-01 00:00:00", "2028-02-29 00:00:00"},
+		{"@daily", "2026-10-14 10:00:00", "2026-10-15 00:00:00"},
+		{"@hourly", "2026-10-14 23:30:00", "2026-10-15 00:00:00"},
+		{"@every 1m", "2026-10-14 10:07:30", "2026-10-14 10:08:00"},
+		{"@every 1h", "2026-10-14 10:07:30", "2026-10-14 11:00:00"},
+	}
+
+	for _, tt := range tests {
+		s, err := parseCron(tt.spec)
+		if err != nil {
+			t.Errorf("parseCron(%q): %v", tt.spec, err)
+			continue
+		}
+
+		if got, want := s.next(at(tt.from)), at(tt.want); !got.Equal(want) {
+			t.Errorf("%q next of %s: got %s, want %s", tt.spec, tt.from, got, want)
+		}
+	}
+}
+
+func TestCronNextNever(t *testing.T) {
+	s, err := parseCron("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if next := s.next(time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)); !next.IsZero() {
+		t.Errorf("expected no tick of Feb 30, got %s", next)
+	}
+}
@@ -0,0 +1,284 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	// DelayScheduler delayed jobs sharded into partitions, each partition is a due zset and a payload hash in one slot.
+	// Partitions are polled by the instance holding their lease, instances share partitions evenly,
+	// so ZRANGEBYSCORE stays cheap and poll load is spread with millions of jobs.
+	// Jobs are delivered at most once: a job being handled when its poller dies is lost.
+	DelayScheduler struct {
+		redis  *Redis
+		prefix string
+		opt    DelayOptions
+		locker *Locker
+		id     string
+	}
+
+	// DelayOptions options of DelayScheduler
+	DelayOptions struct {
+		Partitions   int           // default is 16, never change it while jobs are scheduled
+		PollInterval time.Duration // default is 1s
+		Batch        int           // due jobs popped per partition poll, default is 100
+		LeaseTTL     time.Duration // partition lease ttl, default is 10s
+		RetryDelay   time.Duration // failed jobs are scheduled again after it, default is 10s
+	}
+
+	// DelayedJob a due job
+	DelayedJob struct {
+		ID        string
+		Partition int
+		Payload   []byte
+	}
+)
+
+var (
+	// KEYS[1]: due, KEYS[2]: payloads, KEYS[3]: traces. ARGV[1]: id, ARGV[2]: payload, ARGV[3]: due ms, ARGV[4]: trace
+	delayScheduleScript = newScript(`
+redis.call('zadd', KEYS[1], ARGV[3], ARGV[1])
+redis.call('hset', KEYS[2], ARGV[1], ARGV[2])
+if ARGV[4] ~= '' then
+	redis.call('hset', KEYS[3], ARGV[1], ARGV[4])
+else
+	redis.call('hdel', KEYS[3], ARGV[1])
+end
+return 1
+`)
+
+	// KEYS[1]: due, KEYS[2]: payloads, KEYS[3]: traces. ARGV[1]: id
+	delayCancelScript = newScript(`
+redis.call('hdel', KEYS[2], ARGV[1])
+redis.call('hdel', KEYS[3], ARGV[1])
+return redis.call('zrem', KEYS[1], ARGV[1])
+`)
+
+	// KEYS[1]: due, KEYS[2]: payloads, KEYS[3]: traces. ARGV[1]: now ms, ARGV[2]: batch. Returns id, payload, trace triples
+	delayPopScript = newScript(`
+local ids = redis.call('zrangebyscore', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+local res = {}
+for _, id in ipairs(ids) do
+	redis.call('zrem', KEYS[1], id)
+	local payload = redis.call('hget', KEYS[2], id)
+	local trace = redis.call('hget', KEYS[3], id)
+	redis.call('hdel', KEYS[2], id)
+	redis.call('hdel', KEYS[3], id)
+	res[#res + 1] = id
+	res[#res + 1] = payload or ''
+	res[#res + 1] = trace or ''
+end
+return res
+`)
+)
+
+// NewDelayScheduler new a partitioned delay scheduler, keys are prefixed by prefix.
+func NewDelayScheduler(r *Redis, prefix string, opts ...DelayOptions) *DelayScheduler {
+	opt := DelayOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Partitions <= 0 {
+		opt.Partitions = 16
+	}
+	if opt.PollInterval <= 0 {
+		opt.PollInterval = time.Second
+	}
+	if opt.Batch <= 0 {
+		opt.Batch = 100
+	}
+	if opt.LeaseTTL <= 0 {
+		opt.LeaseTTL = 10 * time.Second
+	}
+	if opt.RetryDelay <= 0 {
+		opt.RetryDelay = 10 * time.Second
+	}
+
+	return &DelayScheduler{
+		redis:  r,
+		prefix: prefix,
+		opt:    opt,
+		locker: NewLocker(r, prefix+":delay:lease", opt.LeaseTTL),
+		id:     randomID(),
+	}
+}
+
+// Schedule schedule job id with payload at, a scheduled job of the same id is replaced.
+// The trace context of ctx is kept with the job when a message tracer is set.
+func (d *DelayScheduler) Schedule(ctx context.Context, id string, payload []byte, at time.Time) error {
+	return d.schedule(ctx, id, payload, at, d.redis.injectTrace(ctx))
+}
+
+func (d *DelayScheduler) schedule(ctx context.Context, id string, payload []byte, at time.Time, carrier map[string]string) error {
+	due, jobs, traces := d.keys(d.partition(id))
+
+	return delayScheduleScript.run(ctx, d.redis, []string{due, jobs, traces}, id, payload, unixMilli(at), encodeCarrier(carrier, at)).Err()
+}
+
+// Cancel cancel job id, canceled is false when it is not scheduled
+func (d *DelayScheduler) Cancel(ctx context.Context, id string) (canceled bool, err error) {
+	due, jobs, traces := d.keys(d.partition(id))
+
+	n, err := delayCancelScript.run(ctx, d.redis, []string{due, jobs, traces}, id).Int64()
+
+	return n == 1, err
+}
+
+// Pending number of scheduled jobs of all partitions
+func (d *DelayScheduler) Pending(ctx context.Context) (int64, error) {
+	var total int64
+	for p := 0; p < d.opt.Partitions; p++ {
+		due, _, _ := d.keys(p)
+
+		n, err := d.redis.DoContext(ctx, "zcard", due).Int64()
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+
+	return total, nil
+}
+
+// Run poll partitions leased by this instance and call handler for due jobs until ctx done.
+// Failed jobs are scheduled again after retryDelay. Handler is called sequentially per partition,
+// in a consumer span linked to the schedule when a message tracer is set.
+func (d *DelayScheduler) Run(ctx context.Context, handler func(ctx context.Context, job DelayedJob) error) error {
+	leases := map[int]*Lock{}
+	defer func() {
+		for _, lk := range leases {
+			lk.Unlock(context.Background())
+		}
+		d.redis.DoContext(context.Background(), "zrem", d.pollersKey(), d.id)
+	}()
+
+	ticker := time.NewTicker(d.opt.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.assign(ctx, leases)
+
+		// leases are kept while paused by the kill switch, so partitions do not move
+		for p := range leases {
+			if d.redis.Paused() {
+				break
+			}
+			if err := d.poll(ctx, p, handler); err != nil && ctx.Err() == nil {
+				d.redis.logf("delay scheduler %s partition %d: %v", d.prefix, p, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// assign renew leases and balance them to the fair share of partitions among live pollers
+func (d *DelayScheduler) assign(ctx context.Context, leases map[int]*Lock) {
+	now := time.Now()
+	if err := d.redis.DoContext(ctx, "zadd", d.pollersKey(), unixMilli(now), d.id).Err(); err != nil {
+		return
+	}
+	d.redis.DoContext(ctx, "zremrangebyscore", d.pollersKey(), "-inf", unixMilli(now.Add(-3*d.opt.LeaseTTL)))
+
+	alive, err := d.redis.DoContext(ctx, "zcard", d.pollersKey()).Int64()
+	if err != nil || alive < 1 {
+		alive = 1
+	}
+	share := int(math.Ceil(float64(d.opt.Partitions) / float64(alive)))
+
+	for p, lk := range leases {
+		if err := lk.Refresh(ctx); err != nil {
+			delete(leases, p)
+		}
+	}
+
+	// release leases above the share, so that new pollers get partitions
+	for p, lk := range leases {
+		if len(leases) <= share {
+			break
+		}
+		lk.Unlock(ctx)
+		delete(leases, p)
+	}
+
+	// start from a random partition, so pollers do not race for the same ones
+	offset := d.partition(d.id)
+	for i := 0; i < d.opt.Partitions && len(leases) < share; i++ {
+		p := (offset + i) % d.opt.Partitions
+		if _, ok := leases[p]; ok {
+			continue
+		}
+
+		if lk, err := d.locker.TryLock(ctx, strconv.Itoa(p)); err == nil {
+			leases[p] = lk
+		}
+	}
+}
+
+// poll pop due jobs of partition p until none is due
+func (d *DelayScheduler) poll(ctx context.Context, p int, handler func(ctx context.Context, job DelayedJob) error) error {
+	due, jobs, traces := d.keys(p)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		val, err := delayPopScript.run(ctx, d.redis, []string{due, jobs, traces}, unixMilli(time.Now()), d.opt.Batch).Result()
+		if err != nil {
+			if err == redis.Nil {
+				return nil
+			}
+			return err
+		}
+
+		values, _ := val.([]interface{})
+		for i := 0; i+2 < len(values); i += 3 {
+			job := DelayedJob{
+				ID:        fmt.Sprint(values[i]),
+				Partition: p,
+				Payload:   []byte(fmt.Sprint(values[i+1])),
+			}
+			carrier, ready := decodeTrace(fmt.Sprint(values[i+2]))
+			jobCtx, end := d.redis.startConsumer(ctx, d.prefix, carrier, ready)
+			err := handler(jobCtx, job)
+			end(err)
+
+			// retries keep the trace context of the schedule
+			if err != nil {
+				d.redis.logf("delay scheduler %s job %s failed, retry in %s: %v", d.prefix, job.ID, d.opt.RetryDelay, err)
+				d.schedule(context.Background(), job.ID, job.Payload, time.Now().Add(d.opt.RetryDelay), carrier)
+			}
+		}
+
+		if len(values)/3 < d.opt.Batch {
+			return nil
+		}
+	}
+}
+
+func (d *DelayScheduler) partition(id string) int {
+	return int(crc32.ChecksumIEEE([]byte(id)) % uint32(d.opt.Partitions))
+}
+
+// keys due zset, payload and trace hashes of partition p, in the same slot
+func (d *DelayScheduler) keys(p int) (due, jobs, traces string) {
+	tag := fmt.Sprintf("{%s:delay:%d}", d.prefix, p)
+
+	return tag + ":due", tag + ":jobs", tag + ":traces"
+}
+
+func (d *DelayScheduler) pollersKey() string {
+	return d.prefix + ":delay:pollers"
+}
@@ -0,0 +1,102 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	// DurabilityError write is applied on the master but not acknowledged by enough replicas in time,
+	// it may be lost on failover
+	DurabilityError struct {
+		Acked    int64
+		Replicas int64
+	}
+)
+
+func (e *DurabilityError) Error() string {
+	return fmt.Sprintf("redis: write acknowledged by %d of %d replicas", e.Acked, e.Replicas)
+}
+
+// WriteDurable run the writes of fn in a pipeline of the client followed by WAIT replicas timeout,
+// a *DurabilityError is returned when fewer replicas acknowledged them. All commands of fn must be in the
+// slot of key for cluster clients, WAIT is sent to the master of that slot after the pipeline on a connection
+// of its pool, the one just released by the pipeline unless a concurrent command took it.
+// timeout is clamped below the read timeout of connections and the deadline of ctx, so a slow WAIT yields
+// a *DurabilityError instead of an i/o timeout.
+func (r *Redis) WriteDurable(ctx context.Context, fn func(pipe redis.Pipeliner) error, replicas int, timeout time.Duration, key ...string) error {
+	if replicas <= 0 {
+		replicas = 1
+	}
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+	timeout = r.clampWait(ctx, timeout)
+
+	cluster := r.isCluster()
+	if cluster && len(key) == 0 {
+		return errors.New("redis: WriteDurable of cluster clients requires a key")
+	}
+
+	// the writes go through the hooks of the client, only WAIT goes to the node client of cluster clients
+	pipe := r.UniversalClient.Pipeline()
+	defer pipe.Close()
+
+	if err := fn(pipe); err != nil {
+		return err
+	}
+
+	acked := redis.NewIntCmd("wait", replicas, int64(timeout/time.Millisecond))
+	if !cluster {
+		pipe.Process(acked)
+	}
+	if _, err := pipe.ExecContext(ctx); err != nil {
+		return err
+	}
+
+	if cluster {
+		master, err := r.masterFor(ctx, key[0])
+		if err != nil {
+			return err
+		}
+		if err := master.ProcessContext(ctx, acked); err != nil {
+			return err
+		}
+	}
+
+	if n := acked.Val(); n < int64(replicas) {
+		return &DurabilityError{Acked: n, Replicas: int64(replicas)}
+	}
+
+	return nil
+}
+
+// clampWait WAIT timeout leaving a quarter of the read timeout or the ctx deadline to the round trip
+func (r *Redis) clampWait(ctx context.Context, timeout time.Duration) time.Duration {
+	limit := r.readTimeout
+	switch limit {
+	case -1:
+		limit = 0
+	case 0:
+		limit = 3 * time.Second // default of go-redis
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if left := time.Until(deadline); limit == 0 || left < limit {
+			limit = left
+		}
+	}
+
+	if limit > 0 && timeout > limit-limit/4 {
+		timeout = limit - limit/4
+	}
+	if timeout < time.Millisecond {
+		timeout = time.Millisecond
+	}
+
+	return timeout
+}
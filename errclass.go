@@ -0,0 +1,86 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"syscall"
+
+	"github.com/go-redis/redis/v7"
+)
+
+// Error classes of the error metrics label
+const (
+	ErrClassOK          = "ok"
+	ErrClassNil         = "nil"
+	ErrClassTimeout     = "timeout"
+	ErrClassConnRefused = "conn_refused"
+	ErrClassReadOnly    = "readonly"
+	ErrClassMoved       = "moved"
+	ErrClassAsk         = "ask"
+	ErrClassOOM         = "oom"
+	ErrClassOther       = "other"
+)
+
+// ErrorClass bounded classification of command error, used as metrics label instead of raw messages
+func ErrorClass(err error) string {
+	if err == nil {
+		return ErrClassOK
+	}
+	if err == redis.Nil {
+		return ErrClassNil
+	}
+	if err == context.DeadlineExceeded {
+		return ErrClassTimeout
+	}
+
+	if ne, ok := err.(net.Error); ok && ne.Timeout() {
+		return ErrClassTimeout
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return ErrClassConnRefused
+	}
+
+	msg := err.Error()
+	switch {
+	case strings.HasPrefix(msg, "READONLY"):
+		return ErrClassReadOnly
+	case strings.HasPrefix(msg, "MOVED "):
+		return ErrClassMoved
+	case strings.HasPrefix(msg, "ASK "):
+		return ErrClassAsk
+	case strings.HasPrefix(msg, "OOM"):
+		return ErrClassOOM
+	case strings.Contains(msg, "i/o timeout"):
+		return ErrClassTimeout
+	case strings.Contains(msg, "connection refused"):
+		return ErrClassConnRefused
+	}
+
+	return ErrClassOther
+}
+
+// errorClass of a command or pipeline: the class of the first failed command, nil when every command is nil
+func errorClass(cmds []redis.Cmder) string {
+	class := ErrClassOK
+	nils := 0
+
+	for _, cmd := range cmds {
+		switch c := ErrorClass(cmd.Err()); c {
+		case ErrClassOK:
+		case ErrClassNil:
+			nils++
+		default:
+			if class == ErrClassOK {
+				class = c
+			}
+		}
+	}
+
+	if class == ErrClassOK && nils > 0 && nils == len(cmds) {
+		return ErrClassNil
+	}
+
+	return class
+}
@@ -0,0 +1,39 @@
+package redis
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type (
+	// TraceIDFunc trace id of ctx, empty when ctx is not traced
+	TraceIDFunc func(ctx context.Context) string
+
+	// exemplarObserver implemented by histograms of prometheus client supporting exemplars
+	exemplarObserver interface {
+		ObserveWithExemplar(value float64, exemplar prometheus.Labels)
+	}
+)
+
+// SetTraceID extract trace ids of commands by fn, latency histogram observations of traced commands carry
+// a trace_id exemplar, so dashboards jump from a latency spike to example traces. Requires histogram,
+// exemplars are exposed by the OpenMetrics format only.
+// Call it before serving, e.g. r.SetTraceID(func(ctx) string { return trace.SpanContextFromContext(ctx).TraceID().String() }).
+func (r *Redis) SetTraceID(fn TraceIDFunc) {
+	r.traceID = fn
+}
+
+// observeWithExemplar observe value with the trace id of ctx as exemplar when supported
+func (r *Redis) observeWithExemplar(ctx context.Context, observer prometheus.Observer, value float64) {
+	if r.traceID != nil {
+		if eo, ok := observer.(exemplarObserver); ok {
+			if id := r.traceID(ctx); id != "" {
+				eo.ObserveWithExemplar(value, prometheus.Labels{"trace_id": id})
+				return
+			}
+		}
+	}
+
+	observer.Observe(value)
+}
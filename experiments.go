@@ -0,0 +1,308 @@
+package redis
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	// Experiments a/b experiment variant assignment store.
+	// Assignments are hash based, persisted on first lookup and can be overridden per user.
+	// Overrides are published so other instances drop their cached assignments.
+	Experiments struct {
+		redis    *Redis
+		prefix   string
+		cacheTTL time.Duration
+		once     sync.Once
+		mu       sync.Mutex
+		cache    map[string]*list.Element
+		lru      *list.List
+		gen      uint64 // bumped by invalidations, assignments read before one are not cached
+		pubsub   *redis.PubSub
+	}
+
+	// Experiment definition
+	Experiment struct {
+		Name     string
+		Variants []Variant
+	}
+
+	// Variant of experiment, users are split by weight
+	Variant struct {
+		Name   string
+		Weight int
+	}
+
+	experimentCacheEntry struct {
+		key      string
+		variant  string
+		expireAt time.Time
+	}
+)
+
+// experimentsCacheSize max cached assignments, the least recently used are evicted
+const experimentsCacheSize = 10000
+
+var (
+	// ErrNoVariants experiment has no variant with positive weight
+	ErrNoVariants = errors.New("redis: experiment has no variants")
+
+	// KEYS: overrides hash, assignments hash. ARGV[1]: user, ARGV[2]: hashed variant
+	experimentsAssignScript = newScript(`
+local v = redis.call('hget', KEYS[1], ARGV[1])
+if v then
+	return v
+end
+redis.call('hsetnx', KEYS[2], ARGV[1], ARGV[2])
+return redis.call('hget', KEYS[2], ARGV[1])
+`)
+)
+
+// NewExperiments new an experiments store, keys are prefixed by prefix.
+// Assignments are cached locally for cacheTTL, zero disables local caching.
+func NewExperiments(r *Redis, prefix string, cacheTTL time.Duration) *Experiments {
+	return &Experiments{
+		redis:    r,
+		prefix:   prefix,
+		cacheTTL: cacheTTL,
+		cache:    make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// Assign return the variant of user in experiment, persisting it on first assignment.
+func (e *Experiments) Assign(ctx context.Context, exp Experiment, user string) (string, error) {
+	cacheKey := exp.Name + "\x00" + user
+
+	if variant, ok := e.cached(cacheKey); ok {
+		return variant, nil
+	}
+
+	hashed, err := exp.pick(user)
+	if err != nil {
+		return "", err
+	}
+
+	gen := e.generation()
+	variant, err := experimentsAssignScript.run(ctx, e.redis, []string{e.overridesKey(exp.Name), e.assignmentsKey(exp.Name)}, user, hashed).Text()
+	if err != nil {
+		return "", err
+	}
+
+	e.store(cacheKey, variant, gen)
+
+	return variant, nil
+}
+
+// Lookup the persisted variant of user without assigning, ok is false when user is not assigned.
+func (e *Experiments) Lookup(ctx context.Context, experiment, user string) (variant string, ok bool, err error) {
+	for _, key := range []string{e.overridesKey(experiment), e.assignmentsKey(experiment)} {
+		variant, err = e.redis.DoContext(ctx, "hget", key, user).Text()
+		if err == redis.Nil {
+			continue
+		}
+
+		return variant, err == nil, err
+	}
+
+	return "", false, nil
+}
+
+// Override force user into variant.
+func (e *Experiments) Override(ctx context.Context, experiment, user, variant string) error {
+	if err := e.redis.DoContext(ctx, "hset", e.overridesKey(experiment), user, variant).Err(); err != nil {
+		return err
+	}
+
+	return e.invalidate(ctx, experiment+"\x00"+user)
+}
+
+// ClearOverride remove override of user.
+func (e *Experiments) ClearOverride(ctx context.Context, experiment, user string) error {
+	if err := e.redis.DoContext(ctx, "hdel", e.overridesKey(experiment), user).Err(); err != nil {
+		return err
+	}
+
+	return e.invalidate(ctx, experiment+"\x00"+user)
+}
+
+// Close stop listening invalidations of other instances
+func (e *Experiments) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.pubsub == nil {
+		return nil
+	}
+
+	return e.pubsub.Close()
+}
+
+// Expose count an exposure of variant.
+func (e *Experiments) Expose(ctx context.Context, experiment, variant string) error {
+	return e.redis.DoContext(ctx, "hincrby", e.exposuresKey(experiment), variant, 1).Err()
+}
+
+// Exposures count of each variant.
+func (e *Experiments) Exposures(ctx context.Context, experiment string) (map[string]int64, error) {
+	val, err := e.redis.DoContext(ctx, "hgetall", e.exposuresKey(experiment)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	values, _ := val.([]interface{})
+
+	return parseCounts(values)
+}
+
+// cached variant of key, an expired entry is evicted when it is looked up
+func (e *Experiments) cached(key string) (string, bool) {
+	if e.cacheTTL <= 0 {
+		return "", false
+	}
+
+	e.once.Do(e.listen)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	elem, ok := e.cache[key]
+	if !ok {
+		return "", false
+	}
+
+	entry := elem.Value.(*experimentCacheEntry)
+	if time.Now().After(entry.expireAt) {
+		e.lru.Remove(elem)
+		delete(e.cache, key)
+		return "", false
+	}
+
+	e.lru.MoveToFront(elem)
+
+	return entry.variant, true
+}
+
+// generation of invalidations, taken before reading an assignment to store
+func (e *Experiments) generation() uint64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.gen
+}
+
+// store variant of key read at generation gen, it is dropped when an invalidation happened meanwhile,
+// so an override racing with a cache miss does not leave the stale variant cached
+func (e *Experiments) store(key, variant string, gen uint64) {
+	if e.cacheTTL <= 0 {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.gen != gen {
+		return
+	}
+
+	expireAt := time.Now().Add(e.cacheTTL)
+	if elem, ok := e.cache[key]; ok {
+		entry := elem.Value.(*experimentCacheEntry)
+		entry.variant, entry.expireAt = variant, expireAt
+		e.lru.MoveToFront(elem)
+		return
+	}
+
+	e.cache[key] = e.lru.PushFront(&experimentCacheEntry{key: key, variant: variant, expireAt: expireAt})
+
+	if e.lru.Len() > experimentsCacheSize {
+		oldest := e.lru.Back()
+		e.lru.Remove(oldest)
+		delete(e.cache, oldest.Value.(*experimentCacheEntry).key)
+	}
+}
+
+func (e *Experiments) forget(key string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.gen++
+
+	if elem, ok := e.cache[key]; ok {
+		e.lru.Remove(elem)
+		delete(e.cache, key)
+	}
+}
+
+// invalidate cached assignment of key on all instances
+func (e *Experiments) invalidate(ctx context.Context, key string) error {
+	e.forget(key)
+
+	return e.redis.DoContext(ctx, "publish", e.channel(), key).Err()
+}
+
+// listen invalidations published by other instances
+func (e *Experiments) listen() {
+	ps := e.redis.Subscribe(e.channel())
+
+	e.mu.Lock()
+	e.pubsub = ps
+	e.mu.Unlock()
+
+	go func() {
+		for msg := range ps.Channel() {
+			e.forget(msg.Payload)
+		}
+	}()
+}
+
+func (e *Experiments) channel() string {
+	return e.prefix + ":experiments:invalidate"
+}
+
+func (e *Experiments) overridesKey(experiment string) string {
+	return e.prefix + ":{" + experiment + "}:overrides"
+}
+
+func (e *Experiments) assignmentsKey(experiment string) string {
+	return e.prefix + ":{" + experiment + "}:assignments"
+}
+
+func (e *Experiments) exposuresKey(experiment string) string {
+	return e.prefix + ":{" + experiment + "}:exposures"
+}
+
+// pick variant by hash of experiment name and user
+func (exp Experiment) pick(user string) (string, error) {
+	total := 0
+	for _, v := range exp.Variants {
+		if v.Weight > 0 {
+			total += v.Weight
+		}
+	}
+	if total == 0 {
+		return "", ErrNoVariants
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(exp.Name + ":" + user))
+	n := int(h.Sum32() % uint32(total))
+
+	for _, v := range exp.Variants {
+		if v.Weight <= 0 {
+			continue
+		}
+		if n < v.Weight {
+			return v.Name, nil
+		}
+		n -= v.Weight
+	}
+
+	return exp.Variants[len(exp.Variants)-1].Name, nil
+}
@@ -0,0 +1,265 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	// ExpiryCallbacks run callbacks when keys expire, at least once. Expired keyspace events trigger callbacks at once,
+	// a sweeper runs those whose event was missed. Each expiry is claimed by one instance, callbacks failed
+	// or claimed by a crashed instance are run again.
+	ExpiryCallbacks struct {
+		redis    *Redis
+		prefix   string
+		opt      ExpiryOptions
+		mu       sync.RWMutex
+		handlers map[string]ExpiryFunc
+	}
+
+	// ExpiryOptions options of ExpiryCallbacks
+	ExpiryOptions struct {
+		SweepInterval time.Duration // default is 5s
+		Grace         time.Duration // wait for the keyspace event before sweeping an expiry, default is 1s
+		Lease         time.Duration // claimed callbacks not done in it are run again, default is 30s
+		RetryDelay    time.Duration // failed callbacks are run again after it, default is 10s
+	}
+
+	// ExpiryFunc callback of an expired key
+	ExpiryFunc func(ctx context.Context, key string) error
+)
+
+var (
+	// KEYS: due, processing, callbacks. ARGV[1]: key, ARGV[2]: lease deadline ms
+	expiryClaimScript = newScript(`
+if not redis.call('zscore', KEYS[1], ARGV[1]) then
+	return false
+end
+redis.call('zrem', KEYS[1], ARGV[1])
+redis.call('zadd', KEYS[2], ARGV[2], ARGV[1])
+return redis.call('hget', KEYS[3], ARGV[1])
+`)
+
+	// KEYS: due, processing, callbacks. ARGV[1]: key
+	expiryDoneScript = newScript(`
+if redis.call('zrem', KEYS[2], ARGV[1]) == 1 and not redis.call('zscore', KEYS[1], ARGV[1]) then
+	redis.call('hdel', KEYS[3], ARGV[1])
+end
+return 1
+`)
+
+	// KEYS: due, processing. ARGV[1]: now ms
+	expiryRequeueScript = newScript(`
+local keys = redis.call('zrangebyscore', KEYS[2], '-inf', ARGV[1], 'LIMIT', 0, 1000)
+for _, key in ipairs(keys) do
+	redis.call('zrem', KEYS[2], key)
+	redis.call('zadd', KEYS[1], ARGV[1], key)
+end
+return #keys
+`)
+)
+
+// NewExpiryCallbacks new expiry callbacks, keys are prefixed by prefix.
+// Keyspace events of expired keys are enabled by keyspaceConfigure or notify-keyspace-events Ex, without them only the sweeper runs callbacks.
+func NewExpiryCallbacks(r *Redis, prefix string, opts ...ExpiryOptions) *ExpiryCallbacks {
+	opt := ExpiryOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.SweepInterval <= 0 {
+		opt.SweepInterval = 5 * time.Second
+	}
+	if opt.Grace <= 0 {
+		opt.Grace = time.Second
+	}
+	if opt.Lease <= 0 {
+		opt.Lease = 30 * time.Second
+	}
+	if opt.RetryDelay <= 0 {
+		opt.RetryDelay = 10 * time.Second
+	}
+
+	return &ExpiryCallbacks{
+		redis:    r,
+		prefix:   prefix,
+		opt:      opt,
+		handlers: make(map[string]ExpiryFunc),
+	}
+}
+
+// Handle register callback name, callbacks are looked up by name so every instance running them should register it
+func (e *ExpiryCallbacks) Handle(name string, fn ExpiryFunc) {
+	e.mu.Lock()
+	e.handlers[name] = fn
+	e.mu.Unlock()
+}
+
+// Expire set ttl of key and run callback name when it expires
+func (e *ExpiryCallbacks) Expire(ctx context.Context, key string, ttl time.Duration, name string) error {
+	ok, err := e.redis.DoContext(ctx, "pexpire", key, ttl.Milliseconds()).Bool()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("redis: expire %s: key does not exist", key)
+	}
+
+	return e.Register(ctx, key, time.Now().Add(ttl), name)
+}
+
+// Register run callback name when key expires at, for keys whose ttl is set by the caller. Registering again replaces it.
+func (e *ExpiryCallbacks) Register(ctx context.Context, key string, at time.Time, name string) error {
+	_, err := e.redis.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Do("zadd", e.key("due"), unixMilli(at), key)
+		pipe.Do("hset", e.key("callbacks"), key, name)
+		return nil
+	})
+
+	return err
+}
+
+// Cancel do not run callback of key
+func (e *ExpiryCallbacks) Cancel(ctx context.Context, key string) error {
+	_, err := e.redis.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Do("zrem", e.key("due"), key)
+		pipe.Do("hdel", e.key("callbacks"), key)
+		return nil
+	})
+
+	return err
+}
+
+// Run listen expired keyspace events and sweep missed expiries, run callbacks until ctx done
+func (e *ExpiryCallbacks) Run(ctx context.Context) error {
+	channel := fmt.Sprintf("__keyevent@%d__:expired", e.redis.DB)
+
+	var mu sync.Mutex
+	pubsubs := make([]*redis.PubSub, 0)
+	defer func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		for _, ps := range pubsubs {
+			ps.Close()
+		}
+	}()
+
+	// keyspace events are not propagated across the cluster, subscribe every master
+	err := e.redis.forEachMaster(func(node redis.UniversalClient, addr string) error {
+		ps := node.Subscribe(channel)
+		mu.Lock()
+		pubsubs = append(pubsubs, ps)
+		mu.Unlock()
+
+		go func() {
+			for msg := range ps.Channel() {
+				// the sweeper runs callbacks of events missed while paused
+				if e.redis.Paused() {
+					continue
+				}
+				e.fire(ctx, msg.Payload)
+			}
+		}()
+
+		return nil
+	})
+	if err != nil {
+		e.redis.logf("expiry callbacks %s: keyspace events: %v", e.prefix, err)
+	}
+
+	ticker := time.NewTicker(e.opt.SweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if e.redis.Paused() {
+				continue
+			}
+			if err := e.sweep(ctx); err != nil && ctx.Err() == nil {
+				e.redis.logf("expiry callbacks %s: sweep: %v", e.prefix, err)
+			}
+		}
+	}
+}
+
+// sweep requeue expired leases, run callbacks of due keys past grace which are gone
+func (e *ExpiryCallbacks) sweep(ctx context.Context) error {
+	now := time.Now()
+
+	if err := expiryRequeueScript.run(ctx, e.redis, []string{e.key("due"), e.key("processing")}, unixMilli(now)).Err(); err != nil {
+		return err
+	}
+
+	keys, err := e.redis.DoContext(ForceMaster(ctx), "zrangebyscore", e.key("due"), "-inf", unixMilli(now.Add(-e.opt.Grace)), "limit", 0, 1000).Result()
+	if err != nil {
+		return err
+	}
+
+	values, _ := keys.([]interface{})
+	for _, v := range values {
+		key := fmt.Sprint(v)
+
+		// ttl may have been extended after registering
+		ttl, err := e.redis.DoContext(ctx, "pttl", key).Int64()
+		if err != nil {
+			return err
+		}
+		switch {
+		case ttl == -1:
+			// persisted, it never expires
+			e.Cancel(ctx, key)
+			continue
+		case ttl > 0:
+			e.redis.DoContext(ctx, "zadd", e.key("due"), unixMilli(now)+ttl, key)
+			continue
+		}
+
+		e.fire(ctx, key)
+	}
+
+	return nil
+}
+
+// fire claim expiry of key and run its callback, no-op when it is not registered or claimed
+func (e *ExpiryCallbacks) fire(ctx context.Context, key string) {
+	keys := []string{e.key("due"), e.key("processing"), e.key("callbacks")}
+
+	name, err := expiryClaimScript.run(ctx, e.redis, keys, key, unixMilli(time.Now().Add(e.opt.Lease))).Text()
+	if err != nil {
+		if err != redis.Nil {
+			e.redis.logf("expiry callbacks %s: claim %s: %v", e.prefix, key, err)
+		}
+		return
+	}
+
+	e.mu.RLock()
+	fn := e.handlers[name]
+	e.mu.RUnlock()
+
+	if fn == nil {
+		err = fmt.Errorf("callback %s is not registered", name)
+	} else {
+		err = fn(ctx, key)
+	}
+
+	if err != nil {
+		e.redis.logf("expiry callbacks %s: %s of %s failed, retry in %s: %v", e.prefix, name, key, e.opt.RetryDelay, err)
+		// the lease expires at retry and the sweeper requeues it
+		e.redis.DoContext(ctx, "zadd", e.key("processing"), unixMilli(time.Now().Add(e.opt.RetryDelay)), key)
+		return
+	}
+
+	expiryDoneScript.run(ctx, e.redis, keys, key)
+}
+
+// key keys of all expiries share a hash tag, so scripts run in one slot
+func (e *ExpiryCallbacks) key(name string) string {
+	return fmt.Sprintf("{%s:expiry}:%s", e.prefix, name)
+}
@@ -0,0 +1,151 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	// Explanation context of the key of a command, for diagnosing slow commands
+	Explanation struct {
+		Command  string        `json:"command"`
+		Key      string        `json:"key,omitempty"`
+		Type     string        `json:"type,omitempty"`
+		Size     int64         `json:"size"`
+		Encoding string        `json:"encoding,omitempty"`
+		TTL      time.Duration `json:"ttl"`
+		Slot     int           `json:"slot"`
+		Node     string        `json:"node,omitempty"`
+		Error    string        `json:"error,omitempty"`
+	}
+)
+
+var (
+	// size command of key types
+	sizeCommands = map[string]string{
+		"string": "strlen",
+		"list":   "llen",
+		"hash":   "hlen",
+		"set":    "scard",
+		"zset":   "zcard",
+		"stream": "xlen",
+	}
+
+	// commands without a key at the first argument
+	keylessCommands = map[string]bool{
+		"auth": true, "client": true, "cluster": true, "command": true, "config": true, "dbsize": true,
+		"echo": true, "exec": true, "flushall": true, "flushdb": true, "info": true, "memory": true,
+		"multi": true, "ping": true, "psubscribe": true, "publish": true, "scan": true, "script": true,
+		"select": true, "slowlog": true, "subscribe": true, "time": true, "xread": true, "xreadgroup": true,
+	}
+)
+
+// Explain gather type, size, encoding, ttl, slot and node of the key of cmd.
+// Commands without a key are explained by the command name only.
+func (r *Redis) Explain(ctx context.Context, cmd redis.Cmder) Explanation {
+	e := Explanation{Command: cmd.Name(), Slot: -1, TTL: -1}
+
+	key, ok := commandKey(cmd)
+	if !ok {
+		return e
+	}
+	e.Key = key
+	e.Slot = keySlot(key)
+
+	typ, err := r.DoContext(ctx, "type", key).Text()
+	if err != nil {
+		e.Error = err.Error()
+		return e
+	}
+	e.Type = typ
+	if typ == "none" {
+		return e
+	}
+
+	if size, ok := sizeCommands[typ]; ok {
+		e.Size, _ = r.DoContext(ctx, size, key).Int64()
+	}
+	e.Encoding, _ = r.DoContext(ctx, "object", "encoding", key).Text()
+	if ttl, err := r.DoContext(ctx, "pttl", key).Int64(); err == nil && ttl >= 0 {
+		e.TTL = time.Duration(ttl) * time.Millisecond
+	}
+
+	if r.isCluster() {
+		e.Node = r.slotNode(ctx, e.Slot)
+	} else {
+		e.Node = nodeAddr(r.client())
+	}
+
+	return e
+}
+
+func (e Explanation) String() string {
+	if e.Key == "" {
+		return fmt.Sprintf("cmd=%s", e.Command)
+	}
+
+	s := fmt.Sprintf("cmd=%s key=%s type=%s size=%d encoding=%s ttl=%s slot=%d node=%s",
+		e.Command, e.Key, e.Type, e.Size, e.Encoding, e.TTL, e.Slot, e.Node)
+	if e.Error != "" {
+		s += " error=" + e.Error
+	}
+
+	return s
+}
+
+// explainSlow log explanations of slow cmds, one explanation runs at a time so slow redis is not loaded further
+func (r *Redis) explainSlow(elapsed time.Duration, cmds []redis.Cmder) {
+	if !atomic.CompareAndSwapInt32(&r.explaining, 0, 1) {
+		return
+	}
+
+	go func() {
+		defer atomic.StoreInt32(&r.explaining, 0)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		for i, cmd := range cmds {
+			// pipelines are explained by their first commands
+			if i >= 5 {
+				break
+			}
+			r.logf("slow command elapsed=%s explain: %s", elapsed, r.Explain(ctx, cmd))
+		}
+	}()
+}
+
+// slotNode master address serving slot by CLUSTER SLOTS
+func (r *Redis) slotNode(ctx context.Context, slot int) string {
+	slots, err := r.clusterSlots(ctx)
+	if err != nil {
+		return ""
+	}
+
+	return slots[slot]
+}
+
+// commandKey first key of cmd
+func commandKey(cmd redis.Cmder) (string, bool) {
+	args := cmd.Args()
+	name := strings.ToLower(cmd.Name())
+
+	switch {
+	case keylessCommands[name]:
+		return "", false
+	case name == "eval" || name == "evalsha":
+		if len(args) > 3 && fmt.Sprint(args[2]) != "0" {
+			return fmt.Sprint(args[3]), true
+		}
+		return "", false
+	case len(args) < 2:
+		return "", false
+	}
+
+	return fmt.Sprint(args[1]), true
+}
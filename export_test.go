@@ -0,0 +1,18 @@
+package redis
+
+// sources of scripts, stubbed on the fake server of redistest by tests of package redis_test
+var (
+	UnlockScript      = unlockScript.src
+	RefreshLockScript = refreshLockScript.src
+
+	SemaphoreAcquireScript = semaphoreAcquireScript.src
+	SemaphoreReleaseScript = semaphoreReleaseScript.src
+	SemaphoreRefreshScript = semaphoreRefreshScript.src
+
+	ReservationReserveScript   = reservationReserveScript.src
+	ReservationSettleScript    = reservationSettleScript.src
+	ReservationAvailableScript = reservationAvailableScript.src
+
+	IdempotencyBeginScript  = idempotencyBeginScript.src
+	IdempotencyFinishScript = idempotencyFinishScript.src
+)
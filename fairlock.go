@@ -0,0 +1,107 @@
+package redis
+
+import (
+	"context"
+	"time"
+)
+
+var (
+	// KEYS[1]: lock, KEYS[2]: waiters list, KEYS[3]: waiter heartbeats zset.
+	// ARGV[1]: token, ARGV[2]: lock value, ARGV[3]: now ms, ARGV[4]: lock ttl ms, ARGV[5]: waiter ttl ms.
+	// returns 1 when granted
+	fairLockScript = newScript(`
+while true do
+	local head = redis.call('lindex', KEYS[2], 0)
+	if not head then
+		break
+	end
+	local beat = redis.call('zscore', KEYS[3], head)
+	if beat and tonumber(beat) > tonumber(ARGV[3]) then
+		break
+	end
+	redis.call('lpop', KEYS[2])
+	redis.call('zrem', KEYS[3], head)
+end
+if redis.call('exists', KEYS[1]) == 0 then
+	local head = redis.call('lindex', KEYS[2], 0)
+	if not head or head == ARGV[1] then
+		redis.call('set', KEYS[1], ARGV[2], 'px', ARGV[4])
+		if head then
+			redis.call('lpop', KEYS[2])
+		end
+		redis.call('zrem', KEYS[3], ARGV[1])
+		return 1
+	end
+end
+if not redis.call('zscore', KEYS[3], ARGV[1]) then
+	redis.call('rpush', KEYS[2], ARGV[1])
+end
+redis.call('zadd', KEYS[3], ARGV[3] + ARGV[5], ARGV[1])
+redis.call('pexpire', KEYS[2], ARGV[5] * 2)
+redis.call('pexpire', KEYS[3], ARGV[5] * 2)
+return 0
+`)
+
+	// KEYS[2]: waiters list, KEYS[3]: waiter heartbeats zset. ARGV[1]: token
+	fairLeaveScript = newScript(`
+redis.call('lrem', KEYS[2], 1, ARGV[1])
+return redis.call('zrem', KEYS[3], ARGV[1])
+`)
+)
+
+// FairLock obtain lock name in FIFO order of waiters, retry until it is obtained or ctx is done.
+// Waiters are queued in a list and heartbeat on every retry, waiters missing heartbeats are skipped.
+// The lock is the same key as Lock obtains, so fair and plain waiters of a name should not be mixed.
+func (l *Locker) FairLock(ctx context.Context, name string) (*Lock, error) {
+	begin := time.Now()
+	token := randomID()
+	keys := l.fairKeys(name)
+	waiterTTL := l.retry * 20
+	contended := false
+
+	for {
+		now := time.Now()
+		value, err := l.holderValue(token, now)
+		if err != nil {
+			return nil, err
+		}
+
+		n, err := fairLockScript.run(ctx, l.redis, keys, token, value, unixMilli(now), l.ttl.Milliseconds(), waiterTTL.Milliseconds()).Int64()
+		if err != nil {
+			fairLeaveScript.run(context.Background(), l.redis, keys, token)
+			return nil, err
+		}
+
+		if n == 1 {
+			l.record(name, func(s *LockStats) { s.Acquired++ })
+			l.observeWait(name, time.Since(begin))
+
+			return &Lock{
+				locker:     l,
+				name:       name,
+				value:      value,
+				acquiredAt: now,
+			}, nil
+		}
+
+		if !contended {
+			contended = true
+			l.contended(name)
+		}
+
+		select {
+		case <-ctx.Done():
+			// leave the queue, otherwise waiters behind wait until the heartbeat expires
+			fairLeaveScript.run(context.Background(), l.redis, keys, token)
+			return nil, ctx.Err()
+		case <-time.After(l.retry):
+		}
+	}
+}
+
+// fairKeys lock and its queue, the queue uses the lock key as hash tag so that they are in the same cluster slot.
+func (l *Locker) fairKeys(name string) []string {
+	key := l.key(name)
+
+	return []string{key, "{" + key + "}:waiters", "{" + key + "}:heartbeats"}
+}
@@ -0,0 +1,91 @@
+package redis
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type (
+	// fallback instance serving reads when the primary is unreachable
+	fallback struct {
+		redis   *Redis
+		counter *prometheus.CounterVec
+	}
+)
+
+// Fallback results of the fallback metrics
+const (
+	FallbackHit   = "hit"
+	FallbackError = "error"
+)
+
+// SetFallback retry read-only commands on fb when the primary (or its replica) fails with a connection error,
+// e.g. an instance of another region. Reads may be stale, writes are never retried.
+// Fallback reads are counted by redis_fallback_reads_total when metrics is enabled.
+func (r *Redis) SetFallback(fb *Redis) {
+	f := &fallback{redis: fb}
+
+	if r.Metrics {
+		f.counter = mustRegister(prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: r.metrics.Namespace,
+				Subsystem: r.metrics.Subsystem,
+				Name:      "redis_fallback_reads_total",
+				Help:      "redis reads retried on the fallback instance total",
+			},
+			[]string{"redis_instance", "fallback", "result"},
+		)).(*prometheus.CounterVec)
+	}
+
+	r.fallback = f
+}
+
+// shouldFallback read failed with err should be retried on the fallback
+func (r *Redis) shouldFallback(err error) bool {
+	return r.fallback != nil && isConnError(err) && r.rolledOut(FeatureFallback)
+}
+
+// countFallback count a fallback read
+func (r *Redis) countFallback(err error) {
+	f := r.fallback
+	if f.counter == nil {
+		return
+	}
+
+	result := FallbackHit
+	if err != nil && err != redis.Nil {
+		result = FallbackError
+	}
+
+	f.counter.WithLabelValues(r.Instance(), f.redis.Instance(), result).Inc()
+}
+
+// isConnError err is from connecting or talking to the server, not a reply of it
+func isConnError(err error) bool {
+	if err == nil || err == redis.Nil {
+		return false
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	switch ErrorClass(err) {
+	case ErrClassConnRefused, ErrClassTimeout:
+		return true
+	}
+
+	msg := err.Error()
+
+	// pool.ErrClosed of go-redis is internal
+	return msg == "redis: client is closed" || strings.Contains(msg, "connection reset") || strings.Contains(msg, "broken pipe") || strings.Contains(msg, "pool timeout")
+}
@@ -0,0 +1,259 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	// Functions Redis 7 function libraries as a box, an alternative of EVALSHA scripts surviving restarts and failovers.
+	// Registered libraries are loaded on every master when served by FUNCTION LOAD REPLACE,
+	// unless the loaded library is identical or of a newer version (deployed by a newer replica of the application).
+	Functions struct {
+		Required bool `config:"required" help:"Fail serve when libraries are not loaded, e.g. redis is older than 7.0"`
+
+		Component
+		mu        sync.Mutex
+		libraries []FunctionLibrary
+	}
+
+	// FunctionLibrary function library source
+	FunctionLibrary struct {
+		Name    string // parsed from the shebang line, e.g. #!lua name=mylib
+		Version int    // written as "-- version: N" after the shebang line, 0 disables version checks
+		Code    string
+	}
+)
+
+// functionVersionPrefix version line of library code
+const functionVersionPrefix = "-- version: "
+
+var (
+	// ErrFunctionsUnsupported redis does not support functions, i.e. older than 7.0
+	ErrFunctionsUnsupported = errors.New("redis: functions are not supported by redis")
+)
+
+// NewFunctions new a function library manager box named name
+func NewFunctions(name string, r *Redis) *Functions {
+	return &Functions{
+		Component: NewComponent(name, r),
+	}
+}
+
+// Register library code of version, register libraries before serve.
+func (f *Functions) Register(code string, version int) error {
+	name, err := functionLibraryName(code)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, lib := range f.libraries {
+		if lib.Name == name {
+			return fmt.Errorf("redis: function library %s is registered", name)
+		}
+	}
+
+	f.libraries = append(f.libraries, FunctionLibrary{Name: name, Version: version, Code: withFunctionVersion(code, version)})
+
+	return nil
+}
+
+// RegisterFS register library files of fs, e.g. http.Dir or packed assets, with the same version.
+func (f *Functions) RegisterFS(fs http.FileSystem, version int, names ...string) error {
+	for _, name := range names {
+		file, err := fs.Open(name)
+		if err != nil {
+			return err
+		}
+
+		code, err := ioutil.ReadAll(file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("redis: function library %s: %w", name, err)
+		}
+
+		if err := f.Register(string(code), version); err != nil {
+			return fmt.Errorf("redis: function library %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// Libraries registered libraries
+func (f *Functions) Libraries() []FunctionLibrary {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]FunctionLibrary(nil), f.libraries...)
+}
+
+// Load registered libraries on every master, functions are not propagated across cluster shards.
+func (f *Functions) Load(ctx context.Context) error {
+	r := f.Component.redis
+	libraries := f.Libraries()
+
+	return r.forEachMaster(func(node redis.UniversalClient, addr string) error {
+		for _, lib := range libraries {
+			loaded, err := loadedFunctionLibrary(ctx, node, lib.Name)
+			if err != nil {
+				return err
+			}
+
+			if loaded == lib.Code {
+				continue
+			}
+			if lib.Version > 0 && functionVersion(loaded) >= lib.Version {
+				r.logf("function library %s of %s is version %d, keep it", lib.Name, addr, functionVersion(loaded))
+				continue
+			}
+
+			if err := node.DoContext(ctx, "function", "load", "replace", lib.Code).Err(); err != nil {
+				return fmt.Errorf("redis: load function library %s on %s: %w", lib.Name, addr, err)
+			}
+			r.logf("function library %s version %d loaded on %s", lib.Name, lib.Version, addr)
+		}
+
+		return nil
+	})
+}
+
+// FCall call function fn with keys and args, keys of a call must be in the same slot of cluster.
+func (f *Functions) FCall(ctx context.Context, fn string, keys []string, args ...interface{}) *redis.Cmd {
+	return f.Component.redis.DoContext(ctx, functionArgs("fcall", fn, keys, args)...)
+}
+
+// FCallRO call read-only function fn (flags=no-writes), it is routed to replicas in read splitting mode.
+func (f *Functions) FCallRO(ctx context.Context, fn string, keys []string, args ...interface{}) *redis.Cmd {
+	r := f.Component.redis
+
+	return r.Reader(ctx).DoContext(ctx, functionArgs("fcall_ro", fn, keys, args)...)
+}
+
+// ConfigWillLoad config will load
+func (f *Functions) ConfigWillLoad(context.Context) {
+
+}
+
+// ConfigDidLoad config did load
+func (f *Functions) ConfigDidLoad(context.Context) {
+
+}
+
+// Serve load registered libraries, errors are logged unless required
+func (f *Functions) Serve(ctx context.Context) error {
+	err := f.Load(ctx)
+	if err == nil {
+		return nil
+	}
+
+	if f.Required {
+		return err
+	}
+
+	f.Component.redis.logf("%v", err)
+
+	return nil
+}
+
+// Shutdown libraries are kept on redis
+func (f *Functions) Shutdown(context.Context) error {
+	return nil
+}
+
+// loadedFunctionLibrary code of library loaded on node, empty when it is not loaded.
+// Reply of FUNCTION LIST is [[library_name, x, engine, LUA, functions, [...], library_code, code]].
+func loadedFunctionLibrary(ctx context.Context, node redis.UniversalClient, name string) (string, error) {
+	v, err := node.DoContext(ctx, "function", "list", "libraryname", name, "withcode").Result()
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "ERR unknown command") {
+			return "", ErrFunctionsUnsupported
+		}
+		return "", err
+	}
+
+	libraries, _ := v.([]interface{})
+	for _, library := range libraries {
+		fields, _ := library.([]interface{})
+
+		attrs := map[string]interface{}{}
+		for i := 0; i+1 < len(fields); i += 2 {
+			attrs[fmt.Sprint(fields[i])] = fields[i+1]
+		}
+
+		// LIBRARYNAME is a pattern, match the name exactly
+		if fmt.Sprint(attrs["library_name"]) == name {
+			return fmt.Sprint(attrs["library_code"]), nil
+		}
+	}
+
+	return "", nil
+}
+
+// functionLibraryName name of the shebang line "#!lua name=x"
+func functionLibraryName(code string) (string, error) {
+	line := code
+	if i := strings.IndexByte(code, '\n'); i >= 0 {
+		line = code[:i]
+	}
+
+	if !strings.HasPrefix(line, "#!") {
+		return "", errors.New("redis: function library without shebang line")
+	}
+
+	for _, field := range strings.Fields(line) {
+		if strings.HasPrefix(field, "name=") {
+			return strings.TrimPrefix(field, "name="), nil
+		}
+	}
+
+	return "", errors.New("redis: function library without name")
+}
+
+// withFunctionVersion code with version line after the shebang line
+func withFunctionVersion(code string, version int) string {
+	if version <= 0 {
+		return code
+	}
+
+	i := strings.IndexByte(code, '\n')
+	if i < 0 {
+		return code + "\n" + functionVersionPrefix + strconv.Itoa(version) + "\n"
+	}
+
+	return code[:i+1] + functionVersionPrefix + strconv.Itoa(version) + "\n" + code[i+1:]
+}
+
+// functionVersion version of library code, 0 when it is not versioned
+func functionVersion(code string) int {
+	for _, line := range strings.SplitN(code, "\n", 3)[1:] {
+		if strings.HasPrefix(line, functionVersionPrefix) {
+			version, _ := strconv.Atoi(strings.TrimPrefix(line, functionVersionPrefix))
+			return version
+		}
+	}
+
+	return 0
+}
+
+func functionArgs(name, fn string, keys []string, args []interface{}) []interface{} {
+	cmdArgs := make([]interface{}, 0, 3+len(keys)+len(args))
+	cmdArgs = append(cmdArgs, name, fn, len(keys))
+
+	for _, key := range keys {
+		cmdArgs = append(cmdArgs, key)
+	}
+
+	return append(cmdArgs, args...)
+}
@@ -0,0 +1,247 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	// Geo helper of geo sets, keys are prefixed by prefix
+	Geo struct {
+		redis  *Redis
+		prefix string
+	}
+
+	// Location member of a geo set, Distance is set by searches in the unit of the query
+	Location struct {
+		Name      string  `json:"name"`
+		Longitude float64 `json:"longitude"`
+		Latitude  float64 `json:"latitude"`
+		Distance  float64 `json:"distance,omitempty"`
+	}
+
+	// GeoUnit distance unit
+	GeoUnit string
+
+	// GeoQuery search around Member, or Longitude and Latitude when Member is empty,
+	// within Radius, or the Width x Height box when Radius is 0.
+	GeoQuery struct {
+		Member    string
+		Longitude float64
+		Latitude  float64
+		Radius    float64
+		Width     float64
+		Height    float64
+		Unit      GeoUnit // default is Meters
+		Desc      bool    // farthest first, default is nearest first
+		Offset    int
+		Limit     int // default is unlimited
+	}
+)
+
+// Distance units
+const (
+	Meters     GeoUnit = "m"
+	Kilometers GeoUnit = "km"
+	Miles      GeoUnit = "mi"
+	Feet       GeoUnit = "ft"
+)
+
+var (
+	// ErrGeoQuery query has neither radius nor box
+	ErrGeoQuery = errors.New("redis: geo query requires radius or width and height")
+)
+
+// NewGeo new a geo helper, keys are prefixed by prefix.
+func NewGeo(r *Redis, prefix string) *Geo {
+	return &Geo{
+		redis:  r,
+		prefix: prefix,
+	}
+}
+
+// Add add or update locations, return the number of new members
+func (g *Geo) Add(ctx context.Context, key string, locations ...Location) (int64, error) {
+	if len(locations) == 0 {
+		return 0, nil
+	}
+
+	args := make([]interface{}, 0, 2+3*len(locations))
+	args = append(args, "geoadd", g.key(key))
+	for _, loc := range locations {
+		args = append(args, loc.Longitude, loc.Latitude, loc.Name)
+	}
+
+	return g.redis.DoContext(ctx, args...).Int64()
+}
+
+// Remove remove members
+func (g *Geo) Remove(ctx context.Context, key string, names ...string) (int64, error) {
+	if len(names) == 0 {
+		return 0, nil
+	}
+
+	args := make([]interface{}, 0, 2+len(names))
+	args = append(args, "zrem", g.key(key))
+	for _, name := range names {
+		args = append(args, name)
+	}
+
+	return g.redis.DoContext(ctx, args...).Int64()
+}
+
+// Position locations of names, nil for missing members
+func (g *Geo) Position(ctx context.Context, key string, names ...string) ([]*Location, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	args := make([]interface{}, 0, 2+len(names))
+	args = append(args, "geopos", g.key(key))
+	for _, name := range names {
+		args = append(args, name)
+	}
+
+	val, err := g.redis.DoContext(ctx, args...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	values, _ := val.([]interface{})
+	locations := make([]*Location, len(names))
+	for i, v := range values {
+		if i >= len(names) {
+			break
+		}
+
+		lon, lat, ok := geoCoord(v)
+		if !ok {
+			continue
+		}
+		locations[i] = &Location{Name: names[i], Longitude: lon, Latitude: lat}
+	}
+
+	return locations, nil
+}
+
+// Distance distance between members a and b in unit, found is false when either is missing
+func (g *Geo) Distance(ctx context.Context, key, a, b string, unit GeoUnit) (distance float64, found bool, err error) {
+	distance, err = g.redis.DoContext(ctx, "geodist", g.key(key), a, b, unit.String()).Float64()
+	if err == redis.Nil {
+		return 0, false, nil
+	}
+
+	return distance, err == nil, err
+}
+
+// Nearby locations within radius meters from lat, lon, nearest first with distances in meters
+func (g *Geo) Nearby(ctx context.Context, key string, lat, lon, radius float64) ([]Location, error) {
+	return g.Search(ctx, key, GeoQuery{Longitude: lon, Latitude: lat, Radius: radius})
+}
+
+// Search locations matching q with distances in q.Unit.
+// GEOSEARCH is used, on redis before 6.2 radius queries fall back to GEORADIUS and box queries fail.
+// Pages are sliced from the nearest (or farthest) Offset+Limit locations, so keep offsets small.
+func (g *Geo) Search(ctx context.Context, key string, q GeoQuery) ([]Location, error) {
+	if q.Radius <= 0 && (q.Width <= 0 || q.Height <= 0) {
+		return nil, ErrGeoQuery
+	}
+
+	args := []interface{}{"geosearch", g.key(key)}
+	if q.Member != "" {
+		args = append(args, "frommember", q.Member)
+	} else {
+		args = append(args, "fromlonlat", q.Longitude, q.Latitude)
+	}
+	if q.Radius > 0 {
+		args = append(args, "byradius", q.Radius, q.Unit.String())
+	} else {
+		args = append(args, "bybox", q.Width, q.Height, q.Unit.String())
+	}
+	args = append(args, q.tail()...)
+
+	val, err := g.redis.DoContext(ctx, args...).Result()
+	if err != nil && q.Radius > 0 && strings.HasPrefix(err.Error(), "ERR unknown command") {
+		args = []interface{}{"georadius", g.key(key), q.Longitude, q.Latitude}
+		if q.Member != "" {
+			args = []interface{}{"georadiusbymember", g.key(key), q.Member}
+		}
+		args = append(args, q.Radius, q.Unit.String())
+		args = append(args, q.tail()...)
+
+		val, err = g.redis.DoContext(ctx, args...).Result()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	values, _ := val.([]interface{})
+	if q.Offset >= len(values) {
+		return []Location{}, nil
+	}
+	values = values[q.Offset:]
+
+	locations := make([]Location, 0, len(values))
+	for _, v := range values {
+		// [name, distance, [longitude, latitude]]
+		item, ok := v.([]interface{})
+		if !ok || len(item) < 3 {
+			return nil, fmt.Errorf("redis: unexpected geo reply %v", v)
+		}
+
+		loc := Location{Name: fmt.Sprint(item[0])}
+		loc.Distance, _ = strconv.ParseFloat(fmt.Sprint(item[1]), 64)
+		loc.Longitude, loc.Latitude, _ = geoCoord(item[2])
+
+		locations = append(locations, loc)
+	}
+
+	return locations, nil
+}
+
+// tail order, count and with options of search commands
+func (q GeoQuery) tail() []interface{} {
+	args := []interface{}{"asc"}
+	if q.Desc {
+		args[0] = "desc"
+	}
+	if q.Limit > 0 {
+		args = append(args, "count", q.Offset+q.Limit)
+	}
+
+	return append(args, "withcoord", "withdist")
+}
+
+func (u GeoUnit) String() string {
+	if u == "" {
+		return string(Meters)
+	}
+
+	return string(u)
+}
+
+func (g *Geo) key(key string) string {
+	if g.prefix == "" {
+		return key
+	}
+
+	return g.prefix + ":" + key
+}
+
+// geoCoord longitude and latitude of a coordinate reply
+func geoCoord(v interface{}) (lon, lat float64, ok bool) {
+	coord, _ := v.([]interface{})
+	if len(coord) != 2 {
+		return 0, 0, false
+	}
+
+	lon, err1 := strconv.ParseFloat(fmt.Sprint(coord[0]), 64)
+	lat, err2 := strconv.ParseFloat(fmt.Sprint(coord[1]), 64)
+
+	return lon, lat, err1 == nil && err2 == nil
+}
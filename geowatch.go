@@ -0,0 +1,173 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+type (
+	// GeoWatch geofences watcher, position updates of subjects trigger enter/exit events
+	// which are returned to the updater and published to listeners.
+	// All keys use the same hash tag, so they are in one cluster slot.
+	GeoWatch struct {
+		redis  *Redis
+		prefix string
+	}
+
+	// Geofence circle fence
+	Geofence struct {
+		ID        string
+		Longitude float64
+		Latitude  float64
+		Radius    float64 // meters
+	}
+
+	// GeoEvent subject enter or exit a fence
+	GeoEvent struct {
+		Type    string `json:"type"`
+		Fence   string `json:"fence"`
+		Subject string `json:"subject"`
+	}
+)
+
+const (
+	// GeoEnter subject entered fence
+	GeoEnter = "enter"
+	// GeoExit subject exited fence
+	GeoExit = "exit"
+)
+
+var (
+	// KEYS: fences geo, radius hash, max radius, positions geo, inside set of subject
+	// ARGV: subject, longitude, latitude
+	// writes follow smembers and georadius, effects replication keeps replicas in step (no-op on redis 7)
+	geoWatchUpdateScript = newScript(`
+redis.replicate_commands()
+redis.call('geoadd', KEYS[4], ARGV[2], ARGV[3], ARGV[1])
+local inside = {}
+local maxr = tonumber(redis.call('get', KEYS[3]) or '0')
+if maxr > 0 then
+	local near = redis.call('georadius', KEYS[1], ARGV[2], ARGV[3], maxr, 'm', 'WITHDIST')
+	for _, item in ipairs(near) do
+		local r = tonumber(redis.call('hget', KEYS[2], item[1]) or '0')
+		if tonumber(item[2]) <= r then
+			inside[item[1]] = true
+		end
+	end
+end
+local events = {}
+for _, id in ipairs(redis.call('smembers', KEYS[5])) do
+	if inside[id] then
+		inside[id] = nil
+	else
+		redis.call('srem', KEYS[5], id)
+		table.insert(events, 'exit')
+		table.insert(events, id)
+	end
+end
+for id in pairs(inside) do
+	redis.call('sadd', KEYS[5], id)
+	table.insert(events, 'enter')
+	table.insert(events, id)
+end
+return events
+`)
+
+	// KEYS: fences geo, radius hash, max radius. ARGV: id, longitude, latitude, radius
+	geoWatchAddFenceScript = newScript(`
+redis.call('geoadd', KEYS[1], ARGV[2], ARGV[3], ARGV[1])
+redis.call('hset', KEYS[2], ARGV[1], ARGV[4])
+if tonumber(redis.call('get', KEYS[3]) or '0') < tonumber(ARGV[4]) then
+	redis.call('set', KEYS[3], ARGV[4])
+end
+return 1
+`)
+)
+
+// NewGeoWatch new a geofence watcher, keys are prefixed by prefix.
+func NewGeoWatch(r *Redis, prefix string) *GeoWatch {
+	return &GeoWatch{
+		redis:  r,
+		prefix: prefix,
+	}
+}
+
+// AddFence add or replace a fence
+func (g *GeoWatch) AddFence(ctx context.Context, fence Geofence) error {
+	return geoWatchAddFenceScript.run(ctx, g.redis, []string{g.key("fences"), g.key("radius"), g.key("maxradius")},
+		fence.ID, fence.Longitude, fence.Latitude, fence.Radius).Err()
+}
+
+// RemoveFence remove a fence, subjects inside it will get exit events on next update
+func (g *GeoWatch) RemoveFence(ctx context.Context, id string) error {
+	if err := g.redis.DoContext(ctx, "zrem", g.key("fences"), id).Err(); err != nil {
+		return err
+	}
+
+	return g.redis.DoContext(ctx, "hdel", g.key("radius"), id).Err()
+}
+
+// Update position of subject, return and publish the enter/exit events
+func (g *GeoWatch) Update(ctx context.Context, subject string, longitude, latitude float64) ([]GeoEvent, error) {
+	keys := []string{g.key("fences"), g.key("radius"), g.key("maxradius"), g.key("positions"), g.key("inside:" + subject)}
+
+	val, err := geoWatchUpdateScript.run(ctx, g.redis, keys, subject, longitude, latitude).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	values, _ := val.([]interface{})
+	events := make([]GeoEvent, 0, len(values)/2)
+
+	for i := 0; i+1 < len(values); i += 2 {
+		event := GeoEvent{
+			Type:    fmt.Sprint(values[i]),
+			Fence:   fmt.Sprint(values[i+1]),
+			Subject: subject,
+		}
+		events = append(events, event)
+
+		data, _ := json.Marshal(event)
+		if err := g.redis.DoContext(ctx, "publish", g.channel(), string(data)).Err(); err != nil {
+			return events, err
+		}
+	}
+
+	return events, nil
+}
+
+// Listen events published by all updaters and call handler, block until ctx done
+func (g *GeoWatch) Listen(ctx context.Context, handler func(GeoEvent)) error {
+	ps := g.redis.Subscribe(g.channel())
+	defer ps.Close()
+
+	if _, err := ps.Receive(); err != nil {
+		return err
+	}
+
+	ch := ps.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-ch:
+			if !ok {
+				return nil
+			}
+
+			event := GeoEvent{}
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err == nil {
+				handler(event)
+			}
+		}
+	}
+}
+
+func (g *GeoWatch) key(name string) string {
+	return g.prefix + ":{geowatch}:" + name
+}
+
+func (g *GeoWatch) channel() string {
+	return g.prefix + ":geowatch:events"
+}
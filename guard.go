@@ -0,0 +1,277 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	// guardHook reject commands violating guard policies before sent, added only when guards are configured
+	guardHook struct {
+		r *Redis
+	}
+
+	// GuardError command rejected by a guard policy
+	GuardError struct {
+		Command string
+		Key     string
+		Rule    string
+	}
+
+	truncatedKey struct{}
+
+	// valueArg value written to key by the arg-th argument of a command
+	valueArg struct {
+		key string
+		arg int
+	}
+)
+
+// Guard rules
+const (
+	GuardRuleDenied     = "denied"
+	GuardRuleNotAllowed = "not allowed"
+	GuardRuleTTL        = "ttl required"
+	GuardRuleValueSize  = "value too large"
+)
+
+// Guard actions on values larger than guardMaxValueBytes
+const (
+	GuardValueReject   = "reject"
+	GuardValueWarn     = "warn"
+	GuardValueTruncate = "truncate"
+)
+
+func (e *GuardError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("redis: %s rejected by guard: %s", e.Command, e.Rule)
+	}
+
+	return fmt.Sprintf("redis: %s %s rejected by guard: %s", e.Command, e.Key, e.Rule)
+}
+
+func (r *Redis) guarded() bool {
+	return r.GuardRequireTTL || r.GuardMaxValueBytes > 0 || len(r.GuardDeny) > 0 || len(r.GuardAllow) > 0
+}
+
+// guard check cmd against the guard policies of the instance, truncated is the error cmd fails with after sent
+// when its values are truncated
+func (r *Redis) guard(ctx context.Context, cmd redis.Cmder) (truncated, err error) {
+	name := strings.ToLower(cmd.Name())
+
+	for _, denied := range r.GuardDeny {
+		if strings.EqualFold(denied, name) {
+			return nil, &GuardError{Command: name, Rule: GuardRuleDenied}
+		}
+	}
+
+	if len(r.GuardAllow) > 0 {
+		allowed := false
+		for _, allow := range r.GuardAllow {
+			if strings.EqualFold(allow, name) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return nil, &GuardError{Command: name, Rule: GuardRuleNotAllowed}
+		}
+	}
+
+	if r.GuardRequireTTL && !hasTTL(cmd) {
+		key, _ := commandKey(cmd)
+		if !r.ttlExempt(key) {
+			return nil, &GuardError{Command: name, Key: key, Rule: GuardRuleTTL}
+		}
+	}
+
+	if r.GuardMaxValueBytes > 0 {
+		return r.guardValues(ctx, name, cmd)
+	}
+
+	return nil, nil
+}
+
+// guardValues apply guardValueAction to values of cmd larger than guardMaxValueBytes
+func (r *Redis) guardValues(ctx context.Context, name string, cmd redis.Cmder) (truncated, err error) {
+	action := strings.ToLower(r.GuardValueAction)
+	if action == "" {
+		action = GuardValueReject
+	}
+
+	args := cmd.Args()
+	for _, v := range valueArgs(cmd) {
+		value := argBytes(args[v.arg])
+		if len(value) <= r.GuardMaxValueBytes {
+			continue
+		}
+
+		if r.largeValues != nil {
+			r.largeValues.WithLabelValues(r.Instance(), matchPrefix(r.guardPrefixes, v.key), action).Inc()
+		}
+
+		guardErr := &GuardError{Command: name, Key: v.key, Rule: fmt.Sprintf("%s, %d > %d bytes", GuardRuleValueSize, len(value), r.GuardMaxValueBytes)}
+
+		switch action {
+		case GuardValueWarn:
+			r.logf("guard: %s %s writes %d bytes > %d, caller=%s", name, v.key, len(value), r.GuardMaxValueBytes, CallerFrom(ctx))
+		case GuardValueTruncate:
+			args[v.arg] = value[:r.GuardMaxValueBytes]
+			if truncated == nil {
+				truncated = guardErr
+			}
+		default:
+			return nil, guardErr
+		}
+	}
+
+	return truncated, nil
+}
+
+// valueArgs values written by SET family, HSET family and APPEND commands
+func valueArgs(cmd redis.Cmder) []valueArg {
+	args := cmd.Args()
+	if len(args) < 2 {
+		return nil
+	}
+
+	key := fmt.Sprint(args[1])
+	single := func(arg int) []valueArg {
+		if len(args) <= arg {
+			return nil
+		}
+		return []valueArg{{key: key, arg: arg}}
+	}
+
+	switch strings.ToLower(cmd.Name()) {
+	case "set", "setnx", "getset", "append":
+		return single(2)
+	case "setex", "psetex":
+		return single(3)
+	case "hsetnx":
+		return single(3)
+	case "mset", "msetnx":
+		values := make([]valueArg, 0, len(args)/2)
+		for i := 1; i+1 < len(args); i += 2 {
+			values = append(values, valueArg{key: fmt.Sprint(args[i]), arg: i + 1})
+		}
+		return values
+	case "hset", "hmset":
+		values := make([]valueArg, 0, len(args)/2)
+		for i := 3; i < len(args); i += 2 {
+			values = append(values, valueArg{key: key, arg: i})
+		}
+		return values
+	}
+
+	return nil
+}
+
+// sortGuardPrefixes sort guardValuePrefixes by length desc for matchPrefix
+func (r *Redis) sortGuardPrefixes() {
+	prefixes := append([]string(nil), r.GuardValuePrefixes...)
+	sort.Slice(prefixes, func(i, j int) bool {
+		return len(prefixes[i]) > len(prefixes[j])
+	})
+
+	r.guardPrefixes = prefixes
+}
+
+// hasTTL SET family commands write keys with ttl, other commands are not checked
+func hasTTL(cmd redis.Cmder) bool {
+	args := cmd.Args()
+
+	switch strings.ToLower(cmd.Name()) {
+	case "set":
+		// options follow the key and the value, which may be any word themselves
+		if len(args) < 3 {
+			return false
+		}
+		for _, arg := range args[3:] {
+			switch strings.ToLower(fmt.Sprint(arg)) {
+			case "ex", "px", "exat", "pxat", "keepttl":
+				return true
+			}
+		}
+		return false
+	case "setnx", "getset", "mset", "msetnx":
+		return false
+	}
+
+	return true
+}
+
+func (r *Redis) ttlExempt(key string) bool {
+	for _, prefix := range r.GuardTTLExempt {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (h guardHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	if isRaw(ctx) {
+		return ctx, nil
+	}
+
+	truncated, err := h.r.guard(ctx, cmd)
+	if err != nil || truncated == nil {
+		return ctx, err
+	}
+
+	return context.WithValue(ctx, truncatedKey{}, map[redis.Cmder]error{cmd: truncated}), nil
+}
+
+// AfterProcess fail cmd sent with truncated values
+func (h guardHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	truncated, _ := ctx.Value(truncatedKey{}).(map[redis.Cmder]error)
+
+	err, ok := truncated[cmd]
+	if ok {
+		cmd.SetErr(err)
+	}
+
+	return err
+}
+
+func (h guardHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	var truncated map[redis.Cmder]error
+	for _, cmd := range cmds {
+		cut, err := h.r.guard(ctx, cmd)
+		if err != nil {
+			return ctx, err
+		}
+		if cut != nil {
+			if truncated == nil {
+				truncated = make(map[redis.Cmder]error)
+			}
+			truncated[cmd] = cut
+		}
+	}
+
+	if truncated == nil {
+		return ctx, nil
+	}
+
+	return context.WithValue(ctx, truncatedKey{}, truncated), nil
+}
+
+// AfterProcessPipeline fail commands sent with truncated values. No error is returned, go-redis would set it on
+// every command of the pipeline.
+func (h guardHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	truncated, _ := ctx.Value(truncatedKey{}).(map[redis.Cmder]error)
+
+	for _, cmd := range cmds {
+		if err, ok := truncated[cmd]; ok {
+			cmd.SetErr(err)
+		}
+	}
+
+	return nil
+}
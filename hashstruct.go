@@ -0,0 +1,271 @@
+package redis
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	structField struct {
+		name      string
+		index     []int
+		omitEmpty bool
+	}
+)
+
+var (
+	// ErrNotStruct value is not a struct or pointer to struct
+	ErrNotStruct = errors.New("redis: value is not a struct")
+
+	timeType = reflect.TypeOf(time.Time{})
+)
+
+// HSetStruct write fields of struct v into hash key, fields are named by `redis:"name,omitempty"` tags,
+// untagged fields use the field name and `redis:"-"` fields are skipped.
+// Only the listed fields are written when fields is not empty, ttl is applied when positive.
+func (r *Redis) HSetStruct(ctx context.Context, key string, v interface{}, ttl time.Duration, fields ...string) error {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return ErrNotStruct
+	}
+
+	only := map[string]bool{}
+	for _, field := range fields {
+		only[field] = true
+	}
+
+	args := []interface{}{"hset", key}
+	for _, f := range structFields(rv.Type()) {
+		if len(only) > 0 && !only[f.name] {
+			continue
+		}
+
+		fv := rv.FieldByIndex(f.index)
+		if f.omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		}
+
+		s, err := formatField(fv)
+		if err != nil {
+			return fmt.Errorf("redis: field %s: %w", f.name, err)
+		}
+
+		args = append(args, f.name, s)
+	}
+
+	if len(args) == 2 {
+		return nil
+	}
+
+	if ttl <= 0 {
+		return r.DoContext(ctx, args...).Err()
+	}
+
+	_, err := r.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Do(args...)
+		pipe.PExpire(key, ttl)
+		return nil
+	})
+
+	return err
+}
+
+// HGetStruct read hash key into struct pointer dest, found is false when the hash does not exist.
+func (r *Redis) HGetStruct(ctx context.Context, key string, dest interface{}) (found bool, err error) {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return false, ErrNotStruct
+	}
+	rv = rv.Elem()
+
+	cmd := redis.NewStringStringMapCmd("hgetall", key)
+	if err := r.ProcessContext(ctx, cmd); err != nil {
+		return false, err
+	}
+
+	values := cmd.Val()
+	if len(values) == 0 {
+		return false, nil
+	}
+
+	return true, decodeStruct(values, rv)
+}
+
+// DecodeStruct decode field values into struct pointer dest, fields are named like HSetStruct.
+// Companion packages decode hashes returned by module commands with it.
+func DecodeStruct(values map[string]string, dest interface{}) error {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return ErrNotStruct
+	}
+
+	return decodeStruct(values, rv.Elem())
+}
+
+func decodeStruct(values map[string]string, rv reflect.Value) error {
+	for _, f := range structFields(rv.Type()) {
+		s, ok := values[f.name]
+		if !ok {
+			continue
+		}
+
+		fv := rv.FieldByIndex(f.index)
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				fv.Set(reflect.New(fv.Type().Elem()))
+			}
+			fv = fv.Elem()
+		}
+
+		if err := parseField(fv, s); err != nil {
+			return fmt.Errorf("redis: field %s: %w", f.name, err)
+		}
+	}
+
+	return nil
+}
+
+func structFields(t reflect.Type) []structField {
+	fields := make([]structField, 0, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		tag := sf.Tag.Get("redis")
+		if tag == "-" {
+			continue
+		}
+
+		parts := strings.Split(tag, ",")
+		f := structField{name: parts[0], index: sf.Index}
+		if f.name == "" {
+			f.name = sf.Name
+		}
+		for _, opt := range parts[1:] {
+			if opt == "omitempty" {
+				f.omitEmpty = true
+			}
+		}
+
+		fields = append(fields, f)
+	}
+
+	return fields
+}
+
+func formatField(v reflect.Value) (string, error) {
+	if v.Type() == timeType {
+		return v.Interface().(time.Time).Format(time.RFC3339Nano), nil
+	}
+
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		b, err := m.MarshalText()
+		return string(b), err
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes()), nil
+		}
+	}
+
+	b, err := json.Marshal(v.Interface())
+
+	return string(b), err
+}
+
+func parseField(v reflect.Value, s string) error {
+	if v.Type() == timeType {
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err == nil {
+			v.Set(reflect.ValueOf(t))
+		}
+		return err
+	}
+
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+		return nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		v.SetBool(b)
+		return err
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		v.SetInt(n)
+		return err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		v.SetUint(n)
+		return err
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		v.SetFloat(n)
+		return err
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes([]byte(s))
+			return nil
+		}
+	}
+
+	return json.Unmarshal([]byte(s), v.Addr().Interface())
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return v.Interface().(time.Time).IsZero()
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,130 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	// Idempotency run a func once per idempotency key and replay its stored result for duplicates,
+	// e.g. payment and webhook handlers.
+	Idempotency struct {
+		redis      *Redis
+		prefix     string
+		codec      Codec
+		processing time.Duration
+	}
+)
+
+var (
+	// ErrInProgress a duplicate request is being processed, the caller should retry later (e.g. 409)
+	ErrInProgress = errors.New("redis: idempotent request in progress")
+
+	// KEYS[1]: record. ARGV[1]: in-progress marker, ARGV[2]: processing ttl ms.
+	// returns false when the marker is set, stored record otherwise
+	idempotencyBeginScript = newScript(`
+local record = redis.call('get', KEYS[1])
+if record then
+	return record
+end
+redis.call('set', KEYS[1], ARGV[1], 'px', ARGV[2])
+return false
+`)
+
+	// KEYS[1]: record. ARGV[1]: in-progress marker, ARGV[2]: result record, empty to delete, ARGV[3]: ttl ms, zero is no ttl
+	idempotencyFinishScript = newScript(`
+if redis.call('get', KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+if ARGV[2] == '' then
+	redis.call('del', KEYS[1])
+elseif tonumber(ARGV[3]) > 0 then
+	redis.call('set', KEYS[1], ARGV[2], 'px', ARGV[3])
+else
+	redis.call('set', KEYS[1], ARGV[2])
+end
+return 1
+`)
+)
+
+const (
+	idempotencyInProgress = "p:"
+	idempotencyDone       = "d:"
+
+	defaultIdempotencyProcessing = 30 * time.Second
+)
+
+// NewIdempotency new an idempotency helper, keys are prefixed by prefix, codec is JSONCodec if it is nil.
+// A request not finished in processing (default 30s) is treated as failed and can be retried.
+func NewIdempotency(r *Redis, prefix string, codec Codec, processing time.Duration) *Idempotency {
+	if codec == nil {
+		codec = JSONCodec
+	}
+	if processing <= 0 {
+		processing = defaultIdempotencyProcessing
+	}
+
+	return &Idempotency{
+		redis:      r,
+		prefix:     prefix,
+		codec:      codec,
+		processing: processing,
+	}
+}
+
+// Do run fn once for key and decode its result into v, the result is stored for ttl.
+// Duplicates decode the stored result into v with replayed true, or get ErrInProgress while fn is running.
+// Results of failed fn are not stored, so the request can be retried.
+func (idem *Idempotency) Do(ctx context.Context, key string, ttl time.Duration, v interface{}, fn Loader) (replayed bool, err error) {
+	marker := idempotencyInProgress + randomID()
+
+	val, err := idempotencyBeginScript.run(ctx, idem.redis, []string{idem.key(key)}, marker, idem.processing.Milliseconds()).Result()
+	if err != nil && err != redis.Nil {
+		return false, err
+	}
+
+	if record, ok := val.(string); ok {
+		switch {
+		case strings.HasPrefix(record, idempotencyInProgress):
+			return false, ErrInProgress
+		case strings.HasPrefix(record, idempotencyDone):
+			return true, idem.codec.Unmarshal([]byte(record[len(idempotencyDone):]), v)
+		default:
+			return false, fmt.Errorf("redis: unexpected idempotency record of %s", key)
+		}
+	}
+
+	result, err := fn(ctx)
+	if err != nil {
+		idempotencyFinishScript.run(context.Background(), idem.redis, []string{idem.key(key)}, marker, "", 0)
+		return false, err
+	}
+
+	data, err := idem.codec.Marshal(result)
+	if err != nil {
+		idempotencyFinishScript.run(context.Background(), idem.redis, []string{idem.key(key)}, marker, "", 0)
+		return false, err
+	}
+
+	// stored even when ctx is done, since fn has taken effect
+	err = idempotencyFinishScript.run(context.Background(), idem.redis, []string{idem.key(key)}, marker, idempotencyDone+string(data), ttl.Milliseconds()).Err()
+	if err != nil {
+		return false, err
+	}
+
+	return false, idem.codec.Unmarshal(data, v)
+}
+
+// Forget stored result of key, so the next request runs again
+func (idem *Idempotency) Forget(ctx context.Context, key string) error {
+	return idem.redis.DoContext(ctx, "del", idem.key(key)).Err()
+}
+
+func (idem *Idempotency) key(key string) string {
+	return idem.prefix + ":" + key
+}
@@ -0,0 +1,152 @@
+package redis_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/boxgo/redis"
+	"github.com/boxgo/redis/redistest"
+)
+
+func newIdempotencyServer(t *testing.T) (*redis.Redis, *redistest.Server) {
+	r, srv := redistest.New(t)
+
+	srv.Script(redis.IdempotencyBeginScript, func(call func(args ...string) (interface{}, error), keys, args []string) (interface{}, error) {
+		if record, err := call("get", keys[0]); err != nil || record != nil {
+			return record, err
+		}
+		call("set", keys[0], args[0], "px", args[1])
+		return false, nil
+	})
+	srv.Script(redis.IdempotencyFinishScript, func(call func(args ...string) (interface{}, error), keys, args []string) (interface{}, error) {
+		if record, err := call("get", keys[0]); err != nil || record != args[0] {
+			return int64(0), err
+		}
+
+		switch ttl, _ := strconv.ParseInt(args[2], 10, 64); {
+		case args[1] == "":
+			call("del", keys[0])
+		case ttl > 0:
+			call("set", keys[0], args[1], "px", args[2])
+		default:
+			call("set", keys[0], args[1])
+		}
+		return int64(1), nil
+	})
+
+	return r, srv
+}
+
+func TestIdempotencyReplay(t *testing.T) {
+	r, srv := newIdempotencyServer(t)
+	ctx := context.Background()
+	idem := redis.NewIdempotency(r, "idem", nil, time.Minute)
+
+	calls := 0
+	fn := func(context.Context) (interface{}, error) {
+		calls++
+		return map[string]int{"charge": calls}, nil
+	}
+
+	for i, wantReplayed := range []bool{false, true, true} {
+		var v map[string]int
+		replayed, err := idem.Do(ctx, "pay-1", time.Hour, &v, fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if replayed != wantReplayed || v["charge"] != 1 {
+			t.Errorf("call %d got replayed %v result %v", i, replayed, v)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("fn called %d times, want 1", calls)
+	}
+
+	// results expire after ttl
+	srv.FastForward(2 * time.Hour)
+	var v map[string]int
+	if replayed, err := idem.Do(ctx, "pay-1", time.Hour, &v, fn); err != nil || replayed || v["charge"] != 2 {
+		t.Errorf("Do after ttl got replayed %v result %v %v", replayed, v, err)
+	}
+
+	if err := idem.Forget(ctx, "pay-1"); err != nil {
+		t.Fatal(err)
+	}
+	if replayed, err := idem.Do(ctx, "pay-1", time.Hour, &v, fn); err != nil || replayed || v["charge"] != 3 {
+		t.Errorf("Do after Forget got replayed %v result %v %v", replayed, v, err)
+	}
+}
+
+func TestIdempotencyFailed(t *testing.T) {
+	r, _ := newIdempotencyServer(t)
+	ctx := context.Background()
+	idem := redis.NewIdempotency(r, "idem", nil, time.Minute)
+
+	failure := errors.New("declined")
+	var v string
+	if _, err := idem.Do(ctx, "pay-1", time.Hour, &v, func(context.Context) (interface{}, error) {
+		return nil, failure
+	}); err != failure {
+		t.Fatalf("Do of a failing fn expected its error, got %v", err)
+	}
+
+	// failed results are not stored, the request is retried
+	replayed, err := idem.Do(ctx, "pay-1", time.Hour, &v, func(context.Context) (interface{}, error) {
+		return "ok", nil
+	})
+	if err != nil || replayed || v != "ok" {
+		t.Fatalf("retry after a failure got replayed %v result %q %v", replayed, v, err)
+	}
+}
+
+func TestIdempotencyInProgress(t *testing.T) {
+	r, srv := newIdempotencyServer(t)
+	ctx := context.Background()
+	idem := redis.NewIdempotency(r, "idem", nil, time.Minute)
+
+	started, finish := make(chan struct{}), make(chan struct{})
+	done := make(chan error)
+	go func() {
+		var v string
+		_, err := idem.Do(ctx, "pay-1", time.Hour, &v, func(context.Context) (interface{}, error) {
+			close(started)
+			<-finish
+			return "ok", nil
+		})
+		done <- err
+	}()
+	<-started
+
+	var v string
+	if _, err := idem.Do(ctx, "pay-1", time.Hour, &v, func(context.Context) (interface{}, error) {
+		t.Error("duplicate ran while in progress")
+		return nil, nil
+	}); err != redis.ErrInProgress {
+		t.Errorf("duplicate in progress expected ErrInProgress, got %v", err)
+	}
+
+	close(finish)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	// a request not finished in processing, e.g. of a crashed process, is retried after it
+	if err := r.Set("idem:pay-2", "p:crashed", time.Minute).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := idem.Do(ctx, "pay-2", time.Hour, &v, func(context.Context) (interface{}, error) {
+		return "ok", nil
+	}); err != redis.ErrInProgress {
+		t.Fatalf("crashed request within processing expected ErrInProgress, got %v", err)
+	}
+
+	srv.FastForward(2 * time.Minute)
+	if replayed, err := idem.Do(ctx, "pay-2", time.Hour, &v, func(context.Context) (interface{}, error) {
+		return "retried", nil
+	}); err != nil || replayed || v != "retried" {
+		t.Fatalf("retry after processing got replayed %v result %q %v", replayed, v, err)
+	}
+}
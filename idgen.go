@@ -0,0 +1,280 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	// Sequence sequential ids allocated in blocks, ids are unique across instances and increasing within an instance.
+	// Ids of a block not served before restart are skipped.
+	Sequence struct {
+		redis *Redis
+		key   string
+		block int64
+
+		mu   sync.Mutex
+		next int64
+		end  int64 // last id of the allocated block
+	}
+
+	// Snowflake 64-bit time-ordered ids of milliseconds since epoch, worker id and sequence in the millisecond.
+	// Worker ids are leased through redis, so instances never share one while the lease is renewed.
+	Snowflake struct {
+		redis   *Redis
+		prefix  string
+		options SnowflakeOptions
+		token   string
+
+		mu       sync.Mutex
+		worker   int64     // -1 when no worker id is leased
+		expires  time.Time // lease expiration as of the last renewal
+		lastMs   int64
+		sequence int64
+
+		done   chan struct{}
+		closed sync.Once
+	}
+
+	// SnowflakeOptions options of snowflake generator
+	SnowflakeOptions struct {
+		Epoch        time.Time     // epoch of timestamps, default 2020-01-01 UTC
+		WorkerBits   uint          // bits of worker id, default 10 (1024 workers)
+		SequenceBits uint          // bits of sequence in a millisecond, default 12
+		LeaseTTL     time.Duration // ttl of worker id lease renewed every ttl/3, default 30s
+	}
+)
+
+var (
+	// ErrNoWorkerID all worker ids are leased by other instances
+	ErrNoWorkerID = errors.New("redis: no worker id available")
+
+	// ErrWorkerLeaseLost the worker id lease expired, ids are not generated until a worker id is leased again
+	ErrWorkerLeaseLost = errors.New("redis: worker id lease lost")
+)
+
+// NewSequence new a sequence of key, block ids are allocated by a INCRBY, default 1000
+func NewSequence(r *Redis, key string, block int64) *Sequence {
+	if block <= 0 {
+		block = 1000
+	}
+
+	return &Sequence{
+		redis: r,
+		key:   key,
+		block: block,
+	}
+}
+
+// Next id of the sequence, redis is called once per block
+func (s *Sequence) Next(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.next == 0 || s.next > s.end {
+		end, err := s.redis.DoContext(ForceMaster(ctx), "incrby", s.key, s.block).Int64()
+		if err != nil {
+			return 0, err
+		}
+
+		s.next, s.end = end-s.block+1, end
+	}
+
+	id := s.next
+	s.next++
+
+	return id, nil
+}
+
+// NewSnowflake new a snowflake generator, worker id leases are keyed by prefix.
+// The worker id is leased on first Next, Close to release it.
+func NewSnowflake(r *Redis, prefix string, opts ...SnowflakeOptions) *Snowflake {
+	var options SnowflakeOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	if options.Epoch.IsZero() {
+		options.Epoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	if options.WorkerBits == 0 {
+		options.WorkerBits = 10
+	}
+	if options.SequenceBits == 0 {
+		options.SequenceBits = 12
+	}
+	if options.LeaseTTL <= 0 {
+		options.LeaseTTL = 30 * time.Second
+	}
+
+	return &Snowflake{
+		redis:   r,
+		prefix:  prefix,
+		options: options,
+		token:   randomID(),
+		worker:  -1,
+		done:    make(chan struct{}),
+	}
+}
+
+// Next id, it waits for the next millisecond when the sequence of the millisecond is exhausted or the clock moved backwards
+func (s *Snowflake) Next(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// the lease may be taken by another instance once expired without renewal
+	if s.worker >= 0 && !time.Now().Before(s.expires) {
+		s.redis.logf("snowflake %s: %v: worker id %d", s.prefix, ErrWorkerLeaseLost, s.worker)
+		s.worker = -1
+	}
+
+	if s.worker < 0 {
+		if err := s.lease(ctx); err != nil {
+			return 0, err
+		}
+	}
+
+	maxSequence := int64(1)<<s.options.SequenceBits - 1
+
+	for {
+		ms := unixMilli(time.Now()) - unixMilli(s.options.Epoch)
+
+		if ms > s.lastMs {
+			s.lastMs, s.sequence = ms, 0
+			break
+		}
+
+		if ms == s.lastMs && s.sequence < maxSequence {
+			s.sequence++
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(time.Duration(s.lastMs-ms+1) * time.Millisecond):
+		}
+	}
+
+	return s.lastMs<<(s.options.WorkerBits+s.options.SequenceBits) | s.worker<<s.options.SequenceBits | s.sequence, nil
+}
+
+// Worker leased worker id, -1 when not leased
+func (s *Snowflake) Worker() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.worker
+}
+
+// Close stop renewing and release the worker id
+func (s *Snowflake) Close(ctx context.Context) error {
+	s.closed.Do(func() {
+		close(s.done)
+	})
+
+	s.mu.Lock()
+	worker := s.worker
+	s.worker = -1
+	s.mu.Unlock()
+
+	if worker < 0 {
+		return nil
+	}
+
+	return unlockScript.run(ctx, s.redis, []string{s.workerKey(worker)}, s.token).Err()
+}
+
+// lease a free worker id starting from a random one, s.mu is held
+func (s *Snowflake) lease(ctx context.Context) error {
+	select {
+	case <-s.done:
+		return ErrWorkerLeaseLost
+	default:
+	}
+
+	workers := int64(1) << s.options.WorkerBits
+	start := rand.Int63n(workers)
+	ttl := int64(s.options.LeaseTTL / time.Millisecond)
+
+	for i := int64(0); i < workers; i++ {
+		worker := (start + i) % workers
+
+		leasedAt := time.Now()
+
+		err := s.redis.DoContext(ForceMaster(ctx), "set", s.workerKey(worker), s.token, "nx", "px", ttl).Err()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		s.worker, s.expires = worker, leasedAt.Add(s.options.LeaseTTL)
+		go s.renew(worker, ttl)
+
+		s.redis.logf("snowflake %s: worker id %d leased", s.prefix, worker)
+
+		return nil
+	}
+
+	return ErrNoWorkerID
+}
+
+// renew the lease of worker every ttl/3 until closed or the worker id is dropped, it is dropped when the lease is lost
+func (s *Snowflake) renew(worker, ttl int64) {
+	interval := s.options.LeaseTTL / 3
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+
+		s.mu.Lock()
+		current := s.worker
+		s.mu.Unlock()
+		if current != worker {
+			return
+		}
+
+		renewedAt := time.Now()
+
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		n, err := refreshLockScript.run(ctx, s.redis, []string{s.workerKey(worker)}, s.token, ttl).Int64()
+		cancel()
+
+		if err != nil {
+			s.redis.logf("snowflake %s: renew worker id %d: %v", s.prefix, worker, err)
+			continue
+		}
+
+		s.mu.Lock()
+		if s.worker == worker {
+			if n == 0 {
+				s.worker = -1
+			} else {
+				s.expires = renewedAt.Add(s.options.LeaseTTL)
+			}
+		}
+		s.mu.Unlock()
+
+		if n == 0 {
+			s.redis.logf("snowflake %s: %v: worker id %d", s.prefix, ErrWorkerLeaseLost, worker)
+			return
+		}
+	}
+}
+
+func (s *Snowflake) workerKey(worker int64) string {
+	return s.prefix + ":worker:" + strconv.FormatInt(worker, 10)
+}
@@ -0,0 +1,134 @@
+package redis
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"strings"
+	"sync/atomic"
+)
+
+type (
+	// IntegrityOptions integrity envelope of a critical namespace
+	IntegrityOptions struct {
+		// Secret of HMAC-SHA256, CRC32 is used when it is empty
+		Secret []byte
+		// FailOpen treat corrupted values as cache misses (and delete them), otherwise ErrCorrupted is returned
+		FailOpen bool
+	}
+
+	integrityPolicy struct {
+		prefix string
+		IntegrityOptions
+	}
+)
+
+const (
+	// integrityMagic prefix of sealed values, followed by algorithm byte and checksum
+	integrityMagic = "\xffI"
+
+	integrityCRC32   byte = 1
+	integrityHMAC256 byte = 3 // 2 was HMAC-SHA256 of data only, not bound to the key
+)
+
+var (
+	// ErrCorrupted value failed integrity verification
+	ErrCorrupted = errors.New("redis: value integrity verification failed")
+)
+
+// Protect seal values of keys starting with prefix with a checksum verified on read.
+// Call it before using the cache.
+func (c *Cache) Protect(prefix string, opts IntegrityOptions) {
+	c.integrity = append(c.integrity, integrityPolicy{prefix: prefix, IntegrityOptions: opts})
+}
+
+// Corruptions number of values failed integrity verification
+func (c *Cache) Corruptions() int64 {
+	return atomic.LoadInt64(&c.corruptions)
+}
+
+func (c *Cache) integrityPolicy(key string) *integrityPolicy {
+	for i := range c.integrity {
+		if strings.HasPrefix(key, c.integrity[i].prefix) {
+			return &c.integrity[i]
+		}
+	}
+
+	return nil
+}
+
+// seal data of key when it is protected
+func (c *Cache) seal(key string, data []byte) []byte {
+	policy := c.integrityPolicy(key)
+	if policy == nil {
+		return data
+	}
+
+	algorithm, sum := policy.checksum(key, data)
+
+	out := make([]byte, 0, len(integrityMagic)+1+len(sum)+len(data))
+	out = append(out, integrityMagic...)
+	out = append(out, algorithm)
+	out = append(out, sum...)
+
+	return append(out, data...)
+}
+
+// unseal verify and strip the envelope of key when it is protected
+func (c *Cache) unseal(key string, raw []byte) ([]byte, error) {
+	policy := c.integrityPolicy(key)
+	if policy == nil {
+		return raw, nil
+	}
+
+	algorithm, want := policy.checksum(key, nil)
+	header := len(integrityMagic) + 1 + len(want)
+
+	if len(raw) < header || string(raw[:len(integrityMagic)]) != integrityMagic || raw[len(integrityMagic)] != algorithm {
+		return nil, c.corrupted(policy)
+	}
+
+	data := raw[header:]
+	if _, sum := policy.checksum(key, data); !hmac.Equal(sum, raw[len(integrityMagic)+1:header]) {
+		return nil, c.corrupted(policy)
+	}
+
+	return data, nil
+}
+
+func (c *Cache) corrupted(policy *integrityPolicy) error {
+	atomic.AddInt64(&c.corruptions, 1)
+
+	if c.redis.integrityFailures != nil {
+		c.redis.integrityFailures.WithLabelValues(c.redis.Instance(), policy.prefix).Inc()
+	}
+
+	return ErrCorrupted
+}
+
+func (c *Cache) failOpen(key string) bool {
+	policy := c.integrityPolicy(key)
+
+	return policy != nil && policy.FailOpen
+}
+
+// checksum of data of key, the HMAC covers the length-prefixed key so that sealed values cannot be copied to other keys
+func (p *integrityPolicy) checksum(key string, data []byte) (byte, []byte) {
+	if len(p.Secret) == 0 {
+		sum := make([]byte, 4)
+		binary.BigEndian.PutUint32(sum, crc32.ChecksumIEEE(data))
+		return integrityCRC32, sum
+	}
+
+	length := make([]byte, 4)
+	binary.BigEndian.PutUint32(length, uint32(len(key)))
+
+	mac := hmac.New(sha256.New, p.Secret)
+	mac.Write(length)
+	mac.Write([]byte(key))
+	mac.Write(data)
+
+	return integrityHMAC256, mac.Sum(nil)
+}
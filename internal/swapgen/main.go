@@ -0,0 +1,96 @@
+// Command swapgen generate methods of swapClient delegating redis.UniversalClient to the current client
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"sort"
+)
+
+func main() {
+	out := flag.String("o", "swapclient.go", "output file")
+	flag.Parse()
+
+	fset := token.NewFileSet()
+	pkg, err := importer.ForCompiler(fset, "source", nil).Import("github.com/go-redis/redis/v7")
+	if err != nil {
+		panic(err)
+	}
+	iface := pkg.Scope().Lookup("UniversalClient").Type().Underlying().(*types.Interface)
+
+	qual := func(p *types.Package) string {
+		if p.Path() == pkg.Path() {
+			return "redis"
+		}
+		return p.Name()
+	}
+
+	var methods []*types.Func
+	for i := 0; i < iface.NumMethods(); i++ {
+		methods = append(methods, iface.Method(i))
+	}
+	sort.Slice(methods, func(i, j int) bool { return methods[i].Name() < methods[j].Name() })
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by internal/swapgen from redis.UniversalClient. DO NOT EDIT.\n\npackage redis\n\nimport (\n\t\"context\"\n\t\"time\"\n\n\t\"github.com/go-redis/redis/v7\"\n)\n\n")
+	for _, m := range methods {
+		if m.Name() == "AddHook" || m.Name() == "Close" {
+			continue
+		}
+		sig := m.Type().(*types.Signature)
+		params, args := "", ""
+		for i := 0; i < sig.Params().Len(); i++ {
+			p := sig.Params().At(i)
+			name := p.Name()
+			if name == "" || name == "_" {
+				name = fmt.Sprintf("a%d", i)
+			}
+			typ := types.TypeString(p.Type(), qual)
+			if sig.Variadic() && i == sig.Params().Len()-1 {
+				typ = "..." + types.TypeString(p.Type().(*types.Slice).Elem(), qual)
+				name += "..."
+				params += fmt.Sprintf("%s %s, ", name[:len(name)-3], typ)
+			} else {
+				params += fmt.Sprintf("%s %s, ", name, typ)
+			}
+			args += name + ", "
+		}
+		if params != "" {
+			params, args = params[:len(params)-2], args[:len(args)-2]
+		}
+		res := ""
+		switch sig.Results().Len() {
+		case 0:
+		case 1:
+			res = types.TypeString(sig.Results().At(0).Type(), qual)
+		default:
+			res = "("
+			for i := 0; i < sig.Results().Len(); i++ {
+				if i > 0 {
+					res += ", "
+				}
+				res += types.TypeString(sig.Results().At(i).Type(), qual)
+			}
+			res += ")"
+		}
+		ret := "return "
+		if sig.Results().Len() == 0 {
+			ret = ""
+		}
+		fmt.Fprintf(&b, "func (c *swapClient) %s(%s) %s {\n\t%sc.current().%s(%s)\n}\n\n", m.Name(), params, res, ret, m.Name(), args)
+	}
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		panic(err)
+	}
+
+	if err := ioutil.WriteFile(*out, src, 0644); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,30 @@
+package redis
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// SetWithJitter set key with ttl randomized by ±jitterFraction (0-1) of it,
+// so keys written together by mass warm-ups do not expire at the same moment.
+func (r *Redis) SetWithJitter(ctx context.Context, key string, val interface{}, ttl time.Duration, jitterFraction float64) error {
+	return r.DoContext(ctx, setArgs(key, val, jitterTTL(ttl, jitterFraction))...).Err()
+}
+
+// jitterTTL ttl randomized by ±fraction of it, at least 1ms. Zero ttl is persistent and kept as is.
+func jitterTTL(ttl time.Duration, fraction float64) time.Duration {
+	if ttl <= 0 || fraction <= 0 {
+		return ttl
+	}
+	if fraction > 1 {
+		fraction = 1
+	}
+
+	jittered := time.Duration(float64(ttl) * (1 + fraction*(2*rand.Float64()-1)))
+	if jittered < time.Millisecond {
+		return time.Millisecond
+	}
+
+	return jittered
+}
@@ -0,0 +1,198 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	// keyPatterns key naming conventions of an instance, keys of commands must match one of them
+	keyPatterns struct {
+		mu         sync.RWMutex
+		configured []*regexp.Regexp
+		registered []*regexp.Regexp
+	}
+
+	keyNameHook struct {
+		r *Redis
+	}
+)
+
+// Key pattern actions on keys not matching key patterns
+const (
+	KeyPatternLog    = "log"
+	KeyPatternReject = "reject"
+)
+
+// GuardRuleKeyName rule of commands rejected by key patterns
+const GuardRuleKeyName = "key naming convention"
+
+// unknownCaller caller label of violations when ctx carries no caller
+const unknownCaller = "unknown"
+
+// RegisterKeyPattern register key naming conventions in addition to the keyPatterns config, see compileKeyPattern
+func (r *Redis) RegisterKeyPattern(patterns ...string) error {
+	compiled, err := compileKeyPatterns(patterns)
+	if err != nil {
+		return err
+	}
+
+	r.keyPatterns.mu.Lock()
+	defer r.keyPatterns.mu.Unlock()
+
+	r.keyPatterns.registered = append(r.keyPatterns.registered, compiled...)
+
+	return nil
+}
+
+// declareKeyPatterns compile the keyPatterns config, replacing the ones of the previous load
+func (r *Redis) declareKeyPatterns() {
+	compiled, err := compileKeyPatterns(r.KeyPatterns)
+	if err != nil {
+		panic(err)
+	}
+
+	r.keyPatterns.mu.Lock()
+	defer r.keyPatterns.mu.Unlock()
+
+	r.keyPatterns.configured = compiled
+}
+
+func compileKeyPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := compileKeyPattern(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("key pattern %s: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+
+	return compiled, nil
+}
+
+// compileKeyPattern patterns starting with ^ are regexes, others are templates like service:{entity}:{id}
+// where {name} matches a segment without ':' and * matches anything
+func compileKeyPattern(pattern string) (*regexp.Regexp, error) {
+	if strings.HasPrefix(pattern, "^") {
+		return regexp.Compile(pattern)
+	}
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	for rest := pattern; rest != ""; {
+		switch {
+		case rest[0] == '{':
+			end := strings.IndexByte(rest, '}')
+			if end < 0 {
+				return nil, errors.New("unclosed {")
+			}
+			expr.WriteString("[^:]+")
+			rest = rest[end+1:]
+		case rest[0] == '*':
+			expr.WriteString(".*")
+			rest = rest[1:]
+		default:
+			end := strings.IndexAny(rest, "{*")
+			if end < 0 {
+				end = len(rest)
+			}
+			expr.WriteString(regexp.QuoteMeta(rest[:end]))
+			rest = rest[end:]
+		}
+	}
+	expr.WriteString("$")
+
+	return regexp.Compile(expr.String())
+}
+
+// match key matches a pattern
+func (kp *keyPatterns) match(key string) bool {
+	kp.mu.RLock()
+	defer kp.mu.RUnlock()
+
+	for _, patterns := range [][]*regexp.Regexp{kp.configured, kp.registered} {
+		for _, re := range patterns {
+			if re.MatchString(key) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (kp *keyPatterns) empty() bool {
+	kp.mu.RLock()
+	defer kp.mu.RUnlock()
+
+	return len(kp.configured) == 0 && len(kp.registered) == 0
+}
+
+// checkKeyNames count, log or reject keys of cmd not matching key patterns
+func (r *Redis) checkKeyNames(ctx context.Context, cmd redis.Cmder) error {
+	if r.keyPatterns.empty() {
+		return nil
+	}
+
+	for _, key := range auditKeys(cmd) {
+		if r.keyPatterns.match(key) {
+			continue
+		}
+
+		name := strings.ToLower(cmd.Name())
+		action := strings.ToLower(r.KeyPatternAction)
+		if action != KeyPatternReject {
+			action = KeyPatternLog
+		}
+
+		caller := CallerFrom(r.withDetectedCaller(ctx))
+		if caller == "" {
+			caller = unknownCaller
+		}
+
+		if r.keyNameViolations != nil {
+			r.keyNameViolations.WithLabelValues(r.Instance(), caller, action).Inc()
+		}
+
+		if action == KeyPatternReject {
+			return &GuardError{Command: name, Key: key, Rule: GuardRuleKeyName}
+		}
+
+		r.logf("key %s of %s violates key patterns, caller=%s", key, name, caller)
+	}
+
+	return nil
+}
+
+func (h keyNameHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	if isRaw(ctx) {
+		return ctx, nil
+	}
+
+	return ctx, h.r.checkKeyNames(ctx, cmd)
+}
+
+func (h keyNameHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	return nil
+}
+
+func (h keyNameHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	for _, cmd := range cmds {
+		if err := h.r.checkKeyNames(ctx, cmd); err != nil {
+			return ctx, err
+		}
+	}
+
+	return ctx, nil
+}
+
+func (h keyNameHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	return nil
+}
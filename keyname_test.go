@@ -0,0 +1,80 @@
+package redis
+
+import "testing"
+
+func TestCompileKeyPattern(t *testing.T) {
+	tests := []struct {
+		pattern, key string
+		want         bool
+	}{
+		{"user:{id}", "user:1", true},
+		{"user:{id}", "user:", false},
+		{"user:{id}", "user:1:profile", false},
+		{"user:{id}", "users:1", false},
+		{"user:{id}", "prefix:user:1", false},
+		{"svc:{entity}:{id}", "svc:order:42", true},
+		{"svc:{entity}:{id}", "svc:order", false},
+		{"svc:{entity}:{id}", "svc::42", false},
+		{"cache:*", "cache:", true},
+		{"cache:*", "cache:a:b:c", true},
+		{"cache:*", "cach:a", false},
+		{"{tenant}:session:*", "acme:session:x:y", true},
+		{"{tenant}:session:*", "acme:sessions:x", false},
+		// literal parts are not regexes
+		{"a.b:{id}", "a.b:1", true},
+		{"a.b:{id}", "axb:1", false},
+		{"job+{id}", "job+1", true},
+		// patterns starting with ^ are regexes
+		{"^user:[0-9]+$", "user:42", true},
+		{"^user:[0-9]+$", "user:x", false},
+		{"^user:", "user:anything", true},
+	}
+
+	for _, tt := range tests {
+		re, err := compileKeyPattern(tt.pattern)
+		if err != nil {
+			t.Errorf("compileKeyPattern(%q): %v", tt.pattern, err)
+			continue
+		}
+
+		if got := re.MatchString(tt.key); got != tt.want {
+			t.Errorf("pattern %q match %q = %v, want %v", tt.pattern, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestCompileKeyPatternInvalid(t *testing.T) {
+	for _, pattern := range []string{"user:{id", "^user:(", "{a}:{b"} {
+		if _, err := compileKeyPattern(pattern); err == nil {
+			t.Errorf("compileKeyPattern(%q) expected an error", pattern)
+		}
+	}
+}
+
+func TestKeyPatternsMatch(t *testing.T) {
+	var kp keyPatterns
+	if !kp.empty() {
+		t.Fatal("expected empty key patterns")
+	}
+
+	configured, err := compileKeyPatterns([]string{"user:{id}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	registered, err := compileKeyPatterns([]string{"cache:*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kp.configured, kp.registered = configured, registered
+
+	for key, want := range map[string]bool{
+		"user:1":     true,
+		"cache:a:b":  true,
+		"order:1":    false,
+		"user:1:bad": false,
+	} {
+		if got := kp.match(key); got != want {
+			t.Errorf("match(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package redis
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	otherPrefix = "other"
+)
+
+// watchKeyspace count evicted and expired keyspace events by declared prefixes until shutdown.
+// Cluster nodes are subscribed one by one, since keyspace events are not propagated across the cluster.
+func (r *Redis) watchKeyspace(done <-chan struct{}) {
+	events := mustRegister(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: r.metrics.Namespace,
+			Subsystem: r.metrics.Subsystem,
+			Name:      "redis_keyspace_events_total",
+			Help:      "redis evicted and expired keys by prefix total, estimated from sampled events",
+		},
+		[]string{"redis_instance", "event", "prefix"},
+	)).(*prometheus.CounterVec)
+
+	prefixes := append([]string(nil), r.KeyspacePrefixes...)
+	sort.Slice(prefixes, func(i, j int) bool {
+		return len(prefixes[i]) > len(prefixes[j])
+	})
+
+	sample := r.KeyspaceSample
+	if sample <= 0 || sample > 1 {
+		sample = 1
+	}
+
+	channels := []string{
+		fmt.Sprintf("__keyevent@%d__:evicted", r.DB),
+		fmt.Sprintf("__keyevent@%d__:expired", r.DB),
+	}
+
+	var mu sync.Mutex
+	pubsubs := make([]*redis.PubSub, 0)
+	err := r.forEachMaster(func(node redis.UniversalClient, addr string) error {
+		if r.KeyspaceConfigure {
+			if err := node.ConfigSet("notify-keyspace-events", "Exe").Err(); err != nil {
+				r.logf("keyspace events of %s: %v", addr, err)
+			}
+		}
+
+		ps := node.Subscribe(channels...)
+		mu.Lock()
+		pubsubs = append(pubsubs, ps)
+		mu.Unlock()
+
+		go func() {
+			for msg := range ps.Channel() {
+				if sample < 1 && rand.Float64() >= sample {
+					continue
+				}
+
+				event := msg.Channel[strings.LastIndex(msg.Channel, ":")+1:]
+				events.WithLabelValues(r.Instance(), event, matchPrefix(prefixes, msg.Payload)).Add(1 / sample)
+			}
+		}()
+
+		return nil
+	})
+	if err != nil {
+		r.logf("keyspace events: %v", err)
+	}
+
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, ps := range pubsubs {
+		ps.Close()
+	}
+}
+
+// matchPrefix longest declared prefix of key, prefixes are sorted by length desc
+func matchPrefix(prefixes []string, key string) string {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return prefix
+		}
+	}
+
+	return otherPrefix
+}
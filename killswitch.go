@@ -0,0 +1,156 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Pause set killSwitchKey, background subsystems of all instances (schedulers, sweepers, relays, collectors) pause
+// within killSwitchCheck until Resume or ttl elapsed, ttl 0 is until Resume. It is a last-resort incident control,
+// commands sent by the application are not affected.
+func (r *Redis) Pause(ctx context.Context, reason string, ttl time.Duration) error {
+	if r.KillSwitchKey == "" {
+		return fmt.Errorf("redis: killSwitchKey is not configured")
+	}
+
+	if reason == "" {
+		reason = "paused"
+	}
+
+	if err := r.DoContext(ctx, setArgs(r.KillSwitchKey, reason, ttl)...).Err(); err != nil {
+		return err
+	}
+
+	r.setPaused(reason)
+
+	return nil
+}
+
+// Resume delete killSwitchKey, background subsystems of all instances resume within killSwitchCheck.
+func (r *Redis) Resume(ctx context.Context) error {
+	if r.KillSwitchKey == "" {
+		return fmt.Errorf("redis: killSwitchKey is not configured")
+	}
+
+	if err := r.DoContext(ctx, "del", r.KillSwitchKey).Err(); err != nil {
+		return err
+	}
+
+	r.setPaused("")
+
+	return nil
+}
+
+// Paused background subsystems are paused by the kill switch
+func (r *Redis) Paused() bool {
+	return atomic.LoadInt32(&r.paused) == 1
+}
+
+// PauseReason reason of the kill switch, empty when not paused
+func (r *Redis) PauseReason() string {
+	reason, _ := r.pauseReason.Load().(string)
+
+	return reason
+}
+
+// waitResumed block while paused, subsystems holding leases keep renewing them meanwhile
+func (r *Redis) waitResumed(ctx context.Context) error {
+	for r.Paused() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+
+	return nil
+}
+
+// waitConsumersResumed block while paused or consumers are paused by the consumerPause toggle
+func (r *Redis) waitConsumersResumed(ctx context.Context) error {
+	for r.Paused() || atomic.LoadInt32(&r.consumersPaused) == 1 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+
+	return nil
+}
+
+func (r *Redis) setPaused(reason string) {
+	paused := int32(0)
+	if reason != "" {
+		paused = 1
+	}
+
+	if atomic.SwapInt32(&r.paused, paused) != paused {
+		if paused == 1 {
+			r.logf("background subsystems paused by kill switch %s: %s", r.KillSwitchKey, reason)
+		} else {
+			r.logf("background subsystems resumed, kill switch %s cleared", r.KillSwitchKey)
+		}
+	}
+
+	r.pauseReason.Store(reason)
+}
+
+// watchKillSwitch load killSwitchKey periodically until shutdown, the state is kept when redis is unreachable
+func (r *Redis) watchKillSwitch(done <-chan struct{}) {
+	var gauge *prometheus.GaugeVec
+	if r.Metrics {
+		gauge = mustRegister(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: r.metrics.Namespace,
+				Subsystem: r.metrics.Subsystem,
+				Name:      "redis_kill_switch",
+				Help:      "redis background subsystems paused by the kill switch, 1 is paused",
+			},
+			[]string{"redis_instance"},
+		)).(*prometheus.GaugeVec)
+	}
+
+	interval := r.KillSwitchCheck
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		reason, err := r.DoContext(ForceMaster(ctx), "get", r.KillSwitchKey).Text()
+		cancel()
+
+		switch err {
+		case nil:
+			if reason == "" {
+				reason = "paused"
+			}
+			r.setPaused(reason)
+		case redis.Nil:
+			r.setPaused("")
+		}
+
+		if gauge != nil {
+			value := 0.0
+			if r.Paused() {
+				value = 1
+			}
+			gauge.WithLabelValues(r.Instance()).Set(value)
+		}
+
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	// Leaderboard ranking on sorted sets, highest score ranks first.
+	Leaderboard struct {
+		redis  *Redis
+		prefix string
+		opts   LeaderboardOptions
+		at     time.Time
+	}
+
+	// LeaderboardOptions options of Leaderboard
+	LeaderboardOptions struct {
+		// Period per-period boards (e.g. Daily), zero is a single all-time board
+		Period Period
+		// Retention period boards expire after retention periods, default is 1
+		Retention int
+		// HalfLife score contributions decay by half every HalfLife, zero is no decay.
+		// Decayed scores are stored inflated relative to Epoch, so all-time boards need a recent Epoch.
+		HalfLife time.Duration
+		// Epoch reference time of decay, default is the period start, required by decayed all-time boards
+		Epoch time.Time
+	}
+
+	// LeaderboardEntry member of board with 1-based rank
+	LeaderboardEntry struct {
+		Member string
+		Score  float64
+		Rank   int64
+	}
+)
+
+var (
+	// KEYS[1]: board. ARGV[1]: increment, ARGV[2]: member, ARGV[3]: ttl ms, zero is no ttl
+	leaderboardIncrScript = newScript(`
+local score = redis.call('zincrby', KEYS[1], ARGV[1], ARGV[2])
+if tonumber(ARGV[3]) > 0 then
+	redis.call('pexpire', KEYS[1], ARGV[3])
+end
+return score
+`)
+)
+
+// NewLeaderboard new a leaderboard helper, keys are prefixed by prefix.
+func NewLeaderboard(r *Redis, prefix string, opts LeaderboardOptions) *Leaderboard {
+	if opts.Retention <= 0 {
+		opts.Retention = 1
+	}
+
+	if opts.HalfLife > 0 && opts.Period <= 0 && opts.Epoch.IsZero() {
+		panic("redis: leaderboard decay of all-time boards requires epoch")
+	}
+
+	return &Leaderboard{
+		redis:  r,
+		prefix: prefix,
+		opts:   opts,
+	}
+}
+
+// At view of boards of the period containing t, the current period is used by default
+func (lb *Leaderboard) At(t time.Time) *Leaderboard {
+	view := *lb
+	view.at = t
+
+	return &view
+}
+
+// IncrScore add delta to score of member, returns new score
+func (lb *Leaderboard) IncrScore(ctx context.Context, board, member string, delta float64) (float64, error) {
+	now := lb.now()
+
+	var ttl int64
+	if lb.opts.Period > 0 {
+		end := lb.periodStart(now).Add(time.Duration(lb.opts.Period) * time.Duration(lb.opts.Retention))
+		ttl = end.Sub(time.Now()).Milliseconds()
+		if ttl <= 0 {
+			return 0, fmt.Errorf("redis: leaderboard period of %s expired", now)
+		}
+	}
+
+	factor := lb.decayFactor(now)
+	val, err := leaderboardIncrScript.run(ctx, lb.redis, []string{lb.key(board)}, delta*factor, member, ttl).Text()
+	if err != nil {
+		return 0, err
+	}
+
+	score, err := strconv.ParseFloat(val, 64)
+
+	return score / factor, err
+}
+
+// Score of member, found is false when member is not on board
+func (lb *Leaderboard) Score(ctx context.Context, board, member string) (score float64, found bool, err error) {
+	score, err = lb.redis.DoContext(ctx, "zscore", lb.key(board), member).Float64()
+	if err == redis.Nil {
+		return 0, false, nil
+	}
+
+	return score / lb.decayFactor(lb.now()), err == nil, err
+}
+
+// Rank entry of member, found is false when member is not on board
+func (lb *Leaderboard) Rank(ctx context.Context, board, member string) (entry LeaderboardEntry, found bool, err error) {
+	rank, err := lb.redis.DoContext(ctx, "zrevrank", lb.key(board), member).Int64()
+	if err == redis.Nil {
+		return entry, false, nil
+	}
+	if err != nil {
+		return entry, false, err
+	}
+
+	score, found, err := lb.Score(ctx, board, member)
+	if err != nil || !found {
+		return entry, found, err
+	}
+
+	return LeaderboardEntry{Member: member, Score: score, Rank: rank + 1}, true, nil
+}
+
+// Top n entries
+func (lb *Leaderboard) Top(ctx context.Context, board string, n int64) ([]LeaderboardEntry, error) {
+	return lb.Range(ctx, board, 0, n-1)
+}
+
+// Page entries of 1-based page of size
+func (lb *Leaderboard) Page(ctx context.Context, board string, page, size int64) ([]LeaderboardEntry, error) {
+	if page < 1 || size < 1 {
+		return nil, nil
+	}
+
+	start := (page - 1) * size
+
+	return lb.Range(ctx, board, start, start+size-1)
+}
+
+// Around entries of n members ranked above member, member and n members below, empty when member is not on board
+func (lb *Leaderboard) Around(ctx context.Context, board, member string, n int64) ([]LeaderboardEntry, error) {
+	rank, err := lb.redis.DoContext(ctx, "zrevrank", lb.key(board), member).Int64()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	start := rank - n
+	if start < 0 {
+		start = 0
+	}
+
+	return lb.Range(ctx, board, start, rank+n)
+}
+
+// Range entries of 0-based rank from start to stop, both included
+func (lb *Leaderboard) Range(ctx context.Context, board string, start, stop int64) ([]LeaderboardEntry, error) {
+	if stop < start {
+		return nil, nil
+	}
+
+	cmd := redis.NewZSliceCmd("zrevrange", lb.key(board), start, stop, "withscores")
+	if err := lb.redis.ProcessContext(ctx, cmd); err != nil {
+		return nil, err
+	}
+
+	factor := lb.decayFactor(lb.now())
+	zs := cmd.Val()
+	entries := make([]LeaderboardEntry, 0, len(zs))
+	for i, z := range zs {
+		entries = append(entries, LeaderboardEntry{
+			Member: fmt.Sprint(z.Member),
+			Score:  z.Score / factor,
+			Rank:   start + int64(i) + 1,
+		})
+	}
+
+	return entries, nil
+}
+
+// Remove members from board
+func (lb *Leaderboard) Remove(ctx context.Context, board string, members ...string) error {
+	if len(members) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, 0, len(members)+2)
+	args = append(args, "zrem", lb.key(board))
+	for _, member := range members {
+		args = append(args, member)
+	}
+
+	return lb.redis.DoContext(ctx, args...).Err()
+}
+
+// Count members on board
+func (lb *Leaderboard) Count(ctx context.Context, board string) (int64, error) {
+	return lb.redis.DoContext(ctx, "zcard", lb.key(board)).Int64()
+}
+
+func (lb *Leaderboard) now() time.Time {
+	if lb.at.IsZero() {
+		return time.Now()
+	}
+
+	return lb.at
+}
+
+func (lb *Leaderboard) periodStart(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Duration(lb.opts.Period))
+}
+
+// decayFactor stored scores are real scores inflated by 2^((t-epoch)/halfLife)
+func (lb *Leaderboard) decayFactor(t time.Time) float64 {
+	if lb.opts.HalfLife <= 0 {
+		return 1
+	}
+
+	epoch := lb.opts.Epoch
+	if epoch.IsZero() && lb.opts.Period > 0 {
+		epoch = lb.periodStart(t)
+	}
+
+	return math.Exp2(float64(t.Sub(epoch)) / float64(lb.opts.HalfLife))
+}
+
+func (lb *Leaderboard) key(board string) string {
+	if lb.opts.Period <= 0 {
+		return lb.prefix + ":" + board
+	}
+
+	return fmt.Sprintf("%s:%s:%d", lb.prefix, board, lb.periodStart(lb.now()).Unix())
+}
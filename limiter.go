@@ -0,0 +1,136 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type (
+	// limiterHook bound in-flight commands of the instance, added only when maxInFlight is configured.
+	// It is added after hooks rejecting commands, so a slot acquired is always released by AfterProcess.
+	limiterHook struct {
+		r *Redis
+	}
+
+	// limiter semaphore of in-flight commands, pipelines take one slot.
+	// The semaphore is replaced when the maxInFlight toggle changes, slots are released to the one they are acquired from.
+	limiter struct {
+		slots    atomic.Value
+		inflight *prometheus.GaugeVec
+		shed     *prometheus.CounterVec
+	}
+
+	limiterAcquiredKey struct{}
+)
+
+var (
+	// ErrShed command shed since maxInFlight commands are in flight longer than inFlightTimeout
+	ErrShed = errors.New("redis: command shed, too many in-flight commands")
+)
+
+func (r *Redis) newLimiter() *limiter {
+	l := &limiter{}
+	l.resize(r.MaxInFlight)
+
+	if r.Metrics {
+		l.inflight = mustRegister(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: r.metrics.Namespace,
+				Subsystem: r.metrics.Subsystem,
+				Name:      "redis_inflight_commands",
+				Help:      "redis commands and pipelines in flight",
+			},
+			[]string{"redis_instance"},
+		)).(*prometheus.GaugeVec)
+		l.shed = mustRegister(prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: r.metrics.Namespace,
+				Subsystem: r.metrics.Subsystem,
+				Name:      "redis_shed_total",
+				Help:      "redis commands shed by the in-flight limit total",
+			},
+			[]string{"redis_instance", "cmd"},
+		)).(*prometheus.CounterVec)
+	}
+
+	return l
+}
+
+// resize replace the semaphore by one of max slots
+func (l *limiter) resize(max int) {
+	l.slots.Store(make(chan struct{}, max))
+}
+
+// acquire a slot, waiting at most inFlightTimeout (default is 100ms) or until ctx is done
+func (h limiterHook) acquire(ctx context.Context, name string) (context.Context, error) {
+	l := h.r.limiter
+	slots := l.slots.Load().(chan struct{})
+
+	select {
+	case slots <- struct{}{}:
+	default:
+		timeout := h.r.InFlightTimeout
+		if timeout <= 0 {
+			timeout = 100 * time.Millisecond
+		}
+
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return ctx, ctx.Err()
+		case <-timer.C:
+			if l.shed != nil {
+				l.shed.WithLabelValues(h.r.Instance(), name).Inc()
+			}
+			return ctx, ErrShed
+		}
+	}
+
+	if l.inflight != nil {
+		l.inflight.WithLabelValues(h.r.Instance()).Inc()
+	}
+
+	return context.WithValue(ctx, limiterAcquiredKey{}, slots), nil
+}
+
+func (h limiterHook) release(ctx context.Context) {
+	slots, ok := ctx.Value(limiterAcquiredKey{}).(chan struct{})
+	if !ok {
+		return
+	}
+
+	l := h.r.limiter
+	<-slots
+
+	if l.inflight != nil {
+		l.inflight.WithLabelValues(h.r.Instance()).Dec()
+	}
+}
+
+func (h limiterHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return h.acquire(ctx, cmd.Name())
+}
+
+func (h limiterHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	h.release(ctx)
+
+	return nil
+}
+
+func (h limiterHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return h.acquire(ctx, "pipeline")
+}
+
+func (h limiterHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	h.release(ctx)
+
+	return nil
+}
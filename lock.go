@@ -0,0 +1,492 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type (
+	// Locker distributed locks, a lock is a key holding the owner's token with ttl.
+	Locker struct {
+		redis  *Redis
+		prefix string
+		ttl    time.Duration
+		retry  time.Duration
+
+		mu    sync.Mutex
+		stats map[string]*LockStats
+
+		wait        *prometheus.SummaryVec
+		hold        *prometheus.SummaryVec
+		contentions *prometheus.CounterVec
+		renewals    *prometheus.CounterVec
+		expirations *prometheus.CounterVec
+	}
+
+	// Lock held lock
+	Lock struct {
+		locker     *Locker
+		name       string
+		value      string
+		acquiredAt time.Time
+
+		mu   sync.Mutex
+		stop chan struct{}
+		lost sync.Once
+	}
+
+	// LockHolder identity of lock owner stored in the lock value
+	LockHolder struct {
+		Token      string        `json:"token"`
+		Instance   string        `json:"instance"`
+		Hostname   string        `json:"hostname"`
+		PID        int           `json:"pid"`
+		AcquiredAt time.Time     `json:"acquiredAt"`
+		TTL        time.Duration `json:"ttl"`
+	}
+
+	// LockStats counters of a lock name in this process
+	LockStats struct {
+		Acquired        int64
+		Contentions     int64
+		RenewalFailures int64
+		Expired         int64
+		WaitTotal       time.Duration
+		HoldTotal       time.Duration
+	}
+)
+
+var (
+	// ErrNotObtained lock is held by another owner
+	ErrNotObtained = errors.New("redis: lock not obtained")
+	// ErrLockNotHeld lock expired or is held by another owner
+	ErrLockNotHeld = errors.New("redis: lock not held")
+
+	// KEYS[1]: lock. ARGV[1]: value
+	unlockScript = newScript(`
+if redis.call('get', KEYS[1]) == ARGV[1] then
+	return redis.call('del', KEYS[1])
+end
+return 0
+`)
+
+	// KEYS[1]: lock. ARGV[1]: value, ARGV[2]: ttl ms
+	refreshLockScript = newScript(`
+if redis.call('get', KEYS[1]) == ARGV[1] then
+	return redis.call('pexpire', KEYS[1], ARGV[2])
+end
+return 0
+`)
+)
+
+var (
+	hostname, _ = os.Hostname()
+)
+
+const (
+	defaultLockTTL   = 10 * time.Second
+	defaultLockRetry = 50 * time.Millisecond
+)
+
+// NewLocker new a locker, keys are prefixed by prefix, ttl is 10s if it is not positive.
+// Lock metrics are labeled by lock name, keep names low cardinality.
+func NewLocker(r *Redis, prefix string, ttl time.Duration) *Locker {
+	if ttl <= 0 {
+		ttl = defaultLockTTL
+	}
+
+	l := &Locker{
+		redis:  r,
+		prefix: prefix,
+		ttl:    ttl,
+		retry:  defaultLockRetry,
+		stats:  make(map[string]*LockStats),
+	}
+
+	if r.Metrics {
+		labels := []string{"redis_instance", "lock"}
+		l.wait = mustRegister(prometheus.NewSummaryVec(prometheus.SummaryOpts{
+			Namespace: r.metrics.Namespace,
+			Subsystem: r.metrics.Subsystem,
+			Name:      "redis_lock_wait_seconds",
+			Help:      "redis lock acquisition wait summary",
+		}, labels)).(*prometheus.SummaryVec)
+		l.hold = mustRegister(prometheus.NewSummaryVec(prometheus.SummaryOpts{
+			Namespace: r.metrics.Namespace,
+			Subsystem: r.metrics.Subsystem,
+			Name:      "redis_lock_hold_seconds",
+			Help:      "redis lock hold duration summary",
+		}, labels)).(*prometheus.SummaryVec)
+		l.contentions = mustRegister(prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: r.metrics.Namespace,
+			Subsystem: r.metrics.Subsystem,
+			Name:      "redis_lock_contentions_total",
+			Help:      "redis lock attempts failed because the lock is held total",
+		}, labels)).(*prometheus.CounterVec)
+		l.renewals = mustRegister(prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: r.metrics.Namespace,
+			Subsystem: r.metrics.Subsystem,
+			Name:      "redis_lock_renewal_failures_total",
+			Help:      "redis lock renewal failures total",
+		}, labels)).(*prometheus.CounterVec)
+		l.expirations = mustRegister(prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: r.metrics.Namespace,
+			Subsystem: r.metrics.Subsystem,
+			Name:      "redis_lock_expired_total",
+			Help:      "redis locks expired while held total",
+		}, labels)).(*prometheus.CounterVec)
+	}
+
+	r.snapshot.addLocker(l)
+
+	return l
+}
+
+// TryLock obtain lock name once, ErrNotObtained is returned when it is held
+func (l *Locker) TryLock(ctx context.Context, name string) (*Lock, error) {
+	lk, err := l.obtain(ctx, name)
+	if err == ErrNotObtained {
+		l.contended(name)
+	}
+
+	return lk, err
+}
+
+// Lock obtain lock name, retry until it is obtained or ctx is done
+func (l *Locker) Lock(ctx context.Context, name string) (*Lock, error) {
+	begin := time.Now()
+	contended := false
+
+	for {
+		lk, err := l.obtain(ctx, name)
+		if err == nil {
+			l.observeWait(name, time.Since(begin))
+			return lk, nil
+		}
+		if err != ErrNotObtained {
+			return nil, err
+		}
+
+		if !contended {
+			contended = true
+			l.contended(name)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(l.retry):
+		}
+	}
+}
+
+// InspectLock current holder of lock name, found is false when it is not held.
+// Holder of a lock value not written by Locker has only TTL.
+func (l *Locker) InspectLock(ctx context.Context, name string) (holder LockHolder, found bool, err error) {
+	holder, value, err := l.inspect(ctx, name)
+
+	return holder, value != "", err
+}
+
+// ForceUnlock delete lock name regardless of its holder, for resolving stuck locks.
+// The holder and reason are logged for audit. The holder loses the lock silently, use it only when the holder is gone.
+// ctx should carry a token of OpForceUnlock when opsKey is configured.
+func (l *Locker) ForceUnlock(ctx context.Context, name, reason string) (err error) {
+	done, err := l.redis.authorize(ctx, OpForceUnlock, l.key(name))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		done(err)
+	}()
+
+	holder, value, err := l.inspect(ctx, name)
+	if err != nil {
+		return err
+	}
+	if value == "" {
+		return ErrLockNotHeld
+	}
+
+	// value is compared so that a lock obtained by another holder meanwhile is kept
+	n, err := unlockScript.run(ctx, l.redis, []string{l.key(name)}, value).Int64()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrLockNotHeld
+	}
+
+	l.redis.logf("audit: force unlock %s held by instance=%s hostname=%s pid=%d since %s, reason: %s",
+		name, holder.Instance, holder.Hostname, holder.PID, holder.AcquiredAt.Format(time.RFC3339), reason)
+
+	return nil
+}
+
+// inspect holder and raw value of lock name, value is empty when it is not held.
+// It is read from the master, ForceUnlock compares the value with the lock.
+func (l *Locker) inspect(ctx context.Context, name string) (holder LockHolder, value string, err error) {
+	ctx = ForceMaster(ctx)
+
+	value, err = l.redis.DoContext(ctx, "get", l.key(name)).Text()
+	if err == redis.Nil {
+		return holder, "", nil
+	}
+	if err != nil {
+		return holder, "", err
+	}
+
+	json.Unmarshal([]byte(value), &holder)
+
+	ttl, err := l.redis.DoContext(ctx, "pttl", l.key(name)).Int64()
+	if err != nil {
+		return holder, value, err
+	}
+	if ttl > 0 {
+		holder.TTL = time.Duration(ttl) * time.Millisecond
+	}
+
+	return holder, value, nil
+}
+
+// AdminHandler lock debugging endpoints, mount it under a path prefix with http.StripPrefix.
+// Requests are authorized like those of the instance's AdminHandler.
+//
+//	GET  /locks?name=x           current holder
+//	POST /locks?name=x&reason=y  force unlock, the operation token is read from OperationTokenHeader or token
+func (l *Locker) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/locks", func(w http.ResponseWriter, req *http.Request) {
+		name := req.FormValue("name")
+		if name == "" {
+			http.Error(w, "name is required", http.StatusBadRequest)
+			return
+		}
+
+		switch req.Method {
+		case http.MethodGet:
+			holder, found, err := l.InspectLock(req.Context(), name)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if !found {
+				http.Error(w, ErrLockNotHeld.Error(), http.StatusNotFound)
+				return
+			}
+
+			writeJSON(w, holder)
+		case http.MethodPost, http.MethodDelete:
+			reason := req.FormValue("reason")
+			if reason == "" {
+				http.Error(w, "reason is required", http.StatusBadRequest)
+				return
+			}
+
+			err := l.ForceUnlock(operationContext(req), name, reason)
+			if err == ErrOperationToken {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+			if err == ErrLockNotHeld {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	return l.redis.instanceHandler(l.redis.adminAuth(mux, false))
+}
+
+// Stats per lock name counters of this process
+func (l *Locker) Stats() map[string]LockStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stats := make(map[string]LockStats, len(l.stats))
+	for name, s := range l.stats {
+		stats[name] = *s
+	}
+
+	return stats
+}
+
+func (l *Locker) obtain(ctx context.Context, name string) (*Lock, error) {
+	now := time.Now()
+	value, err := l.holderValue(randomID(), now)
+	if err != nil {
+		return nil, err
+	}
+
+	err = l.redis.DoContext(ctx, append(setArgs(l.key(name), value, l.ttl), "nx")...).Err()
+	if err == redis.Nil {
+		return nil, ErrNotObtained
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	l.record(name, func(s *LockStats) { s.Acquired++ })
+
+	return &Lock{
+		locker:     l,
+		name:       name,
+		value:      value,
+		acquiredAt: now,
+	}, nil
+}
+
+// holderValue lock value identifying this process as holder
+func (l *Locker) holderValue(token string, acquiredAt time.Time) (string, error) {
+	value, err := json.Marshal(LockHolder{
+		Token:      token,
+		Instance:   l.redis.Instance(),
+		Hostname:   hostname,
+		PID:        os.Getpid(),
+		AcquiredAt: acquiredAt,
+	})
+
+	return string(value), err
+}
+
+func (l *Locker) contended(name string) {
+	l.record(name, func(s *LockStats) { s.Contentions++ })
+	if l.contentions != nil {
+		l.contentions.WithLabelValues(l.redis.Instance(), name).Inc()
+	}
+}
+
+func (l *Locker) observeWait(name string, wait time.Duration) {
+	l.record(name, func(s *LockStats) { s.WaitTotal += wait })
+	if l.wait != nil {
+		l.wait.WithLabelValues(l.redis.Instance(), name).Observe(wait.Seconds())
+	}
+}
+
+// expired count lock expired while held, once per lock
+func (lk *Lock) expired() {
+	lk.lost.Do(func() {
+		l := lk.locker
+
+		l.record(lk.name, func(s *LockStats) { s.Expired++ })
+		if l.expirations != nil {
+			l.expirations.WithLabelValues(l.redis.Instance(), lk.name).Inc()
+		}
+	})
+}
+
+func (l *Locker) record(name string, fn func(s *LockStats)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	s, ok := l.stats[name]
+	if !ok {
+		s = &LockStats{}
+		l.stats[name] = s
+	}
+	fn(s)
+}
+
+func (l *Locker) key(name string) string {
+	return l.prefix + ":" + name
+}
+
+// Name of lock
+func (lk *Lock) Name() string {
+	return lk.name
+}
+
+// Refresh extend ttl of lock, ErrLockNotHeld is returned when it expired
+func (lk *Lock) Refresh(ctx context.Context) error {
+	l := lk.locker
+
+	n, err := refreshLockScript.run(ctx, l.redis, []string{l.key(lk.name)}, lk.value, l.ttl.Milliseconds()).Int64()
+	if err == nil && n == 0 {
+		err = ErrLockNotHeld
+		lk.expired()
+	}
+
+	if err != nil {
+		l.record(lk.name, func(s *LockStats) { s.RenewalFailures++ })
+		if l.renewals != nil {
+			l.renewals.WithLabelValues(l.redis.Instance(), lk.name).Inc()
+		}
+	}
+
+	return err
+}
+
+// KeepAlive refresh lock every ttl/3 until it is unlocked or refresh failed with ErrLockNotHeld
+func (lk *Lock) KeepAlive() {
+	lk.mu.Lock()
+	defer lk.mu.Unlock()
+
+	if lk.stop != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	lk.stop = stop
+
+	go func() {
+		ticker := time.NewTicker(lk.locker.ttl / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := lk.Refresh(context.Background()); err == ErrLockNotHeld {
+					return
+				}
+			}
+		}
+	}()
+}
+
+// Unlock release lock, ErrLockNotHeld is returned when it expired before
+func (lk *Lock) Unlock(ctx context.Context) error {
+	l := lk.locker
+
+	lk.mu.Lock()
+	if lk.stop != nil {
+		close(lk.stop)
+		lk.stop = nil
+	}
+	lk.mu.Unlock()
+
+	n, err := unlockScript.run(ctx, l.redis, []string{l.key(lk.name)}, lk.value).Int64()
+	if err != nil {
+		return err
+	}
+
+	hold := time.Since(lk.acquiredAt)
+	l.record(lk.name, func(s *LockStats) { s.HoldTotal += hold })
+	if l.hold != nil {
+		l.hold.WithLabelValues(l.redis.Instance(), lk.name).Observe(hold.Seconds())
+	}
+
+	if n == 0 {
+		lk.expired()
+		return ErrLockNotHeld
+	}
+
+	return nil
+}
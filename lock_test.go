@@ -0,0 +1,159 @@
+package redis_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/boxgo/redis"
+	"github.com/boxgo/redis/redistest"
+)
+
+func newLockServer(t *testing.T) (*redis.Redis, *redistest.Server) {
+	r, srv := redistest.New(t)
+
+	srv.Script(redis.UnlockScript, func(call func(args ...string) (interface{}, error), keys, args []string) (interface{}, error) {
+		if v, err := call("get", keys[0]); err != nil || v != args[0] {
+			return int64(0), err
+		}
+		return call("del", keys[0])
+	})
+	srv.Script(redis.RefreshLockScript, func(call func(args ...string) (interface{}, error), keys, args []string) (interface{}, error) {
+		if v, err := call("get", keys[0]); err != nil || v != args[0] {
+			return int64(0), err
+		}
+		return call("pexpire", keys[0], args[1])
+	})
+
+	return r, srv
+}
+
+func TestLockExclusive(t *testing.T) {
+	r, srv := newLockServer(t)
+	ctx := context.Background()
+	l := redis.NewLocker(r, "locks", time.Minute)
+
+	lk, err := l.TryLock(ctx, "job")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.AssertCalled(t, "set", "locks:job")
+
+	if _, err := l.TryLock(ctx, "job"); err != redis.ErrNotObtained {
+		t.Fatalf("second TryLock expected ErrNotObtained, got %v", err)
+	}
+	if _, err := l.TryLock(ctx, "other"); err != nil {
+		t.Fatalf("TryLock of another name: %v", err)
+	}
+
+	if err := lk.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.TryLock(ctx, "job"); err != nil {
+		t.Fatalf("TryLock after Unlock: %v", err)
+	}
+
+	stats := l.Stats()["job"]
+	if stats.Acquired != 2 || stats.Contentions != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestLockWait(t *testing.T) {
+	r, _ := newLockServer(t)
+	l := redis.NewLocker(r, "locks", time.Minute)
+
+	lk, err := l.TryLock(context.Background(), "job")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	// the deadline fails the wait or the command in flight
+	if _, err := l.Lock(ctx, "job"); err == nil {
+		t.Fatal("Lock of a held lock expected to fail at the deadline")
+	}
+
+	released := make(chan struct{})
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		lk.Unlock(context.Background())
+		close(released)
+	}()
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := l.Lock(ctx, "job"); err != nil {
+		t.Fatalf("Lock after release: %v", err)
+	}
+	<-released
+}
+
+func TestLockExpired(t *testing.T) {
+	r, srv := newLockServer(t)
+	ctx := context.Background()
+	l := redis.NewLocker(r, "locks", time.Second)
+
+	lk, err := l.TryLock(ctx, "job")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lk.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh of a held lock: %v", err)
+	}
+
+	srv.FastForward(2 * time.Second)
+
+	if err := lk.Refresh(ctx); err != redis.ErrLockNotHeld {
+		t.Fatalf("Refresh of an expired lock expected ErrLockNotHeld, got %v", err)
+	}
+
+	// another owner obtains it, the expired one must not release it
+	if _, err := l.TryLock(ctx, "job"); err != nil {
+		t.Fatal(err)
+	}
+	if err := lk.Unlock(ctx); err != redis.ErrLockNotHeld {
+		t.Fatalf("Unlock of an expired lock expected ErrLockNotHeld, got %v", err)
+	}
+	if _, found, err := l.InspectLock(ctx, "job"); err != nil || !found {
+		t.Fatalf("lock of the new owner expected held, got %v %v", found, err)
+	}
+
+	if stats := l.Stats()["job"]; stats.Expired != 1 || stats.RenewalFailures != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestLockInspectAndForceUnlock(t *testing.T) {
+	r, _ := newLockServer(t)
+	ctx := context.Background()
+	l := redis.NewLocker(r, "locks", time.Minute)
+
+	if _, found, err := l.InspectLock(ctx, "job"); err != nil || found {
+		t.Fatalf("InspectLock of a free lock got %v %v", found, err)
+	}
+	if err := l.ForceUnlock(ctx, "job", "stuck"); err != redis.ErrLockNotHeld {
+		t.Fatalf("ForceUnlock of a free lock expected ErrLockNotHeld, got %v", err)
+	}
+
+	lk, err := l.TryLock(ctx, "job")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	holder, found, err := l.InspectLock(ctx, "job")
+	if err != nil || !found {
+		t.Fatalf("InspectLock of a held lock got %v %v", found, err)
+	}
+	if holder.Instance != r.Instance() || holder.PID == 0 || holder.TTL <= 0 || holder.AcquiredAt.IsZero() {
+		t.Errorf("unexpected holder %+v", holder)
+	}
+
+	if err := l.ForceUnlock(ctx, "job", "stuck"); err != nil {
+		t.Fatal(err)
+	}
+	if err := lk.Unlock(ctx); err != redis.ErrLockNotHeld {
+		t.Fatalf("Unlock of a force unlocked lock expected ErrLockNotHeld, got %v", err)
+	}
+}
@@ -0,0 +1,31 @@
+package redis
+
+import (
+	"log"
+	"os"
+)
+
+type (
+	// Logger logs of this package
+	Logger interface {
+		Printf(format string, v ...interface{})
+	}
+)
+
+var (
+	defaultLogger Logger = log.New(os.Stderr, "redis: ", log.LstdFlags)
+)
+
+// SetLogger set logger of the instance
+func (r *Redis) SetLogger(logger Logger) {
+	r.logger = logger
+}
+
+func (r *Redis) logf(format string, v ...interface{}) {
+	logger := r.logger
+	if logger == nil {
+		logger = defaultLogger
+	}
+
+	logger.Printf("[%s] "+format, append([]interface{}{r.Instance()}, v...)...)
+}
@@ -0,0 +1,363 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	// MigrateOptions options of Migrate
+	MigrateOptions struct {
+		Pattern       string                      // SCAN MATCH pattern of keys to migrate, default is *
+		Count         int64                       // SCAN count hint, default is 100
+		Concurrency   int                         // keys migrated concurrently, default is 8
+		Replace       bool                        // overwrite keys existing in dst, otherwise they are skipped
+		Typed         bool                        // read and write typed values instead of DUMP/RESTORE, e.g. between incompatible redis versions
+		Verify        bool                        // only compare values and ttl presence of src and dst, do not write
+		Progress      func(p MigrateProgress)     // called every ProgressEvery scanned keys and when finished
+		ProgressEvery int64                       // default is 1000
+		OnMismatch    func(key string)            // called for each key differs in verify mode
+		OnError       func(key string, err error) // called for each key failed to migrate
+	}
+
+	// MigrateProgress counters of Migrate
+	MigrateProgress struct {
+		Scanned    int64 `json:"scanned"`
+		Migrated   int64 `json:"migrated"` // keys copied, or keys matched in verify mode
+		Skipped    int64 `json:"skipped"`
+		Mismatched int64 `json:"mismatched"`
+		Failed     int64 `json:"failed"`
+	}
+
+	migration struct {
+		src, dst *Redis
+		opts     MigrateOptions
+		progress MigrateProgress
+	}
+)
+
+// Migrate copy keys matching the pattern from src to dst with their ttl, e.g. moving from standalone to cluster.
+// Keys are scanned on every master of src and copied by DUMP/RESTORE, or typed values when opts.Typed.
+// Typed copy reads a whole key at once, streams are not supported. Failed keys are counted and reported by OnError,
+// an error is returned when any key failed or scanning failed.
+func Migrate(ctx context.Context, src, dst *Redis, opts ...MigrateOptions) (MigrateProgress, error) {
+	m := &migration{src: src, dst: dst}
+	if len(opts) > 0 {
+		m.opts = opts[0]
+	}
+
+	if m.opts.Pattern == "" {
+		m.opts.Pattern = "*"
+	}
+	if m.opts.Concurrency <= 0 {
+		m.opts.Concurrency = 8
+	}
+	if m.opts.ProgressEvery <= 0 {
+		m.opts.ProgressEvery = 1000
+	}
+
+	keys := make(chan string, m.opts.Concurrency)
+	wg := sync.WaitGroup{}
+	for i := 0; i < m.opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range keys {
+				m.key(ctx, key)
+			}
+		}()
+	}
+
+	err := src.ScanKeys(ctx, m.opts.Pattern, m.opts.Count, func(key string) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case keys <- key:
+		}
+
+		if scanned := atomic.AddInt64(&m.progress.Scanned, 1); scanned%m.opts.ProgressEvery == 0 {
+			m.report()
+		}
+
+		return nil
+	})
+	close(keys)
+	wg.Wait()
+
+	m.report()
+	progress := m.snapshot()
+
+	if err != nil {
+		return progress, fmt.Errorf("redis: migrate: %w", err)
+	}
+	if progress.Failed > 0 {
+		return progress, fmt.Errorf("redis: migrate: %d keys failed", progress.Failed)
+	}
+
+	return progress, nil
+}
+
+func (m *migration) key(ctx context.Context, key string) {
+	var (
+		ok  bool
+		err error
+	)
+
+	switch {
+	case m.opts.Verify:
+		ok, err = m.verify(ctx, key)
+		if err == nil && !ok {
+			atomic.AddInt64(&m.progress.Mismatched, 1)
+			if m.opts.OnMismatch != nil {
+				m.opts.OnMismatch(key)
+			}
+			return
+		}
+	case m.opts.Typed:
+		ok, err = m.copyTyped(ctx, key)
+	default:
+		ok, err = m.restore(ctx, key)
+	}
+
+	switch {
+	case err != nil:
+		atomic.AddInt64(&m.progress.Failed, 1)
+		if m.opts.OnError != nil {
+			m.opts.OnError(key, err)
+		}
+	case ok:
+		atomic.AddInt64(&m.progress.Migrated, 1)
+	default:
+		atomic.AddInt64(&m.progress.Skipped, 1)
+	}
+}
+
+// restore DUMP key from src and RESTORE it to dst, ok is false when key vanished or exists in dst
+func (m *migration) restore(ctx context.Context, key string) (bool, error) {
+	var dump *redis.StringCmd
+	var pttl *redis.DurationCmd
+
+	_, err := m.src.Pipelined(func(pipe redis.Pipeliner) error {
+		dump = pipe.Dump(key)
+		pttl = pipe.PTTL(key)
+		return nil
+	})
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	args := []interface{}{"restore", key, restoreTTL(pttl.Val()), dump.Val()}
+	if m.opts.Replace {
+		args = append(args, "replace")
+	}
+
+	err = m.dst.DoContext(ctx, args...).Err()
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYKEY") {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// copyTyped read typed value of key from src and write it to dst in a transaction
+func (m *migration) copyTyped(ctx context.Context, key string) (bool, error) {
+	typ, value, ttl, err := readTyped(ctx, m.src, key)
+	if err != nil || typ == "none" {
+		return false, err
+	}
+
+	if !m.opts.Replace {
+		n, err := m.dst.DoContext(ctx, "exists", key).Int64()
+		if err != nil || n > 0 {
+			return false, err
+		}
+	}
+
+	args, err := writeArgs(typ, key, value)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = m.dst.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Do("del", key)
+		if args != nil {
+			pipe.Do(args...)
+		}
+		if ttl > 0 {
+			pipe.Do("pexpire", key, int64(ttl/time.Millisecond))
+		}
+		return nil
+	})
+
+	return err == nil, err
+}
+
+// verify typed value and ttl presence of key in dst equal to src
+func (m *migration) verify(ctx context.Context, key string) (bool, error) {
+	srcType, srcValue, srcTTL, err := readTyped(ctx, m.src, key)
+	if err != nil {
+		return false, err
+	}
+	if srcType == "none" {
+		return true, nil
+	}
+
+	dstType, dstValue, dstTTL, err := readTyped(ctx, m.dst, key)
+	if err != nil {
+		return false, err
+	}
+
+	return srcType == dstType && (srcTTL > 0) == (dstTTL > 0) && reflect.DeepEqual(srcValue, dstValue), nil
+}
+
+func (m *migration) snapshot() MigrateProgress {
+	return MigrateProgress{
+		Scanned:    atomic.LoadInt64(&m.progress.Scanned),
+		Migrated:   atomic.LoadInt64(&m.progress.Migrated),
+		Skipped:    atomic.LoadInt64(&m.progress.Skipped),
+		Mismatched: atomic.LoadInt64(&m.progress.Mismatched),
+		Failed:     atomic.LoadInt64(&m.progress.Failed),
+	}
+}
+
+func (m *migration) report() {
+	if m.opts.Progress != nil {
+		m.opts.Progress(m.snapshot())
+	}
+}
+
+// readTyped type, canonical value and ttl of key, type is none when key is missed.
+// Values are []string, hashes are map[string]string and members of sets are sorted.
+func readTyped(ctx context.Context, r *Redis, key string) (string, interface{}, time.Duration, error) {
+	reply, err := r.DoContext(ctx, "type", key).Result()
+	if err != nil {
+		return "", nil, 0, err
+	}
+
+	typ := fmt.Sprint(reply)
+	if typ == "none" {
+		return typ, nil, 0, nil
+	}
+
+	var cmd *redis.Cmd
+	switch typ {
+	case "string":
+		cmd = r.DoContext(ctx, "get", key)
+	case "hash":
+		cmd = r.DoContext(ctx, "hgetall", key)
+	case "list":
+		cmd = r.DoContext(ctx, "lrange", key, 0, -1)
+	case "set":
+		cmd = r.DoContext(ctx, "smembers", key)
+	case "zset":
+		cmd = r.DoContext(ctx, "zrange", key, 0, -1, "withscores")
+	default:
+		return typ, nil, 0, fmt.Errorf("redis: migrate %s: unsupported type %s", key, typ)
+	}
+
+	v, err := cmd.Result()
+	if err == redis.Nil {
+		return "none", nil, 0, nil
+	}
+	if err != nil {
+		return typ, nil, 0, err
+	}
+
+	var value interface{}
+	switch val := v.(type) {
+	case string:
+		value = val
+	case []interface{}:
+		items := make([]string, len(val))
+		for i, item := range val {
+			items[i] = fmt.Sprint(item)
+		}
+
+		switch typ {
+		case "hash":
+			fields := make(map[string]string, len(items)/2)
+			for i := 0; i+1 < len(items); i += 2 {
+				fields[items[i]] = items[i+1]
+			}
+			value = fields
+		case "set":
+			sort.Strings(items)
+			value = items
+		default:
+			value = items
+		}
+	}
+
+	pttl, err := r.DoContext(ctx, "pttl", key).Int64()
+	if err != nil {
+		return typ, nil, 0, err
+	}
+
+	return typ, value, time.Duration(pttl) * time.Millisecond, nil
+}
+
+// writeArgs command writing value read by readTyped, nil for empty collections
+func writeArgs(typ, key string, value interface{}) ([]interface{}, error) {
+	if s, ok := value.(string); ok {
+		return []interface{}{"set", key, s}, nil
+	}
+
+	var args []interface{}
+	switch typ {
+	case "hash":
+		fields := value.(map[string]string)
+		if len(fields) == 0 {
+			return nil, nil
+		}
+		args = append(make([]interface{}, 0, 2+len(fields)*2), "hset", key)
+		for field, v := range fields {
+			args = append(args, field, v)
+		}
+	case "list", "set":
+		items := value.([]string)
+		if len(items) == 0 {
+			return nil, nil
+		}
+		command := "rpush"
+		if typ == "set" {
+			command = "sadd"
+		}
+		args = append(make([]interface{}, 0, 2+len(items)), command, key)
+		for _, item := range items {
+			args = append(args, item)
+		}
+	case "zset":
+		items := value.([]string)
+		if len(items) == 0 {
+			return nil, nil
+		}
+		args = append(make([]interface{}, 0, 2+len(items)), "zadd", key)
+		for i := 0; i+1 < len(items); i += 2 {
+			args = append(args, items[i+1], items[i])
+		}
+	default:
+		return nil, fmt.Errorf("redis: migrate %s: unsupported type %s", key, typ)
+	}
+
+	return args, nil
+}
+
+// restoreTTL ttl argument of RESTORE in milliseconds, 0 is persistent
+func restoreTTL(pttl time.Duration) int64 {
+	if pttl <= 0 {
+		return 0
+	}
+
+	return int64(pttl / time.Millisecond)
+}
@@ -0,0 +1,299 @@
+package redis
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	// Migrator versioned value migrations, applied lazily on read and by background backfill.
+	// Values written by Encode carry their version in a small header, values without header are version 0.
+	Migrator struct {
+		redis      *Redis
+		name       string
+		migrations []Migration
+	}
+
+	// Migration upgrade values of keys matching Pattern to Version
+	Migration struct {
+		Version int
+		Pattern string // glob pattern, same syntax as SCAN MATCH
+		Migrate func(ctx context.Context, key string, data []byte) ([]byte, error)
+	}
+
+	// BackfillOptions options of backfill
+	BackfillOptions struct {
+		Count    int64                         // SCAN count hint, default is 100
+		Progress func(node string, keys int64) // called after each scan batch
+	}
+)
+
+// versionMagic prefix of versioned values, followed by decimal version and ':'
+const versionMagic = "\xffV"
+
+// NewMigrator new a migrator named name, checkpoints of backfill are stored under the name.
+func NewMigrator(r *Redis, name string, migrations ...Migration) *Migrator {
+	sorted := append([]Migration(nil), migrations...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+
+	return &Migrator{
+		redis:      r,
+		name:       name,
+		migrations: sorted,
+	}
+}
+
+// Encode data with the latest version of key, use it when writing new values.
+func (m *Migrator) Encode(key string, data []byte) []byte {
+	return encodeVersion(m.latest(key), data)
+}
+
+// Get value of key migrated to the latest version, the migrated value is written back.
+func (m *Migrator) Get(ctx context.Context, key string) ([]byte, error) {
+	cmd := redis.NewStringCmd("get", key)
+	if err := m.redis.ProcessContext(ctx, cmd); err != nil {
+		return nil, err
+	}
+
+	raw, _ := cmd.Bytes()
+
+	data, _, err := m.migrate(ctx, m.redis, key, raw)
+
+	return data, err
+}
+
+// Backfill migrate all keys matching the patterns of migrations, resuming from the last checkpoint.
+func (m *Migrator) Backfill(ctx context.Context, opts BackfillOptions) error {
+	if opts.Count <= 0 {
+		opts.Count = 100
+	}
+
+	patterns := map[string]bool{}
+	for _, migration := range m.migrations {
+		patterns[migration.Pattern] = true
+	}
+
+	return m.redis.forEachMaster(func(node redis.UniversalClient, addr string) error {
+		for pattern := range patterns {
+			if err := m.backfill(ctx, node, addr, pattern, opts); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Reset remove backfill checkpoints, the next backfill starts over.
+func (m *Migrator) Reset(ctx context.Context) error {
+	return m.redis.DoContext(ctx, "del", m.checkpointKey()).Err()
+}
+
+func (m *Migrator) backfill(ctx context.Context, node redis.UniversalClient, addr, pattern string, opts BackfillOptions) error {
+	field := addr + "|" + pattern
+
+	cursor, err := m.redis.DoContext(ctx, "hget", m.checkpointKey(), field).Uint64()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+	if cursor == ^uint64(0) {
+		return nil
+	}
+
+	var total int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		keys, next, err := node.Scan(cursor, pattern, opts.Count).Result()
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			raw, err := node.DoContext(ctx, "get", key).Text()
+			if err == redis.Nil || err != nil && strings.HasPrefix(err.Error(), "WRONGTYPE") {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			if _, _, err := m.migrate(ctx, node, key, []byte(raw)); err != nil {
+				return err
+			}
+		}
+
+		total += int64(len(keys))
+		if opts.Progress != nil {
+			opts.Progress(addr, total)
+		}
+
+		if next == 0 {
+			return m.redis.DoContext(ctx, "hset", m.checkpointKey(), field, strconv.FormatUint(^uint64(0), 10)).Err()
+		}
+
+		if err := m.redis.DoContext(ctx, "hset", m.checkpointKey(), field, next).Err(); err != nil {
+			return err
+		}
+		cursor = next
+	}
+}
+
+// migrate raw value of key, write back when changed
+func (m *Migrator) migrate(ctx context.Context, c scripter, key string, raw []byte) ([]byte, bool, error) {
+	version, data := decodeVersion(raw)
+	migrated := false
+
+	for _, migration := range m.migrations {
+		if migration.Version <= version || !matchKey(migration.Pattern, key) {
+			continue
+		}
+
+		next, err := migration.Migrate(ctx, key, data)
+		if err != nil {
+			return nil, false, fmt.Errorf("redis: migrate %s to version %d: %w", key, migration.Version, err)
+		}
+
+		data, version, migrated = next, migration.Version, true
+	}
+
+	if migrated {
+		if err := casScript.run(ctx, c, []string{key}, raw, encodeVersion(version, data)).Err(); err != nil {
+			return nil, false, err
+		}
+	}
+
+	return data, migrated, nil
+}
+
+func (m *Migrator) latest(key string) int {
+	version := 0
+	for _, migration := range m.migrations {
+		if matchKey(migration.Pattern, key) && migration.Version > version {
+			version = migration.Version
+		}
+	}
+
+	return version
+}
+
+func (m *Migrator) checkpointKey() string {
+	return "migration:" + m.name + ":checkpoints"
+}
+
+func encodeVersion(version int, data []byte) []byte {
+	if version == 0 {
+		return data
+	}
+
+	header := versionMagic + strconv.Itoa(version) + ":"
+
+	return append([]byte(header), data...)
+}
+
+func decodeVersion(raw []byte) (int, []byte) {
+	if !bytes.HasPrefix(raw, []byte(versionMagic)) {
+		return 0, raw
+	}
+
+	rest := raw[len(versionMagic):]
+	i := bytes.IndexByte(rest, ':')
+	if i < 0 {
+		return 0, raw
+	}
+
+	version, err := strconv.Atoi(string(rest[:i]))
+	if err != nil {
+		return 0, raw
+	}
+
+	return version, rest[i+1:]
+}
+
+// matchKey match key with glob pattern the way redis does for SCAN MATCH and KEYS:
+// * ? [abc] [^abc] [a-z] and \ escapes, '/' is an ordinary character
+func matchKey(pattern, key string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(key); i++ {
+				if matchKey(pattern[1:], key[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(key) == 0 {
+				return false
+			}
+			key = key[1:]
+		case '[':
+			if len(key) == 0 {
+				return false
+			}
+
+			pattern = pattern[1:]
+			not := len(pattern) > 0 && pattern[0] == '^'
+			if not {
+				pattern = pattern[1:]
+			}
+
+			match := false
+			for len(pattern) > 0 && pattern[0] != ']' {
+				switch {
+				case pattern[0] == '\\' && len(pattern) >= 2:
+					pattern = pattern[1:]
+					match = match || pattern[0] == key[0]
+				case len(pattern) >= 3 && pattern[1] == '-':
+					start, end := pattern[0], pattern[2]
+					if start > end {
+						start, end = end, start
+					}
+					match = match || key[0] >= start && key[0] <= end
+					pattern = pattern[2:]
+				default:
+					match = match || pattern[0] == key[0]
+				}
+				pattern = pattern[1:]
+			}
+
+			if match == not {
+				return false
+			}
+			key = key[1:]
+
+			// unterminated class ends the pattern
+			if len(pattern) == 0 {
+				return len(key) == 0
+			}
+		default:
+			if pattern[0] == '\\' && len(pattern) >= 2 {
+				pattern = pattern[1:]
+			}
+			if len(key) == 0 || pattern[0] != key[0] {
+				return false
+			}
+			key = key[1:]
+		}
+
+		pattern = pattern[1:]
+	}
+
+	return len(key) == 0
+}
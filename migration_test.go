@@ -0,0 +1,57 @@
+package redis
+
+import "testing"
+
+func TestMatchKey(t *testing.T) {
+	tests := []struct {
+		pattern, key string
+		want         bool
+	}{
+		{"", "", true},
+		{"", "a", false},
+		{"user:1", "user:1", true},
+		{"user:1", "user:12", false},
+		{"*", "", true},
+		{"*", "user:1", true},
+		{"user:*", "user:1", true},
+		{"user:*", "users:1", false},
+		{"*:1", "user:1", true},
+		{"u*r:1", "ur:1", true},
+		{"u*r:1", "user:2", false},
+		{"*/*", "a/b/c", true},
+		// ** is the same as *
+		{"**", "", true},
+		{"a**b", "axxb", true},
+		{"a**b", "axxc", false},
+		{"?", "", false},
+		{"user:?", "user:1", true},
+		{"user:?", "user:12", false},
+		// classes
+		{"h[ae]llo", "hello", true},
+		{"h[ae]llo", "hallo", true},
+		{"h[ae]llo", "hillo", false},
+		{"h[^e]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-c]llo", "hbllo", true},
+		{"h[a-c]llo", "hdllo", false},
+		{"h[c-a]llo", "hbllo", true},
+		{"h[\\]]llo", "h]llo", true},
+		{"h[\\-]llo", "h-llo", true},
+		{"h[ae]llo", "hllo", false},
+		// unterminated class ends the pattern
+		{"a[bc", "ab", true},
+		{"a[bc", "abx", false},
+		// escapes
+		{"a\\*b", "a*b", true},
+		{"a\\*b", "axb", false},
+		{"\\?", "?", true},
+		{"\\?", "x", false},
+		{"a\\", "a\\", true},
+	}
+
+	for _, tt := range tests {
+		if got := matchKey(tt.pattern, tt.key); got != tt.want {
+			t.Errorf("matchKey(%q, %q) = %v, want %v", tt.pattern, tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package redis
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type (
+	// mirrorHook copy successful write commands to the mirror, added when a mirror is set
+	mirrorHook struct {
+		m *mirror
+	}
+
+	// mirror best effort asynchronous copy of writes to a secondary, in order by one sender
+	mirror struct {
+		primary   *Redis
+		secondary *Redis
+		queue     chan []interface{}
+		once      sync.Once
+		counter   *prometheus.CounterVec
+	}
+)
+
+// Mirror results of the mirror metrics
+const (
+	MirrorOK      = "ok"
+	MirrorError   = "error"
+	MirrorDropped = "dropped"
+)
+
+var (
+	// commands never mirrored: connection, server, pub/sub, transaction control and blocking pops
+	unmirroredCommands = map[string]bool{
+		"auth": true, "client": true, "cluster": true, "command": true, "config": true, "debug": true,
+		"echo": true, "info": true, "memory": true, "monitor": true, "ping": true, "psubscribe": true,
+		"punsubscribe": true, "quit": true, "readonly": true, "readwrite": true, "role": true, "select": true,
+		"slowlog": true, "subscribe": true, "unsubscribe": true, "publish": true, "dbsize": true, "time": true,
+		"scan": true, "hscan": true, "sscan": true, "zscan": true, "keys": true, "object": true, "dump": true,
+		"multi": true, "exec": true, "discard": true, "watch": true, "unwatch": true, "script": true,
+		"flushall": true, "flushdb": true, "shutdown": true, "hello": true,
+	}
+)
+
+// SetMirror mirror successful write commands to secondary asynchronously, for live migrations and validating a new cluster.
+// Writes are queued up to queue commands (default 10000) and dropped when the queue is full. Errors and drops are counted
+// by redis_mirror_total when metrics is enabled, the primary is never affected. Set it before the client is used.
+func (r *Redis) SetMirror(secondary *Redis, queue int) {
+	if queue <= 0 {
+		queue = 10000
+	}
+
+	m := &mirror{
+		primary:   r,
+		secondary: secondary,
+		queue:     make(chan []interface{}, queue),
+	}
+
+	if r.Metrics {
+		m.counter = mustRegister(prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: r.metrics.Namespace,
+				Subsystem: r.metrics.Subsystem,
+				Name:      "redis_mirror_total",
+				Help:      "redis write commands mirrored to a secondary total",
+			},
+			[]string{"redis_instance", "mirror", "result"},
+		)).(*prometheus.CounterVec)
+	}
+
+	r.mirror = m
+	// added to the current client only, clients swapped by Reload are built with it by newClient
+	if r.UniversalClient != nil {
+		r.client().AddHook(mirrorHook{m: m})
+	}
+}
+
+func (h mirrorHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (h mirrorHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	h.m.enqueue(cmd)
+
+	return nil
+}
+
+func (h mirrorHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (h mirrorHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	for _, cmd := range cmds {
+		h.m.enqueue(cmd)
+	}
+
+	return nil
+}
+
+func (m *mirror) enqueue(cmd redis.Cmder) {
+	name := strings.ToLower(cmd.Name())
+	if readOnlyCommands[name] || blockingCommands[name] || unmirroredCommands[name] {
+		return
+	}
+	if err := cmd.Err(); err != nil && err != redis.Nil {
+		return
+	}
+
+	m.once.Do(func() {
+		go m.send()
+	})
+
+	select {
+	case m.queue <- cmd.Args():
+	default:
+		m.count(MirrorDropped)
+	}
+}
+
+// send mirror queued commands in order
+func (m *mirror) send() {
+	ctx := context.Background()
+
+	for args := range m.queue {
+		err := m.secondary.DoContext(ctx, args...).Err()
+
+		// scripts run by EVALSHA are not cached by the secondary, run their source
+		if err != nil && strings.HasPrefix(err.Error(), "NOSCRIPT") && len(args) > 1 {
+			if s, ok := scriptsByHash.Load(args[1]); ok {
+				evalArgs := append([]interface{}{"eval", s.(*script).src}, args[2:]...)
+				err = m.secondary.DoContext(ctx, evalArgs...).Err()
+			}
+		}
+
+		if err != nil && err != redis.Nil {
+			m.count(MirrorError)
+			continue
+		}
+		m.count(MirrorOK)
+	}
+}
+
+func (m *mirror) count(result string) {
+	if m.counter != nil {
+		m.counter.WithLabelValues(m.primary.Instance(), m.secondary.Instance(), result).Inc()
+	}
+}
@@ -0,0 +1,119 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	// MessageTracer propagate trace context through queued messages, implemented over the tracer of the app,
+	// so a trace shows the enqueue, the wait in queue and the processing of a message.
+	MessageTracer interface {
+		// Inject the trace context of ctx into carrier when a message is enqueued
+		Inject(ctx context.Context, carrier map[string]string)
+		// StartConsumer start the consumer span of a message of queue linked to the producer span of carrier,
+		// wait is the time the message was ready before it was handled. end is called with the error of the handler.
+		StartConsumer(ctx context.Context, queue string, carrier map[string]string, wait time.Duration) (_ context.Context, end func(err error))
+	}
+
+	// traceEnvelope trace context of a message kept beside its payload
+	traceEnvelope struct {
+		At      int64             `json:"at"` // ms the message is ready
+		Carrier map[string]string `json:"carrier"`
+	}
+)
+
+// traceField prefix of trace context fields of stream messages
+const traceField = "trace:"
+
+// SetMessageTracer propagate trace context of messages of the priority queues, delay schedulers and stream consumers by t.
+// Call it before serving, messages enqueued without a tracer are handled without links.
+func (r *Redis) SetMessageTracer(t MessageTracer) {
+	r.msgTracer = t
+}
+
+// injectTrace carrier of the trace context of ctx, nil without a tracer
+func (r *Redis) injectTrace(ctx context.Context) map[string]string {
+	if r.msgTracer == nil {
+		return nil
+	}
+
+	carrier := map[string]string{}
+	r.msgTracer.Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+
+	return carrier
+}
+
+// encodeTrace envelope of the trace context of ctx for a message ready at, empty without a tracer
+func (r *Redis) encodeTrace(ctx context.Context, at time.Time) string {
+	return encodeCarrier(r.injectTrace(ctx), at)
+}
+
+// encodeCarrier envelope of carrier for a message ready at, empty when carrier is
+func encodeCarrier(carrier map[string]string, at time.Time) string {
+	if len(carrier) == 0 {
+		return ""
+	}
+
+	data, _ := json.Marshal(traceEnvelope{At: unixMilli(at), Carrier: carrier})
+
+	return string(data)
+}
+
+// decodeTrace carrier and ready time of envelope data, nil carrier when data is empty or invalid
+func decodeTrace(data string) (map[string]string, time.Time) {
+	var env traceEnvelope
+	if data == "" || json.Unmarshal([]byte(data), &env) != nil {
+		return nil, time.Time{}
+	}
+
+	return env.Carrier, time.Unix(0, env.At*int64(time.Millisecond))
+}
+
+// startConsumer start the consumer span of a message of queue ready at, ctx is returned as is without a tracer.
+// Messages without trace context are handled in spans without links.
+func (r *Redis) startConsumer(ctx context.Context, queue string, carrier map[string]string, ready time.Time) (context.Context, func(err error)) {
+	if r.msgTracer == nil {
+		return ctx, func(error) {}
+	}
+
+	var wait time.Duration
+	if !ready.IsZero() {
+		if wait = time.Since(ready); wait < 0 {
+			wait = 0
+		}
+	}
+
+	return r.msgTracer.StartConsumer(ctx, queue, carrier, wait)
+}
+
+// streamTrace carrier of the trace: fields of msg and its ready time from its id
+func streamTrace(msg redis.XMessage) (map[string]string, time.Time) {
+	var carrier map[string]string
+	for field, value := range msg.Values {
+		if strings.HasPrefix(field, traceField) {
+			if carrier == nil {
+				carrier = map[string]string{}
+			}
+			carrier[strings.TrimPrefix(field, traceField)] = fmt.Sprint(value)
+		}
+	}
+
+	var ready time.Time
+	if i := strings.IndexByte(msg.ID, '-'); i > 0 {
+		if ms, err := strconv.ParseInt(msg.ID[:i], 10, 64); err == nil {
+			ready = time.Unix(0, ms*int64(time.Millisecond))
+		}
+	}
+
+	return carrier, ready
+}
@@ -0,0 +1,236 @@
+package redis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type (
+	// NamespacePolicy data handling of values of a declared namespace
+	NamespacePolicy struct {
+		Codec            string `config:"codec" help:"Codec of values, json or one registered by RegisterCodec, default is the codec of the helper"`
+		Compression      string `config:"compression" help:"Compression of values, gzip, flate, none or one registered by RegisterCompressor, default is the compression config"`
+		CompressMinBytes int    `config:"compressMinBytes" help:"Compress values not smaller than this size, default is the compressMinBytes config"`
+		Encryption       string `config:"encryption" help:"Encryption of values, one registered by RegisterEncryptor, default is none"`
+	}
+
+	// Encryptor encrypt values of namespaces declaring its encryption
+	Encryptor interface {
+		Encrypt(data []byte) ([]byte, error)
+		Decrypt(data []byte) ([]byte, error)
+	}
+
+	namespaceEntry struct {
+		prefix string
+		NamespacePolicy
+	}
+
+	encryptorEntry struct {
+		name string
+		id   byte
+		Encryptor
+	}
+)
+
+const (
+	// encryptMagic prefix of encrypted values, followed by encryptor id
+	encryptMagic = "\xffE"
+
+	// noCompression disable compression of a namespace
+	noCompression = "none"
+)
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]Codec{
+		"json": JSONCodec,
+	}
+
+	encryptorsMu sync.RWMutex
+	encryptors   = map[string]*encryptorEntry{}
+	encryptorIDs = map[byte]*encryptorEntry{}
+)
+
+// RegisterCodec register codec by name for namespace policies, json is builtin.
+func RegisterCodec(name string, c Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+
+	codecs[name] = c
+}
+
+// RegisterEncryptor register encryptor by name for namespace policies, id is written to value header for format detection.
+func RegisterEncryptor(name string, id byte, e Encryptor) {
+	encryptorsMu.Lock()
+	defer encryptorsMu.Unlock()
+
+	entry := &encryptorEntry{name: name, id: id, Encryptor: e}
+	encryptors[name] = entry
+	encryptorIDs[id] = entry
+}
+
+// DeclareNamespace apply policy to values of keys starting with "namespace:".
+// Namespaces of the namespaces config are declared when config loaded, the longest matching namespace wins.
+func (r *Redis) DeclareNamespace(namespace string, policy NamespacePolicy) error {
+	if err := policy.validate(); err != nil {
+		return fmt.Errorf("redis: namespace %s: %w", namespace, err)
+	}
+
+	r.schemas.mu.Lock()
+	defer r.schemas.mu.Unlock()
+
+	prefix := namespace + ":"
+	for i := range r.schemas.namespaces {
+		if r.schemas.namespaces[i].prefix == prefix {
+			r.schemas.namespaces[i].NamespacePolicy = policy
+			return nil
+		}
+	}
+
+	r.schemas.namespaces = append(r.schemas.namespaces, namespaceEntry{prefix: prefix, NamespacePolicy: policy})
+	sort.SliceStable(r.schemas.namespaces, func(i, j int) bool {
+		return len(r.schemas.namespaces[i].prefix) > len(r.schemas.namespaces[j].prefix)
+	})
+
+	return nil
+}
+
+// declareNamespaces declare namespaces of config
+func (r *Redis) declareNamespaces() {
+	for namespace, policy := range r.Namespaces {
+		if err := r.DeclareNamespace(namespace, policy); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// namespace policy of key, nil when no namespace matches
+func (sr *schemaRegistry) namespace(key string) *NamespacePolicy {
+	sr.mu.RLock()
+	defer sr.mu.RUnlock()
+
+	for i := range sr.namespaces {
+		if strings.HasPrefix(key, sr.namespaces[i].prefix) {
+			policy := sr.namespaces[i].NamespacePolicy
+			return &policy
+		}
+	}
+
+	return nil
+}
+
+func (p NamespacePolicy) validate() error {
+	if p.Codec != "" {
+		if _, ok := lookupCodec(p.Codec); !ok {
+			return fmt.Errorf("unknown codec %s", p.Codec)
+		}
+	}
+
+	if p.Compression != "" && p.Compression != noCompression {
+		compressorsMu.RLock()
+		_, ok := compressors[p.Compression]
+		compressorsMu.RUnlock()
+		if !ok {
+			return fmt.Errorf("unknown compression %s", p.Compression)
+		}
+	}
+
+	if p.Encryption != "" {
+		encryptorsMu.RLock()
+		_, ok := encryptors[p.Encryption]
+		encryptorsMu.RUnlock()
+		if !ok {
+			return fmt.Errorf("unknown encryption %s", p.Encryption)
+		}
+	}
+
+	return nil
+}
+
+func lookupCodec(name string) (Codec, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	c, ok := codecs[name]
+	return c, ok
+}
+
+// encode data of key: compress then encrypt by the namespace policy, the box compression config applies without policy
+func (r *Redis) encode(key string, data []byte) ([]byte, error) {
+	policy := r.schemas.namespace(key)
+	if policy == nil {
+		return r.compress(r.Compression, r.CompressMinBytes, data)
+	}
+
+	compression, minBytes := r.Compression, r.CompressMinBytes
+	if policy.Compression != "" {
+		compression = policy.Compression
+	}
+	if policy.CompressMinBytes > 0 {
+		minBytes = policy.CompressMinBytes
+	}
+	if compression == noCompression {
+		compression = ""
+	}
+
+	data, err := r.compress(compression, minBytes, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return encrypt(policy.Encryption, data)
+}
+
+// decode data written by encode, formats are detected by value headers so policy changes keep old values readable
+func (r *Redis) decode(data []byte) ([]byte, error) {
+	data, err := decrypt(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return decompress(data)
+}
+
+func encrypt(name string, data []byte) ([]byte, error) {
+	if name == "" {
+		return data, nil
+	}
+
+	encryptorsMu.RLock()
+	entry, ok := encryptors[name]
+	encryptorsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("redis: unknown encryption %s", name)
+	}
+
+	encrypted, err := entry.Encrypt(data)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(encryptMagic)+1+len(encrypted))
+	out = append(out, encryptMagic...)
+	out = append(out, entry.id)
+
+	return append(out, encrypted...), nil
+}
+
+// decrypt data written by encrypt, data without header is returned as is
+func decrypt(data []byte) ([]byte, error) {
+	if len(data) <= len(encryptMagic) || string(data[:len(encryptMagic)]) != encryptMagic {
+		return data, nil
+	}
+
+	id := data[len(encryptMagic)]
+
+	encryptorsMu.RLock()
+	entry, ok := encryptorIDs[id]
+	encryptorsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("redis: unknown encryptor id %d", id)
+	}
+
+	return entry.Decrypt(data[len(encryptMagic)+1:])
+}
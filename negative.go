@@ -0,0 +1,62 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// missMarker value of keys cached as not found
+const missMarker = "\xffN"
+
+var (
+	// ErrNotFound entity does not exist in the source, returned by loaders to cache the miss
+	// and by GetOrLoad for cached misses
+	ErrNotFound = errors.New("redis: not found")
+)
+
+// SetMissTTL cache not found results of GetOrLoad loaders (errors wrapping ErrNotFound) for ttl,
+// so lookups of nonexistent entities do not hammer the source. 0 disables it. Call it before using the cache.
+func (c *Cache) SetMissTTL(ttl time.Duration) {
+	c.missTTL = ttl
+
+	if ttl > 0 && c.redis.Metrics && c.negativeCounter == nil {
+		c.negativeCounter = mustRegister(prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: c.redis.metrics.Namespace,
+				Subsystem: c.redis.metrics.Subsystem,
+				Name:      "redis_cache_negative_hits_total",
+				Help:      "redis cache lookups served by cached not found markers total",
+			},
+			[]string{"redis_instance", "cache"},
+		)).(*prometheus.CounterVec)
+	}
+}
+
+// NegativeHits number of lookups served by cached not found markers
+func (c *Cache) NegativeHits() int64 {
+	return atomic.LoadInt64(&c.negativeHits)
+}
+
+func (c *Cache) countNegativeHit() {
+	atomic.AddInt64(&c.negativeHits, 1)
+
+	if c.negativeCounter != nil {
+		c.negativeCounter.WithLabelValues(c.redis.Instance(), c.prefix).Inc()
+	}
+}
+
+// storeMiss cache the not found result of key, the error of the loader is kept
+func (c *Cache) storeMiss(ctx context.Context, key string, err error) error {
+	if c.missTTL <= 0 || !errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	c.redis.ProcessContext(ctx, redis.NewStatusCmd(setArgs(c.key(key), missMarker, c.missTTL)...))
+
+	return err
+}
@@ -0,0 +1,46 @@
+package redis
+
+import (
+	"sync"
+
+	"github.com/go-redis/redis/v7"
+)
+
+// forEachMaster call fn on each master node concurrently for cluster clients, on the client itself otherwise
+func (r *Redis) forEachMaster(fn func(node redis.UniversalClient, addr string) error) error {
+	cluster, ok := r.client().(*redis.ClusterClient)
+	if !ok {
+		return fn(r.UniversalClient, nodeAddr(r.client()))
+	}
+
+	var (
+		mu    sync.Mutex
+		first error
+	)
+
+	err := cluster.ForEachMaster(func(client *redis.Client) error {
+		if err := fn(client, client.Options().Addr); err != nil {
+			mu.Lock()
+			if first == nil {
+				first = err
+			}
+			mu.Unlock()
+			return err
+		}
+
+		return nil
+	})
+	if first != nil {
+		return first
+	}
+
+	return err
+}
+
+func nodeAddr(c redis.UniversalClient) string {
+	if client, ok := c.(*redis.Client); ok {
+		return client.Options().Addr
+	}
+
+	return ""
+}
@@ -0,0 +1,50 @@
+package redis
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-redis/redis/v7"
+)
+
+// OnConnect register callback called on every new connection after it is authenticated and named.
+// Callbacks can be registered at any time, they apply to connections dialed afterwards.
+func (r *Redis) OnConnect(fn func(conn *redis.Conn) error) {
+	r.onConnectMu.Lock()
+	r.onConnects = append(r.onConnects, fn)
+	r.onConnectMu.Unlock()
+}
+
+// ClientName name set by CLIENT SETNAME on connections, <appname>:<instance>:<pid>
+func (r *Redis) ClientName() string {
+	app := r.AppName
+	if app == "" {
+		app = filepath.Base(os.Args[0])
+	}
+
+	// client names must not contain spaces
+	return strings.Replace(fmt.Sprintf("%s:%s:%d", app, r.Instance(), os.Getpid()), " ", "-", -1)
+}
+
+// onConnect name the connection and call registered callbacks
+func (r *Redis) onConnect(conn *redis.Conn) error {
+	if !r.DisableClientName {
+		if err := conn.ClientSetName(r.clientName).Err(); err != nil {
+			return err
+		}
+	}
+
+	r.onConnectMu.Lock()
+	callbacks := r.onConnects
+	r.onConnectMu.Unlock()
+
+	for _, fn := range callbacks {
+		if err := fn(conn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,217 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	// OperationGrant destructive operation allowed by a token
+	OperationGrant struct {
+		Op        string    `json:"op"`
+		Token     string    `json:"token"`
+		Reason    string    `json:"reason"`
+		Uses      int       `json:"uses"`
+		ExpiresAt time.Time `json:"expiresAt"`
+	}
+
+	operationTokenKey struct{}
+)
+
+// destructive operations guarded by operation tokens
+const (
+	OpDeleteByPattern = "delete_by_pattern"
+	OpForceUnlock     = "force_unlock"
+	OpFlushNamespace  = "flush_namespace"
+	OpPurgeDLQ        = "purge_dlq"
+)
+
+const (
+	// OperationTokenHeader request header of admin endpoints carrying the operation token
+	OperationTokenHeader = "X-Operation-Token"
+
+	defaultOpsAuditMaxLen = 10000
+	maxOperationTTL       = time.Hour
+)
+
+var (
+	// ErrOperationToken destructive operation without a valid operation token
+	ErrOperationToken = errors.New("redis: operation token missing, expired or not granted for this operation")
+
+	// KEYS[1]: token. ARGV[1]: op
+	useOperationScript = newScript(`
+if redis.call('hget', KEYS[1], 'op') ~= ARGV[1] then
+	return 0
+end
+local uses = tonumber(redis.call('hget', KEYS[1], 'uses'))
+if uses == 1 then
+	redis.call('del', KEYS[1])
+elseif uses > 1 then
+	redis.call('hincrby', KEYS[1], 'uses', -1)
+end
+return 1
+`)
+)
+
+// WithOperationToken ctx carrying token authorizing destructive operations
+func WithOperationToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, operationTokenKey{}, token)
+}
+
+// GrantOperation grant a token authorizing op for ttl (at most 1h), uses limits how many times it can be used, 0 is unlimited in ttl.
+// Tokens are required only when opsKey is configured, grants are recorded to the audit stream.
+func (r *Redis) GrantOperation(ctx context.Context, op string, ttl time.Duration, uses int, reason string) (OperationGrant, error) {
+	if r.OpsKey == "" {
+		return OperationGrant{}, fmt.Errorf("redis: grant %s: opsKey is not configured", op)
+	}
+	if reason == "" {
+		return OperationGrant{}, fmt.Errorf("redis: grant %s: reason is required", op)
+	}
+	if ttl <= 0 || ttl > maxOperationTTL {
+		ttl = maxOperationTTL
+	}
+	if uses < 0 {
+		uses = 0
+	}
+
+	grant := OperationGrant{
+		Op:        op,
+		Token:     randomID(),
+		Reason:    reason,
+		Uses:      uses,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+
+	key := r.operationKey(grant.Token)
+	if _, err := r.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Do("hset", key, "op", op, "reason", reason, "uses", uses)
+		pipe.PExpire(key, ttl)
+		return nil
+	}); err != nil {
+		return OperationGrant{}, err
+	}
+
+	r.audit(ctx, "grant", op, "", grant.Token, reason, nil)
+
+	return grant, nil
+}
+
+// RevokeOperation revoke token before it expires
+func (r *Redis) RevokeOperation(ctx context.Context, token string) error {
+	if r.OpsKey == "" {
+		return nil
+	}
+
+	if err := r.DoContext(ctx, "del", r.operationKey(token)).Err(); err != nil {
+		return err
+	}
+
+	r.audit(ctx, "revoke", "", "", token, "", nil)
+
+	return nil
+}
+
+// authorize use the operation token of ctx for op on target, done records the result to the audit stream
+func (r *Redis) authorize(ctx context.Context, op, target string) (done func(err error), err error) {
+	if r.OpsKey == "" {
+		return func(error) {}, nil
+	}
+
+	token, _ := ctx.Value(operationTokenKey{}).(string)
+	if token == "" {
+		r.audit(ctx, "denied", op, target, "", "", ErrOperationToken)
+		return nil, ErrOperationToken
+	}
+
+	n, err := useOperationScript.run(ctx, r, []string{r.operationKey(token)}, op).Int64()
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		r.audit(ctx, "denied", op, target, token, "", ErrOperationToken)
+		return nil, ErrOperationToken
+	}
+
+	return func(err error) {
+		r.audit(ctx, "run", op, target, token, "", err)
+	}, nil
+}
+
+// audit append an entry to the audit stream, failures are logged only
+func (r *Redis) audit(ctx context.Context, action, op, target, token, reason string, result error) {
+	status := "ok"
+	if result != nil {
+		status = result.Error()
+	}
+
+	// only a prefix of the token is recorded, so the stream can not be used to replay it
+	if len(token) > 8 {
+		token = token[:8]
+	}
+
+	maxLen := r.OpsAuditMaxLen
+	if maxLen <= 0 {
+		maxLen = defaultOpsAuditMaxLen
+	}
+
+	err := r.DoContext(ctx, "xadd", r.OpsKey+":audit", "maxlen", "~", maxLen, "*",
+		"action", action,
+		"op", op,
+		"target", target,
+		"token", token,
+		"reason", reason,
+		"result", status,
+		"instance", r.Instance(),
+		"hostname", hostname,
+		"pid", os.Getpid(),
+	).Err()
+	if err != nil {
+		r.logf("audit: %s %s %s failed to record: %s", action, op, target, err)
+	}
+}
+
+func (r *Redis) operationKey(token string) string {
+	return r.OpsKey + ":token:" + token
+}
+
+// operationContext ctx of an admin request carrying its operation token
+func operationContext(req *http.Request) context.Context {
+	token := req.Header.Get(OperationTokenHeader)
+	if token == "" {
+		token = req.FormValue("token")
+	}
+
+	return WithOperationToken(req.Context(), token)
+}
+
+func (r *Redis) handleOperations(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodPost, http.MethodPut:
+		ttl, _ := time.ParseDuration(req.FormValue("ttl"))
+		uses, _ := strconv.Atoi(req.FormValue("uses"))
+
+		grant, err := r.GrantOperation(req.Context(), req.FormValue("op"), ttl, uses, req.FormValue("reason"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		writeJSON(w, grant)
+	case http.MethodDelete:
+		if err := r.RevokeOperation(req.Context(), req.FormValue("token")); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,303 @@
+package redis
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	// OutboxEvent domain event appended to the outbox stream
+	OutboxEvent struct {
+		ID      string    `json:"id"`
+		Type    string    `json:"type"`
+		Payload []byte    `json:"payload"`
+		At      time.Time `json:"at"`
+	}
+
+	// Publisher deliver events to a broker or callback, e.g. a kafka producer adapter
+	Publisher interface {
+		Publish(ctx context.Context, event OutboxEvent) error
+	}
+
+	// PublisherFunc function as Publisher
+	PublisherFunc func(ctx context.Context, event OutboxEvent) error
+
+	// OutboxRelay outbox relay as a box. The leader among replicas reads the outbox stream from its checkpoint
+	// and dispatches events to publishers registered for their type, at least once.
+	// Events failed maxAttempts times are moved to the "<stream>:dlq" stream.
+	OutboxRelay struct {
+		Stream      string        `config:"stream" help:"Outbox stream, default is <name>:outbox"`
+		Batch       int           `config:"batch" default:"100" help:"Events read per batch, default is 100"`
+		MaxAttempts int           `config:"maxAttempts" default:"5" help:"Publish attempts of an event before it is moved to the DLQ, default is 5"`
+		Backoff     time.Duration `config:"backoff" default:"100ms" help:"Initial backoff between publish attempts, doubled after each attempt, default is 100ms"`
+		LeaderTTL   time.Duration `config:"leaderTTL" default:"10s" help:"Leader lock ttl, default is 10s"`
+
+		Component
+		mu         sync.RWMutex
+		publishers map[string][]Publisher
+		done       chan struct{}
+		wg         sync.WaitGroup
+	}
+)
+
+const (
+	outboxBlock = time.Second
+)
+
+// AppendOutbox append event of type with payload to outbox stream, MAXLEN ~ maxLen trims it when positive.
+func AppendOutbox(ctx context.Context, r *Redis, stream, typ string, payload []byte, maxLen int64) (string, error) {
+	args := []interface{}{"xadd", stream}
+	if maxLen > 0 {
+		args = append(args, "maxlen", "~", maxLen)
+	}
+	args = append(args, "*", "type", typ, "payload", payload, "at", unixMilli(time.Now()))
+
+	return r.DoContext(ctx, args...).Text()
+}
+
+// Publish call f
+func (f PublisherFunc) Publish(ctx context.Context, event OutboxEvent) error {
+	return f(ctx, event)
+}
+
+// HTTPPublisher POST events as JSON to url, non 2xx responses are failures. client is http.DefaultClient if it is nil.
+func HTTPPublisher(url string, client *http.Client) Publisher {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return PublisherFunc(func(ctx context.Context, event OutboxEvent) error {
+		body, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(req.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("redis: outbox publish %s: %s", url, resp.Status)
+		}
+
+		return nil
+	})
+}
+
+// NewOutboxRelay new an outbox relay box named name
+func NewOutboxRelay(name string, r *Redis) *OutboxRelay {
+	return &OutboxRelay{
+		Component:  NewComponent(name, r),
+		publishers: make(map[string][]Publisher),
+	}
+}
+
+// Register publisher of events of typ, "*" receives all types. An event is delivered when all its publishers succeeded,
+// events without publishers are skipped.
+func (o *OutboxRelay) Register(typ string, publisher Publisher) {
+	o.mu.Lock()
+	o.publishers[typ] = append(o.publishers[typ], publisher)
+	o.mu.Unlock()
+}
+
+// ConfigWillLoad config will load
+func (o *OutboxRelay) ConfigWillLoad(context.Context) {
+
+}
+
+// ConfigDidLoad set defaults
+func (o *OutboxRelay) ConfigDidLoad(context.Context) {
+	if o.Stream == "" {
+		o.Stream = o.name + ":outbox"
+	}
+	if o.Batch <= 0 {
+		o.Batch = 100
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 5
+	}
+	if o.Backoff <= 0 {
+		o.Backoff = 100 * time.Millisecond
+	}
+	if o.LeaderTTL <= 0 {
+		o.LeaderTTL = defaultLockTTL
+	}
+}
+
+// Serve start relaying when elected
+func (o *OutboxRelay) Serve(context.Context) error {
+	o.done = make(chan struct{})
+
+	o.wg.Add(1)
+	go o.run(o.done)
+
+	return nil
+}
+
+// Shutdown stop relaying, the current event is finished first
+func (o *OutboxRelay) Shutdown(context.Context) error {
+	if o.done != nil {
+		close(o.done)
+		o.done = nil
+	}
+
+	o.wg.Wait()
+
+	return nil
+}
+
+// Checkpoint id of the last relayed event, read from the master since relaying resumes after it
+func (o *OutboxRelay) Checkpoint(ctx context.Context) (string, error) {
+	id, err := o.Component.redis.DoContext(ForceMaster(ctx), "get", o.Stream+":checkpoint").Text()
+	if err == redis.Nil {
+		return "0-0", nil
+	}
+
+	return id, err
+}
+
+func (o *OutboxRelay) run(done <-chan struct{}) {
+	defer o.wg.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-done
+		cancel()
+	}()
+
+	r := o.Component.redis
+	locker := NewLocker(r, o.Stream, o.LeaderTTL)
+
+	for ctx.Err() == nil {
+		lk, err := locker.Lock(ctx, "leader")
+		if err != nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(o.Backoff):
+			}
+			continue
+		}
+
+		r.logf("outbox relay %s elected", o.Stream)
+		lk.KeepAlive()
+		err = o.lead(ctx, lk)
+		lk.Unlock(context.Background())
+
+		if err != nil && ctx.Err() == nil {
+			r.logf("outbox relay %s stepped down: %v", o.Stream, err)
+			time.Sleep(o.Backoff)
+		}
+	}
+}
+
+// lead relay events while lk is held
+func (o *OutboxRelay) lead(ctx context.Context, lk *Lock) error {
+	r := o.Component.redis
+
+	checkpoint, err := o.Checkpoint(ctx)
+	if err != nil {
+		return err
+	}
+
+	for {
+		if err := lk.Refresh(ctx); err != nil {
+			return err
+		}
+		// leadership is kept alive while paused by the kill switch
+		if err := r.waitResumed(ctx); err != nil {
+			return err
+		}
+
+		cmd := redis.NewXStreamSliceCmd("xread", "count", o.Batch, "block", outboxBlock.Milliseconds(), "streams", o.Stream, checkpoint)
+		err := r.ProcessContext(ctx, cmd)
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, stream := range cmd.Val() {
+			for _, msg := range stream.Messages {
+				if err := o.relay(ctx, msg); err != nil {
+					return err
+				}
+
+				// checkpoint after delivery, the event is relayed again by the next leader when it is lost
+				checkpoint = msg.ID
+				if err := r.DoContext(ctx, "set", o.Stream+":checkpoint", checkpoint).Err(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// relay dispatch msg with retries, move it to the DLQ when attempts are exhausted
+func (o *OutboxRelay) relay(ctx context.Context, msg redis.XMessage) error {
+	event := OutboxEvent{
+		ID:      msg.ID,
+		Type:    fmt.Sprint(msg.Values["type"]),
+		Payload: []byte(fmt.Sprint(msg.Values["payload"])),
+	}
+	if at, ok := msg.Values["at"]; ok {
+		var ms int64
+		fmt.Sscan(fmt.Sprint(at), &ms)
+		event.At = time.Unix(0, ms*int64(time.Millisecond))
+	}
+
+	o.mu.RLock()
+	publishers := append(append([]Publisher(nil), o.publishers[event.Type]...), o.publishers["*"]...)
+	o.mu.RUnlock()
+
+	var err error
+	for attempt := 0; attempt < o.MaxAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(o.Backoff << uint(attempt-1)):
+			}
+		}
+
+		if err = publish(ctx, publishers, event); err == nil {
+			return nil
+		}
+	}
+
+	o.Component.redis.logf("outbox relay %s event %s moved to dlq after %d attempts: %v", o.Stream, event.ID, o.MaxAttempts, err)
+
+	return o.Component.redis.DoContext(ctx, "xadd", o.Stream+":dlq", "*",
+		"id", event.ID,
+		"type", event.Type,
+		"payload", event.Payload,
+		"at", unixMilli(event.At),
+		"error", err.Error(),
+	).Err()
+}
+
+func publish(ctx context.Context, publishers []Publisher, event OutboxEvent) error {
+	for _, p := range publishers {
+		if err := p.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
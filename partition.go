@@ -0,0 +1,231 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type (
+	// PartitionedConsumer consume partitioned streams <stream>:{0..partitions-1} as a box. Live members heartbeat
+	// into a membership registry in redis, partitions are spread over the sorted members and reassigned when members
+	// join or leave. Each partition is read by its consumer group, pending messages of a partition handed over are
+	// reclaimed by the new owner after minIdle, so messages are handled at least once.
+	PartitionedConsumer struct {
+		Stream        string        `config:"stream" help:"Stream prefix of partitions, default is <name>"`
+		Partitions    int           `config:"partitions" default:"16" help:"Number of partitions, never change it while messages are pending, default is 16"`
+		Group         string        `config:"group" help:"Consumer group, default is <name>"`
+		Consumer      string        `config:"consumer" help:"Member name, default is <hostname>-<pid>"`
+		Batch         int           `config:"batch" default:"10" help:"Messages read per batch, default is 10"`
+		MinIdle       time.Duration `config:"minIdle" default:"30s" help:"Pending messages idle longer are redelivered, default is 30s"`
+		MaxDeliveries int64         `config:"maxDeliveries" default:"5" help:"Deliveries of a message before it is moved to the dead-letter stream <partition>:dlq, default is 5"`
+		MemberTTL     time.Duration `config:"memberTTL" default:"15s" help:"Members not heartbeating for it leave the group, heartbeats and rebalances every ttl/3, default is 15s"`
+
+		Component
+		handler  StreamHandler
+		presence *Presence
+		mu       sync.Mutex
+		assigned map[int]context.CancelFunc
+		done     chan struct{}
+		wg       sync.WaitGroup
+	}
+)
+
+// NewPartitionedConsumer new a partitioned stream consumer box named name, messages are handled by handler
+func NewPartitionedConsumer(name string, r *Redis, handler StreamHandler) *PartitionedConsumer {
+	return &PartitionedConsumer{
+		Component: NewComponent(name, r),
+		handler:   handler,
+		assigned:  make(map[int]context.CancelFunc),
+	}
+}
+
+// PartitionOf partition of key in partitions, for producers appending to <stream>:{partition}
+func PartitionOf(key string, partitions int) int {
+	return int(crc32.ChecksumIEEE([]byte(key)) % uint32(partitions))
+}
+
+// PartitionStream stream of partition p
+func PartitionStream(stream string, p int) string {
+	return fmt.Sprintf("%s:{%d}", stream, p)
+}
+
+// ConfigWillLoad config will load
+func (c *PartitionedConsumer) ConfigWillLoad(context.Context) {
+
+}
+
+// ConfigDidLoad set defaults
+func (c *PartitionedConsumer) ConfigDidLoad(context.Context) {
+	if c.Stream == "" {
+		c.Stream = c.name
+	}
+	if c.Partitions <= 0 {
+		c.Partitions = 16
+	}
+	if c.Group == "" {
+		c.Group = c.name
+	}
+	if c.Consumer == "" {
+		c.Consumer = hostname + "-" + strconv.Itoa(os.Getpid())
+	}
+	if c.MemberTTL <= 0 {
+		c.MemberTTL = 15 * time.Second
+	}
+
+	c.presence = NewPresence(c.Component.redis, c.Stream+":members:"+c.Group)
+}
+
+// Serve join the group and consume assigned partitions
+func (c *PartitionedConsumer) Serve(context.Context) error {
+	if c.handler == nil {
+		return ErrNoHandler
+	}
+
+	reader := newGroupReader(c.Component.redis, c.Group, c.Consumer, c.Batch, c.MinIdle, c.MaxDeliveries, c.handler,
+		func(stream string) string { return stream + ":dlq" })
+
+	c.done = make(chan struct{})
+
+	c.wg.Add(1)
+	go c.rebalance(c.done, reader)
+
+	return nil
+}
+
+// Shutdown leave the group, partitions are taken over by other members on their next rebalance
+func (c *PartitionedConsumer) Shutdown(ctx context.Context) error {
+	if c.done != nil {
+		close(c.done)
+		c.done = nil
+	}
+
+	c.wg.Wait()
+
+	return c.presence.Offline(ctx, c.Consumer)
+}
+
+// Assigned partitions consumed by this member
+func (c *PartitionedConsumer) Assigned() []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	partitions := make([]int, 0, len(c.assigned))
+	for p := range c.assigned {
+		partitions = append(partitions, p)
+	}
+	sort.Ints(partitions)
+
+	return partitions
+}
+
+// DeadLetters oldest count dead letters of partition p
+func (c *PartitionedConsumer) DeadLetters(ctx context.Context, p int, count int64) ([]DeadLetter, error) {
+	return readDeadLetters(ctx, c.Component.redis, PartitionStream(c.Stream, p)+":dlq", count)
+}
+
+// Replay append dead letters ids of partition p to the partition as new messages
+func (c *PartitionedConsumer) Replay(ctx context.Context, p int, ids ...string) error {
+	return replayDeadLetters(ctx, c.Component.redis, PartitionStream(c.Stream, p)+":dlq", ids...)
+}
+
+// rebalance heartbeat and reassign partitions every memberTTL/3 until done
+func (c *PartitionedConsumer) rebalance(done <-chan struct{}, reader *groupReader) {
+	defer c.wg.Done()
+
+	var consumers sync.WaitGroup
+	defer func() {
+		c.mu.Lock()
+		for p, cancel := range c.assigned {
+			cancel()
+			delete(c.assigned, p)
+		}
+		c.mu.Unlock()
+
+		consumers.Wait()
+	}()
+
+	interval := c.MemberTTL / 3
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		partitions, err := c.assignment(ctx)
+		cancel()
+
+		if err != nil {
+			c.Component.redis.logf("stream %s group %s rebalance: %v", c.Stream, c.Group, err)
+		} else {
+			c.assign(partitions, reader, &consumers)
+		}
+
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// assignment heartbeat and compute partitions of this member: partition p belongs to the p%n-th of n sorted live members
+func (c *PartitionedConsumer) assignment(ctx context.Context) (map[int]bool, error) {
+	if err := c.presence.Heartbeat(ctx, c.Consumer, c.MemberTTL); err != nil {
+		return nil, err
+	}
+
+	members, err := c.presence.Online(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(members)
+
+	index := sort.SearchStrings(members, c.Consumer)
+	if index == len(members) || members[index] != c.Consumer {
+		return nil, fmt.Errorf("redis: member %s is not online", c.Consumer)
+	}
+
+	partitions := make(map[int]bool)
+	for p := index; p < c.Partitions; p += len(members) {
+		partitions[p] = true
+	}
+
+	return partitions, nil
+}
+
+// assign start consuming added partitions and stop revoked ones
+func (c *PartitionedConsumer) assign(partitions map[int]bool, reader *groupReader, consumers *sync.WaitGroup) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for p, cancel := range c.assigned {
+		if !partitions[p] {
+			cancel()
+			delete(c.assigned, p)
+			c.Component.redis.logf("stream %s group %s partition %d revoked from %s", c.Stream, c.Group, p, c.Consumer)
+		}
+	}
+
+	for p := range partitions {
+		if _, ok := c.assigned[p]; ok {
+			continue
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		c.assigned[p] = cancel
+
+		consumers.Add(1)
+		go func(stream string) {
+			defer consumers.Done()
+			reader.run(ctx, stream)
+		}(PartitionStream(c.Stream, p))
+
+		c.Component.redis.logf("stream %s group %s partition %d assigned to %s", c.Stream, c.Group, p, c.Consumer)
+	}
+}
@@ -0,0 +1,122 @@
+package redis
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type (
+	// Part node of the instance subsystem tree, for application inspectors
+	Part struct {
+		Name      string   `json:"name"`
+		Kind      string   `json:"kind"`
+		Healthy   bool     `json:"healthy"`
+		Detail    string   `json:"detail,omitempty"`
+		DependsOn []string `json:"dependsOn,omitempty"`
+		Parts     []Part   `json:"parts,omitempty"`
+	}
+)
+
+// Part kinds
+const (
+	PartInstance  = "instance"
+	PartClient    = "client"
+	PartCollector = "collector"
+	PartLoop      = "loop"
+	PartComponent = "component"
+)
+
+// Parts subsystem tree of the instance: clients, collectors, background loops and component boxes.
+// Health is derived from state known by the box, no command is sent.
+func (r *Redis) Parts() Part {
+	ready := r.Ready()
+	client := r.Instance() + "/client"
+
+	root := Part{
+		Name:    r.Instance(),
+		Kind:    PartInstance,
+		Healthy: ready,
+	}
+
+	root.Parts = append(root.Parts, Part{
+		Name:    client,
+		Kind:    PartClient,
+		Healthy: ready,
+		Detail:  fmt.Sprintf("address=%s db=%d masterName=%s", strings.Join(r.Address, ","), r.DB, r.MasterName),
+	})
+
+	for i := range r.replicas {
+		root.Parts = append(root.Parts, Part{
+			Name:    fmt.Sprintf("%s/replica/%d", r.Instance(), i),
+			Kind:    PartClient,
+			Healthy: ready,
+		})
+	}
+
+	if r.summary != nil {
+		root.Parts = append(root.Parts, Part{
+			Name:      r.Instance() + "/metrics",
+			Kind:      PartCollector,
+			Healthy:   true,
+			DependsOn: []string{client},
+		})
+	}
+
+	loops := []struct {
+		name    string
+		enabled bool
+	}{
+		{"rollout", r.RolloutKey != ""},
+		{"toggles", r.ControlKey != ""},
+		{"coldstart", r.ColdStartKey != "" || r.ColdStartUptime > 0},
+		{"keyspace", r.Metrics && len(r.KeyspacePrefixes) > 0},
+		{"analyze", r.Metrics && r.AnalyzeInterval > 0},
+		{"serverinfo", r.Metrics && r.ServerInfoInterval > 0},
+		{"topology", r.TopologyEvents},
+	}
+	for _, loop := range loops {
+		if !loop.enabled {
+			continue
+		}
+
+		root.Parts = append(root.Parts, Part{
+			Name:      r.Instance() + "/" + loop.name,
+			Kind:      PartLoop,
+			Healthy:   ready,
+			DependsOn: []string{client},
+		})
+	}
+
+	if r.KillSwitchKey != "" {
+		killSwitch := Part{
+			Name:      r.Instance() + "/killswitch",
+			Kind:      PartLoop,
+			Healthy:   !r.Paused(),
+			DependsOn: []string{client},
+		}
+		if r.Paused() {
+			killSwitch.Detail = "background subsystems paused: " + r.PauseReason()
+		}
+		root.Parts = append(root.Parts, killSwitch)
+	}
+
+	r.componentsMu.Lock()
+	components := append([]string(nil), r.components...)
+	r.componentsMu.Unlock()
+
+	for _, name := range components {
+		root.Parts = append(root.Parts, Part{
+			Name:      name,
+			Kind:      PartComponent,
+			Healthy:   ready,
+			DependsOn: []string{client},
+		})
+	}
+
+	return root
+}
+
+func (r *Redis) handleParts(w http.ResponseWriter, req *http.Request) {
+	writeJSON(w, r.Parts())
+}
@@ -0,0 +1,86 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	// Presence online status of members, e.g. devices, users or workers, kept in a sorted set scored by heartbeat expiration.
+	// Expired members are hidden from queries and removed lazily by heartbeats.
+	Presence struct {
+		redis *Redis
+		key   string
+	}
+)
+
+// NewPresence new a presence tracker of the sorted set key
+func NewPresence(r *Redis, key string) *Presence {
+	return &Presence{
+		redis: r,
+		key:   key,
+	}
+}
+
+// Heartbeat mark member online for ttl
+func (p *Presence) Heartbeat(ctx context.Context, member string, ttl time.Duration) error {
+	now := time.Now()
+
+	_, err := p.redis.Pipelined(func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(p.key, &redis.Z{Score: float64(unixMilli(now.Add(ttl))), Member: member})
+		pipe.ZRemRangeByScore(p.key, "-inf", "("+strconv.FormatInt(unixMilli(now), 10))
+		return nil
+	})
+
+	return err
+}
+
+// Offline mark members offline
+func (p *Presence) Offline(ctx context.Context, members ...string) error {
+	if len(members) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, 0, len(members)+2)
+	args = append(args, "zrem", p.key)
+	for _, member := range members {
+		args = append(args, member)
+	}
+
+	return p.redis.DoContext(ctx, args...).Err()
+}
+
+// Online members
+func (p *Presence) Online(ctx context.Context) ([]string, error) {
+	cmd := redis.NewStringSliceCmd("zrangebyscore", p.key, p.now(), "+inf")
+	if err := p.redis.ProcessContext(ctx, cmd); err != nil {
+		return nil, err
+	}
+
+	return cmd.Val(), nil
+}
+
+// Count online members
+func (p *Presence) Count(ctx context.Context) (int64, error) {
+	return p.redis.DoContext(ctx, "zcount", p.key, p.now(), "+inf").Int64()
+}
+
+// IsOnline member has heartbeat not expired
+func (p *Presence) IsOnline(ctx context.Context, member string) (bool, error) {
+	expires, err := p.redis.DoContext(ctx, "zscore", p.key, member).Float64()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return int64(expires) >= unixMilli(time.Now()), nil
+}
+
+func (p *Presence) now() string {
+	return strconv.FormatInt(unixMilli(time.Now()), 10)
+}
@@ -0,0 +1,193 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	// PriorityQueue items popped by the highest priority then the earliest enqueued, kept in a zset of ids and a payload hash in one slot.
+	// With aging, waiting items gain priority over time so low priorities are not starved by a steady flow of high priorities.
+	PriorityQueue struct {
+		redis *Redis
+		name  string
+		opt   PriorityQueueOptions
+	}
+
+	// PriorityQueueOptions options of PriorityQueue
+	PriorityQueueOptions struct {
+		AgingRate float64       // priority gained per second waited, default is 0 (strict priority)
+		MinPoll   time.Duration // poll interval of Dequeue after an item, doubled while empty, default is 10ms
+		MaxPoll   time.Duration // max poll interval of Dequeue, default is 1s
+	}
+
+	// PriorityItem a dequeued item
+	PriorityItem struct {
+		ID      string
+		Payload []byte
+
+		trace string
+	}
+)
+
+// PriorityMax priorities are clamped to 0-PriorityMax
+const PriorityMax = 4095
+
+// priorityEpoch enqueue ms since it fit in 41 bits until 2089
+var priorityEpoch = unixMilli(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+
+var (
+	// ErrQueueEmpty no item is enqueued
+	ErrQueueEmpty = errors.New("redis: queue is empty")
+
+	// KEYS[1]: zset, KEYS[2]: payloads, KEYS[3]: traces. ARGV[1]: id, ARGV[2]: payload, ARGV[3]: score, ARGV[4]: trace
+	priorityEnqueueScript = newScript(`
+redis.call('zadd', KEYS[1], ARGV[3], ARGV[1])
+redis.call('hset', KEYS[2], ARGV[1], ARGV[2])
+if ARGV[4] ~= '' then
+	redis.call('hset', KEYS[3], ARGV[1], ARGV[4])
+end
+return 1
+`)
+
+	// KEYS[1]: zset, KEYS[2]: payloads, KEYS[3]: traces. Returns {id, payload, trace} of the lowest score or nil
+	priorityPopScript = newScript(`
+local ids = redis.call('zrange', KEYS[1], 0, 0)
+if #ids == 0 then
+	return false
+end
+redis.call('zrem', KEYS[1], ids[1])
+local payload = redis.call('hget', KEYS[2], ids[1])
+local trace = redis.call('hget', KEYS[3], ids[1])
+redis.call('hdel', KEYS[2], ids[1])
+redis.call('hdel', KEYS[3], ids[1])
+return {ids[1], payload or '', trace or ''}
+`)
+)
+
+// NewPriorityQueue new a priority queue, keys are prefixed by name
+func NewPriorityQueue(r *Redis, name string, opts ...PriorityQueueOptions) *PriorityQueue {
+	opt := PriorityQueueOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.MinPoll <= 0 {
+		opt.MinPoll = 10 * time.Millisecond
+	}
+	if opt.MaxPoll <= 0 {
+		opt.MaxPoll = time.Second
+	}
+	if opt.MaxPoll < opt.MinPoll {
+		opt.MaxPoll = opt.MinPoll
+	}
+
+	return &PriorityQueue{
+		redis: r,
+		name:  name,
+		opt:   opt,
+	}
+}
+
+// Enqueue payload with priority, the higher is popped first. The trace context of ctx is kept with the item
+// when a message tracer is set.
+func (q *PriorityQueue) Enqueue(ctx context.Context, payload []byte, priority int) (string, error) {
+	id := randomID()
+	now := time.Now()
+
+	err := priorityEnqueueScript.run(ctx, q.redis, q.keys(), id, payload, q.score(priority, now), q.redis.encodeTrace(ctx, now)).Err()
+
+	return id, err
+}
+
+// TryDequeue pop the item of the highest priority, ErrQueueEmpty is returned when empty
+func (q *PriorityQueue) TryDequeue(ctx context.Context) (PriorityItem, error) {
+	v, err := priorityPopScript.run(ctx, q.redis, q.keys()).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return PriorityItem{}, ErrQueueEmpty
+		}
+		return PriorityItem{}, err
+	}
+
+	fields, _ := v.([]interface{})
+	if len(fields) < 2 {
+		return PriorityItem{}, ErrQueueEmpty
+	}
+
+	id, _ := fields[0].(string)
+	payload, _ := fields[1].(string)
+	item := PriorityItem{ID: id, Payload: []byte(payload)}
+	if len(fields) > 2 {
+		item.trace, _ = fields[2].(string)
+	}
+
+	return item, nil
+}
+
+// Dequeue pop the item of the highest priority, block until an item is enqueued or ctx is done.
+// The poll interval grows from minPoll to maxPoll while the queue is empty.
+func (q *PriorityQueue) Dequeue(ctx context.Context) (PriorityItem, error) {
+	poll := q.opt.MinPoll
+
+	for {
+		item, err := q.TryDequeue(ctx)
+		if err != ErrQueueEmpty {
+			return item, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return PriorityItem{}, ctx.Err()
+		case <-time.After(poll):
+		}
+
+		if poll *= 2; poll > q.opt.MaxPoll {
+			poll = q.opt.MaxPoll
+		}
+	}
+}
+
+// Process dequeue an item like Dequeue and handle it by handler, in a consumer span linked to the enqueue
+// when a message tracer is set. The error of handler is returned, the item is not enqueued again.
+func (q *PriorityQueue) Process(ctx context.Context, handler QueueHandler) error {
+	item, err := q.Dequeue(ctx)
+	if err != nil {
+		return err
+	}
+
+	carrier, ready := decodeTrace(item.trace)
+	ctx, end := q.redis.startConsumer(ctx, q.name, carrier, ready)
+	err = handler(ctx, item)
+	end(err)
+
+	return err
+}
+
+// Len number of enqueued items
+func (q *PriorityQueue) Len(ctx context.Context) (int64, error) {
+	return q.redis.DoContext(ctx, "zcard", q.keys()[0]).Int64()
+}
+
+// score the lowest is popped first. Strict priority: priority in the high bits, enqueue ms in the low 41 bits, exact in float64.
+// Aging: effective priority is priority + waited seconds * agingRate, the lowest enqueue seconds * agingRate - priority is the highest.
+func (q *PriorityQueue) score(priority int, at time.Time) float64 {
+	if priority < 0 {
+		priority = 0
+	}
+	if priority > PriorityMax {
+		priority = PriorityMax
+	}
+
+	if q.opt.AgingRate > 0 {
+		return float64(unixMilli(at))/1000*q.opt.AgingRate - float64(priority)
+	}
+
+	return float64(int64(PriorityMax-priority)<<41 | (unixMilli(at)-priorityEpoch)&(1<<41-1))
+}
+
+func (q *PriorityQueue) keys() []string {
+	return []string{"{" + q.name + "}:queue", "{" + q.name + "}:payloads", "{" + q.name + "}:traces"}
+}
@@ -0,0 +1,40 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	rawKey struct{}
+)
+
+// RawGet get bytes of key for ultra-hot paths, redis.Nil is returned when key is missed.
+// It is sent to the master as is: no key prefix, codec, compression or encryption, no schema validation,
+// no read splitting or fallback, and latency is not sampled (slow log, SLO, snapshot), only the command total is counted.
+// Timeouts and chaos still apply. Prefer Cache unless profiling shows the helper is the bottleneck.
+func (r *Redis) RawGet(ctx context.Context, key string) ([]byte, error) {
+	cmd := redis.NewStringCmd("get", key)
+	if err := r.UniversalClient.ProcessContext(rawContext(ctx), cmd); err != nil {
+		return nil, err
+	}
+
+	return cmd.Bytes()
+}
+
+// RawSet set bytes of key with optional ttl for ultra-hot paths, with the trade-offs of RawGet.
+// Values are not validated by registered schemas, and not readable by Cache when its key is processed by a namespace policy.
+func (r *Redis) RawSet(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return r.UniversalClient.ProcessContext(rawContext(ctx), redis.NewStatusCmd(setArgs(key, value, ttl)...))
+}
+
+func rawContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, rawKey{}, true)
+}
+
+// isRaw command of ctx is sent by the raw API, hooks skip their overhead
+func isRaw(ctx context.Context) bool {
+	return ctx.Value(rawKey{}) != nil
+}
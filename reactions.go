@@ -0,0 +1,142 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	// Reactions votes/reactions counter, each user has at most one reaction per item.
+	Reactions struct {
+		redis  *Redis
+		prefix string
+	}
+)
+
+var (
+	// KEYS: users hash, counts hash. ARGV[1]: user, ARGV[2]: reaction, empty for removal.
+	// returns {changed, reaction1, count1, reaction2, count2...}
+	reactionsReactScript = newScript(`
+local old = redis.call('hget', KEYS[1], ARGV[1])
+local changed = 0
+if old ~= (ARGV[2] ~= '' and ARGV[2] or false) then
+	changed = 1
+	if old then
+		if redis.call('hincrby', KEYS[2], old, -1) <= 0 then
+			redis.call('hdel', KEYS[2], old)
+		end
+	end
+	if ARGV[2] == '' then
+		redis.call('hdel', KEYS[1], ARGV[1])
+	else
+		redis.call('hset', KEYS[1], ARGV[1], ARGV[2])
+		redis.call('hincrby', KEYS[2], ARGV[2], 1)
+	end
+end
+local result = redis.call('hgetall', KEYS[2])
+table.insert(result, 1, changed)
+return result
+`)
+)
+
+// NewReactions new a reactions helper, keys are prefixed by prefix.
+func NewReactions(r *Redis, prefix string) *Reactions {
+	return &Reactions{
+		redis:  r,
+		prefix: prefix,
+	}
+}
+
+// React record user's reaction on item, a previous different reaction of the user is replaced.
+// changed is false when the user has already given the same reaction.
+func (re *Reactions) React(ctx context.Context, item, user, reaction string) (changed bool, tallies map[string]int64, err error) {
+	if reaction == "" {
+		return false, nil, fmt.Errorf("redis: reaction is required")
+	}
+
+	return re.react(ctx, item, user, reaction)
+}
+
+// Remove user's reaction on item.
+func (re *Reactions) Remove(ctx context.Context, item, user string) (changed bool, tallies map[string]int64, err error) {
+	return re.react(ctx, item, user, "")
+}
+
+// Reaction of user on item, empty when user has not reacted.
+func (re *Reactions) Reaction(ctx context.Context, item, user string) (string, error) {
+	cmd := re.redis.DoContext(ctx, "hget", re.usersKey(item), user)
+	if cmd.Err() == redis.Nil {
+		return "", nil
+	}
+
+	return cmd.Text()
+}
+
+// Tallies count of each reaction on item.
+func (re *Reactions) Tallies(ctx context.Context, item string) (map[string]int64, error) {
+	val, err := re.redis.DoContext(ctx, "hgetall", re.countsKey(item)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	values, _ := val.([]interface{})
+
+	return parseCounts(values)
+}
+
+func (re *Reactions) react(ctx context.Context, item, user, reaction string) (bool, map[string]int64, error) {
+	val, err := reactionsReactScript.run(ctx, re.redis, []string{re.usersKey(item), re.countsKey(item)}, user, reaction).Result()
+	if err != nil {
+		return false, nil, err
+	}
+
+	values, ok := val.([]interface{})
+	if !ok || len(values) == 0 {
+		return false, nil, fmt.Errorf("redis: unexpected reactions reply %v", val)
+	}
+
+	tallies, err := parseCounts(values[1:])
+	if err != nil {
+		return false, nil, err
+	}
+
+	changed, _ := values[0].(int64)
+
+	return changed == 1, tallies, nil
+}
+
+// keys use hash tag so that keys of an item are in the same cluster slot.
+func (re *Reactions) usersKey(item string) string {
+	return re.prefix + ":{" + item + "}:users"
+}
+
+func (re *Reactions) countsKey(item string) string {
+	return re.prefix + ":{" + item + "}:counts"
+}
+
+// parseCounts parse flat field/value reply into map
+func parseCounts(values []interface{}) (map[string]int64, error) {
+	counts := make(map[string]int64, len(values)/2)
+
+	for i := 0; i+1 < len(values); i += 2 {
+		field := fmt.Sprint(values[i])
+
+		switch v := values[i+1].(type) {
+		case int64:
+			counts[field] = v
+		case string:
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			counts[field] = n
+		default:
+			return nil, fmt.Errorf("redis: unexpected count %v", v)
+		}
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,282 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type (
+	// ReadRepair keep a mirror converged with its primary during migrations.
+	// Sampled reads compare decoded values of both sides (strings, hashes, lists, sets and sorted sets),
+	// the primary's value is rewritten to the lagging mirror on divergence unless the mirror changed meanwhile,
+	// so both sides may run different redis versions.
+	ReadRepair struct {
+		primary    *Redis
+		mirror     *Redis
+		sampleRate float64
+		compared   int64
+		diverged   int64
+		repaired   int64
+		counter    *prometheus.CounterVec
+	}
+
+	// repairValue decoded value of a key, elements are canonically ordered for comparison
+	repairValue struct {
+		kind     string
+		ttl      int64
+		elements []string
+		dump     string // DUMP of the side it is read from, the compare-and-set token of repairs
+	}
+
+	// ReadRepairStats counters of read repair
+	ReadRepairStats struct {
+		Compared int64
+		Diverged int64
+		Repaired int64
+	}
+)
+
+var (
+	// KEYS: key. Returns type, pttl, elements and DUMP of key
+	readRepairReadScript = newScript(`
+local t = redis.call('type', KEYS[1]).ok
+local v
+if t == 'string' then
+	v = {redis.call('get', KEYS[1])}
+elseif t == 'hash' then
+	v = redis.call('hgetall', KEYS[1])
+elseif t == 'list' then
+	v = redis.call('lrange', KEYS[1], 0, -1)
+elseif t == 'set' then
+	v = redis.call('smembers', KEYS[1])
+elseif t == 'zset' then
+	v = redis.call('zrange', KEYS[1], 0, -1, 'withscores')
+elseif t == 'none' then
+	v = {}
+else
+	return redis.error_reply('read repair does not support type ' .. t)
+end
+return {t, redis.call('pttl', KEYS[1]), v, redis.call('dump', KEYS[1]) or ''}
+`)
+
+	// KEYS: key. ARGV[1]: expected DUMP of key ('' when missing), ARGV[2]: type, ARGV[3]: pttl, ARGV[4...]: elements.
+	// Returns 0 when key changed since it was compared.
+	readRepairWriteScript = newScript(`
+if (redis.call('dump', KEYS[1]) or '') ~= ARGV[1] then
+	return 0
+end
+redis.call('del', KEYS[1])
+local t = ARGV[2]
+if t == 'string' then
+	redis.call('set', KEYS[1], ARGV[4])
+elseif t == 'hash' then
+	for i = 4, #ARGV, 2 do
+		redis.call('hset', KEYS[1], ARGV[i], ARGV[i + 1])
+	end
+elseif t == 'list' then
+	for i = 4, #ARGV do
+		redis.call('rpush', KEYS[1], ARGV[i])
+	end
+elseif t == 'set' then
+	for i = 4, #ARGV do
+		redis.call('sadd', KEYS[1], ARGV[i])
+	end
+elseif t == 'zset' then
+	for i = 4, #ARGV, 2 do
+		redis.call('zadd', KEYS[1], ARGV[i + 1], ARGV[i])
+	end
+end
+if t ~= 'none' and tonumber(ARGV[3]) > 0 then
+	redis.call('pexpire', KEYS[1], ARGV[3])
+end
+return 1
+`)
+)
+
+// NewReadRepair new a read repairer comparing sampleRate (0-1) of reads.
+func NewReadRepair(primary, mirror *Redis, sampleRate float64) *ReadRepair {
+	rr := &ReadRepair{
+		primary:    primary,
+		mirror:     mirror,
+		sampleRate: sampleRate,
+	}
+
+	if primary.Metrics {
+		rr.counter = mustRegister(prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: primary.metrics.Namespace,
+				Subsystem: primary.metrics.Subsystem,
+				Name:      "redis_read_repair_total",
+				Help:      "redis read repair comparisons total",
+			},
+			[]string{"redis_instance", "mirror", "result"},
+		)).(*prometheus.CounterVec)
+	}
+
+	return rr
+}
+
+// Get value of key from primary, sampled keys are compared and repaired in background.
+func (rr *ReadRepair) Get(ctx context.Context, key string) (string, error) {
+	val, err := rr.primary.DoContext(ctx, "get", key).Text()
+	if err != nil && err != redis.Nil {
+		return "", err
+	}
+
+	if rand.Float64() < rr.sampleRate {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			rr.Compare(ctx, key)
+		}()
+	}
+
+	return val, err
+}
+
+// Compare key on both sides, rewrite the primary's value to the mirror when they diverge.
+func (rr *ReadRepair) Compare(ctx context.Context, key string) (diverged bool, err error) {
+	atomic.AddInt64(&rr.compared, 1)
+
+	want, err := readRepairValue(ctx, rr.primary, key)
+	if err != nil {
+		rr.count("failed")
+		return false, err
+	}
+
+	got, err := readRepairValue(ctx, rr.mirror, key)
+	if err != nil {
+		rr.count("failed")
+		return false, err
+	}
+
+	if want.equal(got) {
+		rr.count("converged")
+		return false, nil
+	}
+
+	atomic.AddInt64(&rr.diverged, 1)
+	rr.count("diverged")
+
+	repaired, err := rr.repair(ctx, key, want, got)
+	if err != nil {
+		rr.count("failed")
+		return true, err
+	}
+	if !repaired {
+		rr.count("changed")
+		return true, nil
+	}
+
+	atomic.AddInt64(&rr.repaired, 1)
+	rr.count("repaired")
+
+	return true, nil
+}
+
+// Stats counters since created
+func (rr *ReadRepair) Stats() ReadRepairStats {
+	return ReadRepairStats{
+		Compared: atomic.LoadInt64(&rr.compared),
+		Diverged: atomic.LoadInt64(&rr.diverged),
+		Repaired: atomic.LoadInt64(&rr.repaired),
+	}
+}
+
+// repair write want to the mirror unless the mirror changed since got was read
+func (rr *ReadRepair) repair(ctx context.Context, key string, want, got repairValue) (bool, error) {
+	args := make([]interface{}, 0, 3+len(want.elements))
+	args = append(args, got.dump, want.kind, want.ttl)
+	for _, element := range want.elements {
+		args = append(args, element)
+	}
+
+	repaired, err := readRepairWriteScript.run(ctx, rr.mirror, []string{key}, args...).Int64()
+
+	return repaired == 1, err
+}
+
+func (rr *ReadRepair) count(result string) {
+	if rr.counter != nil {
+		rr.counter.WithLabelValues(rr.primary.Instance(), rr.mirror.Instance(), result).Inc()
+	}
+}
+
+// readRepairValue decoded value of key, unordered elements of sets and hashes are sorted, scores are normalized
+func readRepairValue(ctx context.Context, r *Redis, key string) (repairValue, error) {
+	val, err := readRepairReadScript.run(ctx, r, []string{key}).Result()
+	if err != nil {
+		return repairValue{}, err
+	}
+
+	reply, _ := val.([]interface{})
+	if len(reply) != 4 {
+		return repairValue{}, fmt.Errorf("redis: unexpected read repair reply %v", val)
+	}
+
+	v := repairValue{kind: fmt.Sprint(reply[0]), dump: fmt.Sprint(reply[3])}
+	v.ttl, _ = reply[1].(int64)
+
+	items, _ := reply[2].([]interface{})
+	for _, item := range items {
+		v.elements = append(v.elements, fmt.Sprint(item))
+	}
+
+	switch v.kind {
+	case "set":
+		sort.Strings(v.elements)
+	case "hash":
+		sortPairs(v.elements)
+	case "zset":
+		// score formatting differs between redis versions
+		for i := 1; i < len(v.elements); i += 2 {
+			if score, err := strconv.ParseFloat(v.elements[i], 64); err == nil {
+				v.elements[i] = strconv.FormatFloat(score, 'g', -1, 64)
+			}
+		}
+	}
+
+	return v, nil
+}
+
+// equal values have the same type and elements, ttls are not compared
+func (v repairValue) equal(o repairValue) bool {
+	if v.kind != o.kind || len(v.elements) != len(o.elements) {
+		return false
+	}
+
+	for i := range v.elements {
+		if v.elements[i] != o.elements[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sortPairs sort flat field value pairs by field
+func sortPairs(pairs []string) {
+	type pair struct{ field, value string }
+
+	sorted := make([]pair, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		sorted = append(sorted, pair{pairs[i], pairs[i+1]})
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].field < sorted[j].field
+	})
+
+	for i, p := range sorted {
+		pairs[2*i], pairs[2*i+1] = p.field, p.value
+	}
+}
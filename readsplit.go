@@ -0,0 +1,120 @@
+package redis
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	forceMasterKey struct{}
+)
+
+var (
+	// readOnlyCommands are routed to replicas in read splitting mode
+	readOnlyCommands = map[string]bool{
+		"get": true, "mget": true, "getrange": true, "strlen": true, "exists": true,
+		"ttl": true, "pttl": true, "type": true,
+		"hget": true, "hmget": true, "hgetall": true, "hkeys": true, "hvals": true, "hlen": true, "hexists": true,
+		"lrange": true, "llen": true, "lindex": true,
+		"smembers": true, "sismember": true, "scard": true, "srandmember": true,
+		"zrange": true, "zrevrange": true, "zrangebyscore": true, "zrevrangebyscore": true,
+		"zscore": true, "zrank": true, "zrevrank": true, "zcard": true, "zcount": true,
+		"geopos": true, "geodist": true, "georadius_ro": true, "georadiusbymember_ro": true,
+	}
+)
+
+// ForceMaster context forcing reads to the master, for read-your-writes cases
+func ForceMaster(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceMasterKey{}, true)
+}
+
+// Reader client serving reads of ctx: a replica in read splitting mode, the master otherwise.
+// Reads of ctx of AfterWrite are served by a replica caught up with the writes, or by the master.
+func (r *Redis) Reader(ctx context.Context) redis.UniversalClient {
+	if len(r.replicas) == 0 || ctx.Value(forceMasterKey{}) != nil || !r.rolledOut(FeatureReadReplicas) {
+		return r.UniversalClient
+	}
+
+	i := int(atomic.AddUint32(&r.next, 1)) % len(r.replicas)
+
+	if replica, ok := r.consistentReplica(ctx, i); ok {
+		if replica == nil {
+			return r.UniversalClient
+		}
+		return replica
+	}
+
+	return r.replicas[i]
+}
+
+// DoContext route read-only commands to replicas in read splitting mode, retry them on the fallback on connection errors.
+// Concurrent identical reads of keys matching coalesce patterns share one round trip.
+// Only DoContext and ProcessContext are routed: typed commands like r.Get or r.ZRange carry no ctx and are sent
+// to the master, use r.Reader(ctx).ZRange for typed reads of replicas.
+func (r *Redis) DoContext(ctx context.Context, args ...interface{}) *redis.Cmd {
+	if len(args) > 0 && isReadOnly(args[0]) {
+		return r.coalesce(ctx, args, func() redis.Cmder {
+			cmd := r.Reader(ctx).DoContext(ctx, args...)
+			if r.shouldFallback(cmd.Err()) {
+				cmd = r.fallback.redis.DoContext(ctx, args...)
+				r.countFallback(cmd.Err())
+			}
+			return cmd
+		}, func(err error) redis.Cmder {
+			cmd := redis.NewCmd(args...)
+			cmd.SetErr(err)
+			return cmd
+		}).(*redis.Cmd)
+	}
+
+	return r.UniversalClient.DoContext(ctx, args...)
+}
+
+// ProcessContext route read-only commands to replicas in read splitting mode, retry them on the fallback on connection errors
+func (r *Redis) ProcessContext(ctx context.Context, cmd redis.Cmder) error {
+	if isReadOnly(cmd.Name()) {
+		err := r.Reader(ctx).ProcessContext(ctx, cmd)
+		if r.shouldFallback(err) {
+			err = r.fallback.redis.ProcessContext(ctx, cmd)
+			r.countFallback(err)
+		}
+		return err
+	}
+
+	return r.UniversalClient.ProcessContext(ctx, cmd)
+}
+
+// newReplicas replica clients for read splitting
+func (r *Redis) newReplicas() []redis.UniversalClient {
+	if !r.ReadReplicas {
+		return nil
+	}
+
+	if len(r.Address) > 1 && r.MasterName == "" {
+		opts := r.options()
+		opts.ReadOnly = true
+		opts.RouteByLatency = true
+
+		return []redis.UniversalClient{r.newClient(opts)}
+	}
+
+	replicas := make([]redis.UniversalClient, 0, len(r.ReplicaAddress))
+	for _, addr := range r.ReplicaAddress {
+		opts := r.options()
+		opts.MasterName = ""
+		opts.Addrs = []string{addr}
+
+		replicas = append(replicas, r.newClient(opts))
+	}
+
+	return replicas
+}
+
+func isReadOnly(name interface{}) bool {
+	s, ok := name.(string)
+
+	return ok && readOnlyCommands[strings.ToLower(s)]
+}
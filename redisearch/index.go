@@ -0,0 +1,273 @@
+// Package redisearch RediSearch index management and queries over hashes written by HSetStruct.
+// Index schema is declared by `search` struct tags, hash field names follow `redis` tags.
+//
+//	type Product struct {
+//		Title string   `redis:"title" search:"text,weight=2"`
+//		Color string   `redis:"color" search:"tag"`
+//		Price float64  `redis:"price" search:"numeric,sortable"`
+//	}
+//
+//	idx, _ := redisearch.NewIndex(redis.Default, "products", Product{}, "product:")
+//	idx.Ensure(ctx)
+//
+//	products := []Product{}
+//	res, err := idx.Search(redisearch.NewQuery().Match("title", "lamp").Range("price", 10, 100)).SortBy("price", true).Limit(0, 20).Do(ctx, &products)
+package redisearch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/boxgo/redis"
+)
+
+type (
+	// Index RediSearch index on hashes of key prefixes
+	Index struct {
+		redis    *redis.Redis
+		name     string
+		prefixes []string
+		fields   []Field
+	}
+
+	// Field indexed hash field
+	Field struct {
+		Name      string
+		Type      string
+		Weight    string
+		Separator string
+		Sortable  bool
+		NoIndex   bool
+	}
+)
+
+// Field types
+const (
+	Text    = "TEXT"
+	Tag     = "TAG"
+	Numeric = "NUMERIC"
+	Geo     = "GEO"
+)
+
+var (
+	// ErrNoFields struct has no `search` tagged fields
+	ErrNoFields = errors.New("redisearch: no search fields")
+)
+
+// NewIndex new index named name on hashes prefixed by prefixes, schema is declared by `search` tags of struct v:
+// `search:"text|tag|numeric|geo[,sortable][,noindex][,weight=n][,separator=c]"`.
+func NewIndex(r *redis.Redis, name string, v interface{}, prefixes ...string) (*Index, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, redis.ErrNotStruct
+	}
+
+	fields, err := schema(t)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Index{
+		redis:    r,
+		name:     name,
+		prefixes: prefixes,
+		fields:   fields,
+	}, nil
+}
+
+// Name index name
+func (idx *Index) Name() string {
+	return idx.name
+}
+
+// Fields index schema
+func (idx *Index) Fields() []Field {
+	return idx.fields
+}
+
+// Ensure create the index when it does not exist, or add fields missing from it, call it at startup.
+// Changed options of existing fields are not applied, recreate the index for them.
+func (idx *Index) Ensure(ctx context.Context) error {
+	existing, err := idx.info(ctx)
+	if err != nil {
+		return err
+	}
+
+	if existing == nil {
+		args := []interface{}{"ft.create", idx.name, "on", "hash"}
+		if len(idx.prefixes) > 0 {
+			args = append(args, "prefix", len(idx.prefixes))
+			for _, prefix := range idx.prefixes {
+				args = append(args, prefix)
+			}
+		}
+		args = append(args, "schema")
+		for _, f := range idx.fields {
+			args = append(args, f.args()...)
+		}
+
+		return idx.redis.DoContext(ctx, args...).Err()
+	}
+
+	for _, f := range idx.fields {
+		if existing[f.Name] {
+			continue
+		}
+
+		args := append([]interface{}{"ft.alter", idx.name, "schema", "add"}, f.args()...)
+		if err := idx.redis.DoContext(ctx, args...).Err(); err != nil {
+			return fmt.Errorf("redisearch: add field %s to %s: %w", f.Name, idx.name, err)
+		}
+	}
+
+	return nil
+}
+
+// Drop drop the index, documents are kept
+func (idx *Index) Drop(ctx context.Context) error {
+	return idx.redis.DoContext(ctx, "ft.dropindex", idx.name).Err()
+}
+
+// info names of indexed fields, nil when the index does not exist
+func (idx *Index) info(ctx context.Context) (map[string]bool, error) {
+	reply, err := slice(idx.redis.DoContext(ctx, "ft.info", idx.name).Result())
+	if err != nil {
+		msg := strings.ToLower(err.Error())
+		if strings.Contains(msg, "unknown index") || strings.Contains(msg, "no such index") {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	names := map[string]bool{}
+	for i := 0; i+1 < len(reply); i += 2 {
+		key := str(reply[i])
+		if key != "attributes" && key != "fields" {
+			continue
+		}
+
+		attrs, _ := reply[i+1].([]interface{})
+		for _, attr := range attrs {
+			values, _ := attr.([]interface{})
+			if len(values) == 0 {
+				continue
+			}
+
+			// RediSearch 1.x: [name, type, TEXT, ...], 2.x: [identifier, name, attribute, alias, ...]
+			if key == "fields" {
+				names[str(values[0])] = true
+				continue
+			}
+			for j := 0; j+1 < len(values); j += 2 {
+				if str(values[j]) == "identifier" {
+					names[str(values[j+1])] = true
+				}
+			}
+		}
+	}
+
+	return names, nil
+}
+
+func (f Field) args() []interface{} {
+	args := []interface{}{f.Name, f.Type}
+	if f.Weight != "" {
+		args = append(args, "weight", f.Weight)
+	}
+	if f.Separator != "" {
+		args = append(args, "separator", f.Separator)
+	}
+	if f.Sortable {
+		args = append(args, "sortable")
+	}
+	if f.NoIndex {
+		args = append(args, "noindex")
+	}
+
+	return args
+}
+
+func schema(t reflect.Type) ([]Field, error) {
+	fields := make([]Field, 0, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("search")
+		if sf.PkgPath != "" || tag == "" || tag == "-" {
+			continue
+		}
+
+		name := strings.Split(sf.Tag.Get("redis"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+
+		parts := strings.Split(tag, ",")
+		f := Field{Name: name, Type: strings.ToUpper(parts[0])}
+
+		switch f.Type {
+		case Text, Tag, Numeric, Geo:
+		default:
+			return nil, fmt.Errorf("redisearch: field %s: unknown type %s", sf.Name, parts[0])
+		}
+
+		for _, opt := range parts[1:] {
+			switch {
+			case opt == "sortable":
+				f.Sortable = true
+			case opt == "noindex":
+				f.NoIndex = true
+			case strings.HasPrefix(opt, "weight="):
+				f.Weight = strings.TrimPrefix(opt, "weight=")
+			case strings.HasPrefix(opt, "separator="):
+				f.Separator = strings.TrimPrefix(opt, "separator=")
+			default:
+				return nil, fmt.Errorf("redisearch: field %s: unknown option %s", sf.Name, opt)
+			}
+		}
+
+		fields = append(fields, f)
+	}
+
+	if len(fields) == 0 {
+		return nil, ErrNoFields
+	}
+
+	return fields, nil
+}
+
+func str(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case []byte:
+		return string(s)
+	case nil:
+		return ""
+	}
+
+	return fmt.Sprint(v)
+}
+
+// slice array reply of a command
+func slice(v interface{}, err error) ([]interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	values, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("redisearch: unexpected reply %T", v)
+	}
+
+	return values, nil
+}
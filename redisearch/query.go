@@ -0,0 +1,333 @@
+package redisearch
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/boxgo/redis"
+)
+
+type (
+	// Query fluent builder of the query string, clauses are intersected
+	Query struct {
+		clauses []string
+	}
+
+	// SearchRequest FT.SEARCH of an index
+	SearchRequest struct {
+		idx    *Index
+		query  string
+		sortBy string
+		asc    bool
+		offset int
+		limit  int
+		fields []string
+	}
+
+	// SearchResult total matched documents and keys of returned documents in order
+	SearchResult struct {
+		Total int64
+		Keys  []string
+	}
+
+	// AggregateRequest FT.AGGREGATE of an index
+	AggregateRequest struct {
+		idx   *Index
+		query string
+		steps []interface{}
+	}
+
+	// Reducer reducer of a GROUPBY step, e.g. Reducer{Func: "sum", Args: []string{"@price"}, As: "total"}
+	Reducer struct {
+		Func string
+		Args []string
+		As   string
+	}
+)
+
+// NewQuery new query, an empty query matches all documents
+func NewQuery() *Query {
+	return &Query{}
+}
+
+// Match full-text terms in text field, empty field matches all text fields
+func (q *Query) Match(field, terms string) *Query {
+	if field == "" {
+		return q.Raw("(" + terms + ")")
+	}
+
+	return q.Raw(fmt.Sprintf("@%s:(%s)", field, terms))
+}
+
+// Tag tag field is any of values
+func (q *Query) Tag(field string, values ...string) *Query {
+	escaped := make([]string, len(values))
+	for i, v := range values {
+		escaped[i] = escapeTag(v)
+	}
+
+	return q.Raw(fmt.Sprintf("@%s:{%s}", field, strings.Join(escaped, " | ")))
+}
+
+// Range numeric field in [min, max]
+func (q *Query) Range(field string, min, max float64) *Query {
+	return q.Raw(fmt.Sprintf("@%s:[%s %s]", field, formatFloat(min), formatFloat(max)))
+}
+
+// Near geo field within radius of unit (m, km, mi, ft) from lon, lat
+func (q *Query) Near(field string, lon, lat, radius float64, unit string) *Query {
+	return q.Raw(fmt.Sprintf("@%s:[%s %s %s %s]", field, formatFloat(lon), formatFloat(lat), formatFloat(radius), unit))
+}
+
+// Not exclude documents matching sub
+func (q *Query) Not(sub *Query) *Query {
+	return q.Raw("-(" + sub.String() + ")")
+}
+
+// Or documents matching any of subs
+func (q *Query) Or(subs ...*Query) *Query {
+	parts := make([]string, len(subs))
+	for i, sub := range subs {
+		parts[i] = "(" + sub.String() + ")"
+	}
+
+	return q.Raw("(" + strings.Join(parts, " | ") + ")")
+}
+
+// Raw add a clause in query syntax
+func (q *Query) Raw(clause string) *Query {
+	q.clauses = append(q.clauses, clause)
+
+	return q
+}
+
+// String query string
+func (q *Query) String() string {
+	if len(q.clauses) == 0 {
+		return "*"
+	}
+
+	return strings.Join(q.clauses, " ")
+}
+
+// Search search documents matching q
+func (idx *Index) Search(q *Query) *SearchRequest {
+	return &SearchRequest{idx: idx, query: q.String(), limit: 10}
+}
+
+// SortBy sort by a sortable field
+func (s *SearchRequest) SortBy(field string, asc bool) *SearchRequest {
+	s.sortBy, s.asc = field, asc
+
+	return s
+}
+
+// Limit return n documents from offset, default is 0, 10
+func (s *SearchRequest) Limit(offset, n int) *SearchRequest {
+	s.offset, s.limit = offset, n
+
+	return s
+}
+
+// Return return only these fields, default is all
+func (s *SearchRequest) Return(fields ...string) *SearchRequest {
+	s.fields = fields
+
+	return s
+}
+
+// Do run the search and decode documents into dest, a pointer to slice of structs or struct pointers.
+func (s *SearchRequest) Do(ctx context.Context, dest interface{}) (SearchResult, error) {
+	args := []interface{}{"ft.search", s.idx.name, s.query}
+	if len(s.fields) > 0 {
+		args = append(args, "return", len(s.fields))
+		for _, f := range s.fields {
+			args = append(args, f)
+		}
+	}
+	if s.sortBy != "" {
+		order := "desc"
+		if s.asc {
+			order = "asc"
+		}
+		args = append(args, "sortby", s.sortBy, order)
+	}
+	args = append(args, "limit", s.offset, s.limit)
+
+	reply, err := slice(s.idx.redis.DoContext(ctx, args...).Result())
+	if err != nil {
+		return SearchResult{}, err
+	}
+	if len(reply) == 0 {
+		return SearchResult{}, nil
+	}
+
+	res := SearchResult{}
+	res.Total, _ = reply[0].(int64)
+
+	docs := make([]map[string]string, 0, len(reply)/2)
+	for i := 1; i+1 < len(reply); i += 2 {
+		res.Keys = append(res.Keys, str(reply[i]))
+		docs = append(docs, pairs(reply[i+1]))
+	}
+
+	return res, decodeAll(docs, dest)
+}
+
+// Aggregate aggregate documents matching q
+func (idx *Index) Aggregate(q *Query) *AggregateRequest {
+	return &AggregateRequest{idx: idx, query: q.String()}
+}
+
+// Load load fields from the documents for later steps
+func (a *AggregateRequest) Load(fields ...string) *AggregateRequest {
+	a.steps = append(a.steps, "load", len(fields))
+	for _, f := range fields {
+		a.steps = append(a.steps, "@"+strings.TrimPrefix(f, "@"))
+	}
+
+	return a
+}
+
+// GroupBy group by fields and reduce each group
+func (a *AggregateRequest) GroupBy(fields []string, reducers ...Reducer) *AggregateRequest {
+	a.steps = append(a.steps, "groupby", len(fields))
+	for _, f := range fields {
+		a.steps = append(a.steps, "@"+strings.TrimPrefix(f, "@"))
+	}
+
+	for _, r := range reducers {
+		a.steps = append(a.steps, "reduce", r.Func, len(r.Args))
+		for _, arg := range r.Args {
+			a.steps = append(a.steps, arg)
+		}
+		if r.As != "" {
+			a.steps = append(a.steps, "as", r.As)
+		}
+	}
+
+	return a
+}
+
+// Apply add field as computed by expression
+func (a *AggregateRequest) Apply(expression, as string) *AggregateRequest {
+	a.steps = append(a.steps, "apply", expression, "as", as)
+
+	return a
+}
+
+// Filter keep rows matching expression, e.g. "@total > 10"
+func (a *AggregateRequest) Filter(expression string) *AggregateRequest {
+	a.steps = append(a.steps, "filter", expression)
+
+	return a
+}
+
+// SortBy sort rows by field
+func (a *AggregateRequest) SortBy(field string, asc bool) *AggregateRequest {
+	order := "desc"
+	if asc {
+		order = "asc"
+	}
+	a.steps = append(a.steps, "sortby", 2, "@"+strings.TrimPrefix(field, "@"), order)
+
+	return a
+}
+
+// Limit return n rows from offset
+func (a *AggregateRequest) Limit(offset, n int) *AggregateRequest {
+	a.steps = append(a.steps, "limit", offset, n)
+
+	return a
+}
+
+// Do run the aggregation and decode rows into dest, a pointer to slice of structs, struct pointers or map[string]string.
+// Rows are decoded by `redis` tags, name them after the group fields and reducer aliases.
+func (a *AggregateRequest) Do(ctx context.Context, dest interface{}) error {
+	args := append([]interface{}{"ft.aggregate", a.idx.name, a.query}, a.steps...)
+
+	reply, err := slice(a.idx.redis.DoContext(ctx, args...).Result())
+	if err != nil {
+		return err
+	}
+
+	rows := make([]map[string]string, 0, len(reply))
+	for i := 1; i < len(reply); i++ {
+		rows = append(rows, pairs(reply[i]))
+	}
+
+	return decodeAll(rows, dest)
+}
+
+// decodeAll decode values into the slice pointed by dest
+func decodeAll(values []map[string]string, dest interface{}) error {
+	if dest == nil {
+		return nil
+	}
+
+	if maps, ok := dest.(*[]map[string]string); ok {
+		*maps = append(*maps, values...)
+		return nil
+	}
+
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("redisearch: dest must be a pointer to slice, got %T", dest)
+	}
+
+	slice := rv.Elem()
+	elem := slice.Type().Elem()
+	ptr := elem.Kind() == reflect.Ptr
+	if ptr {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct {
+		return redis.ErrNotStruct
+	}
+
+	for _, v := range values {
+		item := reflect.New(elem)
+		if err := redis.DecodeStruct(v, item.Interface()); err != nil {
+			return err
+		}
+
+		if ptr {
+			slice.Set(reflect.Append(slice, item))
+		} else {
+			slice.Set(reflect.Append(slice, item.Elem()))
+		}
+	}
+
+	return nil
+}
+
+// pairs field value pairs of a reply
+func pairs(v interface{}) map[string]string {
+	values, _ := v.([]interface{})
+
+	m := make(map[string]string, len(values)/2)
+	for i := 0; i+1 < len(values); i += 2 {
+		m[str(values[i])] = str(values[i+1])
+	}
+
+	return m
+}
+
+func escapeTag(v string) string {
+	b := strings.Builder{}
+	for _, c := range v {
+		if strings.ContainsRune(",.<>{}[]\"':;!@#$%^&*()-+=~| /\\", c) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+
+	return b.String()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
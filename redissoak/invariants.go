@@ -0,0 +1,119 @@
+package redissoak
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+type (
+	// Ledger invariant: no acked message is lost.
+	// Producers record ids acked by redis, consumers record ids they processed,
+	// every acked id must be processed or still exist when checked.
+	Ledger struct {
+		mu        sync.Mutex
+		acked     map[string]struct{}
+		processed map[string]struct{}
+		exists    func(ctx context.Context, id string) (bool, error)
+	}
+
+	// Holds invariant: a lock is never held by two owners at the same time
+	Holds struct {
+		mu         sync.Mutex
+		holders    map[string]string
+		violations []string
+	}
+)
+
+// NewLedger new a ledger, exists reports whether an unprocessed id is still stored, nil treats it as lost
+func NewLedger(exists func(ctx context.Context, id string) (bool, error)) *Ledger {
+	return &Ledger{
+		acked:     map[string]struct{}{},
+		processed: map[string]struct{}{},
+		exists:    exists,
+	}
+}
+
+// Acked record id acked by redis
+func (l *Ledger) Acked(id string) {
+	l.mu.Lock()
+	l.acked[id] = struct{}{}
+	l.mu.Unlock()
+}
+
+// Processed record id processed by a consumer
+func (l *Ledger) Processed(id string) {
+	l.mu.Lock()
+	l.processed[id] = struct{}{}
+	l.mu.Unlock()
+}
+
+// Check every acked id is processed or still exists
+func (l *Ledger) Check(ctx context.Context) error {
+	l.mu.Lock()
+	pending := make([]string, 0)
+	for id := range l.acked {
+		if _, ok := l.processed[id]; !ok {
+			pending = append(pending, id)
+		}
+	}
+	l.mu.Unlock()
+
+	lost := 0
+	for _, id := range pending {
+		ok := false
+		if l.exists != nil {
+			var err error
+			if ok, err = l.exists(ctx, id); err != nil {
+				return err
+			}
+		}
+		if !ok {
+			lost++
+		}
+	}
+
+	if lost > 0 {
+		return fmt.Errorf("%d of %d acked messages lost", lost, len(l.acked))
+	}
+
+	return nil
+}
+
+// NewHolds new a holds invariant
+func NewHolds() *Holds {
+	return &Holds{holders: map[string]string{}}
+}
+
+// Acquired record owner holds lock name, call it right after the lock is acquired
+func (h *Holds) Acquired(name, owner string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if holder, ok := h.holders[name]; ok && holder != owner {
+		h.violations = append(h.violations, fmt.Sprintf("%s acquired by %s while held by %s", name, owner, holder))
+	}
+	h.holders[name] = owner
+}
+
+// Released record owner released lock name, call it right before the lock is released
+func (h *Holds) Released(name, owner string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.holders[name] == owner {
+		delete(h.holders, name)
+	}
+}
+
+// Check no lock was double held
+func (h *Holds) Check(context.Context) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.violations) > 0 {
+		return fmt.Errorf("locks double held %d times, first: %s", len(h.violations), h.violations[0])
+	}
+
+	return nil
+}
@@ -0,0 +1,210 @@
+// Package redissoak soak and regression harness running workloads against scripted topology faults.
+// Scenarios are run as Go tests, so downstream CI suites reuse them against their own topology.
+//
+//	func TestFailover(t *testing.T) {
+//		ledger := redissoak.NewLedger(exists)
+//		redissoak.Run(t, redissoak.Scenario{
+//			Duration:   time.Minute,
+//			Workloads:  []redissoak.Workload{produce(ledger)},
+//			Steps:      []redissoak.Step{{At: 10 * time.Second, Fault: redissoak.Partition(proxy, "redis-primary")}},
+//			Invariants: []redissoak.Invariant{ledger},
+//		})
+//	}
+package redissoak
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+type (
+	// Fault topology fault injected by a scenario step
+	Fault interface {
+		Name() string
+		Inject(ctx context.Context) error
+		Heal(ctx context.Context) error
+	}
+
+	// Proxy network proxy between client and redis, e.g. a toxiproxy client adapter
+	Proxy interface {
+		// SetEnabled enable or disable proxy named name, a disabled proxy drops connections
+		SetEnabled(ctx context.Context, name string, enabled bool) error
+		// SetLatency add latency to proxy named name, zero removes it
+		SetLatency(ctx context.Context, name string, latency time.Duration) error
+	}
+
+	// Workload run until ctx is done, errors are logged, invariants decide whether the scenario fails
+	Workload func(ctx context.Context) error
+
+	// Invariant checked after all faults are healed and workloads stopped
+	Invariant interface {
+		Check(ctx context.Context) error
+	}
+
+	// InvariantFunc func as Invariant
+	InvariantFunc func(ctx context.Context) error
+
+	// Step inject fault at offset from scenario start, heal it after For (zero heals at scenario end)
+	Step struct {
+		At    time.Duration
+		For   time.Duration
+		Fault Fault
+	}
+
+	// Scenario workloads run for Duration while steps inject faults
+	Scenario struct {
+		Name       string
+		Duration   time.Duration
+		Workloads  []Workload
+		Steps      []Step
+		Invariants []Invariant
+		// Settle wait after healing before checking invariants, default is 1s
+		Settle time.Duration
+	}
+
+	funcFault struct {
+		name         string
+		inject, heal func(ctx context.Context) error
+	}
+)
+
+// Check call f
+func (f InvariantFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// NewFault fault from inject and heal funcs, heal may be nil
+func NewFault(name string, inject, heal func(ctx context.Context) error) Fault {
+	return &funcFault{name: name, inject: inject, heal: heal}
+}
+
+func (f *funcFault) Name() string {
+	return f.name
+}
+
+func (f *funcFault) Inject(ctx context.Context) error {
+	return f.inject(ctx)
+}
+
+func (f *funcFault) Heal(ctx context.Context) error {
+	if f.heal == nil {
+		return nil
+	}
+
+	return f.heal(ctx)
+}
+
+// Partition network partition: disable proxy when injected, enable it when healed
+func Partition(p Proxy, name string) Fault {
+	return NewFault("partition "+name,
+		func(ctx context.Context) error { return p.SetEnabled(ctx, name, false) },
+		func(ctx context.Context) error { return p.SetEnabled(ctx, name, true) },
+	)
+}
+
+// Latency add latency to proxy when injected, remove it when healed
+func Latency(p Proxy, name string, latency time.Duration) Fault {
+	return NewFault(fmt.Sprintf("latency %s %s", name, latency),
+		func(ctx context.Context) error { return p.SetLatency(ctx, name, latency) },
+		func(ctx context.Context) error { return p.SetLatency(ctx, name, 0) },
+	)
+}
+
+// PrimaryKill kill the primary when injected and restart it when healed, e.g. by docker or a process manager
+func PrimaryKill(kill, restart func(ctx context.Context) error) Fault {
+	return NewFault("primary kill", kill, restart)
+}
+
+// SlotMigration migrate slots when injected, e.g. by redis-cli --cluster reshard, and migrate back when healed
+func SlotMigration(migrate, back func(ctx context.Context) error) Fault {
+	return NewFault("slot migration", migrate, back)
+}
+
+// Run run scenario s, fail t when a fault cannot be injected or an invariant is violated
+func Run(t testing.TB, s Scenario) {
+	t.Helper()
+
+	settle := s.Settle
+	if settle <= 0 {
+		settle = time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.Duration)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i, workload := range s.Workloads {
+		wg.Add(1)
+		go func(i int, workload Workload) {
+			defer wg.Done()
+
+			if err := workload(ctx); err != nil && !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled) {
+				t.Logf("redissoak: %s workload %d: %v", s.Name, i, err)
+			}
+		}(i, workload)
+	}
+
+	healCtx := context.Background()
+	var mu sync.Mutex
+	injected := map[int]bool{}
+	heal := func(i int) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if !injected[i] {
+			return
+		}
+		injected[i] = false
+
+		if err := s.Steps[i].Fault.Heal(healCtx); err != nil {
+			t.Errorf("redissoak: %s heal %s: %v", s.Name, s.Steps[i].Fault.Name(), err)
+		}
+	}
+
+	timers := make([]*time.Timer, 0, len(s.Steps)*2)
+	for i, step := range s.Steps {
+		i, step := i, step
+
+		timers = append(timers, time.AfterFunc(step.At, func() {
+			if ctx.Err() != nil {
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			t.Logf("redissoak: %s inject %s at %s", s.Name, step.Fault.Name(), step.At)
+			if err := step.Fault.Inject(ctx); err != nil {
+				t.Errorf("redissoak: %s inject %s: %v", s.Name, step.Fault.Name(), err)
+				return
+			}
+			injected[i] = true
+		}))
+
+		if step.For > 0 {
+			timers = append(timers, time.AfterFunc(step.At+step.For, func() { heal(i) }))
+		}
+	}
+
+	<-ctx.Done()
+	for _, timer := range timers {
+		timer.Stop()
+	}
+	wg.Wait()
+
+	for i := range s.Steps {
+		heal(i)
+	}
+
+	time.Sleep(settle)
+
+	for _, invariant := range s.Invariants {
+		if err := invariant.Check(healCtx); err != nil {
+			t.Errorf("redissoak: %s invariant violated: %v", s.Name, err)
+		}
+	}
+}
@@ -0,0 +1,1115 @@
+package redistest
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	wrongType = errReply("WRONGTYPE Operation against a key holding the wrong kind of value")
+	notInt    = errReply("ERR value is not an integer or out of range")
+	syntaxErr = errReply("ERR syntax error")
+)
+
+// exec command, s.mu is held
+func (s *Server) exec(args []string) interface{} {
+	name, args := args[0], args[1:]
+
+	handler, ok := handlers[name]
+	if !ok {
+		return errReply(fmt.Sprintf("ERR unknown command '%s'", name))
+	}
+
+	if len(args) < handler.minArgs {
+		return errReply(fmt.Sprintf("ERR wrong number of arguments for '%s' command", name))
+	}
+
+	return handler.fn(s, args)
+}
+
+type handler struct {
+	minArgs int
+	fn      func(s *Server, args []string) interface{}
+}
+
+var handlers map[string]handler
+
+func init() {
+	handlers = map[string]handler{
+		"ping":    {0, cmdPing},
+		"echo":    {1, func(s *Server, args []string) interface{} { return args[0] }},
+		"select":  {1, func(s *Server, args []string) interface{} { return okReply }},
+		"auth":    {1, func(s *Server, args []string) interface{} { return okReply }},
+		"client":  {1, cmdClient},
+		"watch":   {1, func(s *Server, args []string) interface{} { return okReply }},
+		"unwatch": {0, func(s *Server, args []string) interface{} { return okReply }},
+		"info": {0, func(s *Server, args []string) interface{} {
+			return "# Server\r\nredis_version:6.0.0\r\nuptime_in_seconds:1\r\n"
+		}},
+		"flushdb":  {0, cmdFlush},
+		"flushall": {0, cmdFlush},
+		"dbsize":   {0, cmdDBSize},
+		"publish":  {2, cmdPublish},
+		"eval":     {2, cmdEval},
+		"evalsha":  {2, cmdEvalSha},
+		"script":   {1, cmdScript},
+
+		"del":     {1, cmdDel},
+		"unlink":  {1, cmdDel},
+		"exists":  {1, cmdExists},
+		"expire":  {2, cmdExpire(time.Second)},
+		"pexpire": {2, cmdExpire(time.Millisecond)},
+		"ttl":     {1, cmdTTL(time.Second)},
+		"pttl":    {1, cmdTTL(time.Millisecond)},
+		"persist": {1, cmdPersist},
+		"type":    {1, cmdType},
+		"keys":    {1, cmdKeys},
+		"scan":    {1, cmdScan},
+
+		"get": {1, cmdGet},
+		"set": {2, cmdSet},
+		"setnx": {2, func(s *Server, args []string) interface{} {
+			return boolInt(cmdSet(s, []string{args[0], args[1], "nx"}) == okReply)
+		}},
+		"setex": {3, func(s *Server, args []string) interface{} {
+			return cmdSet(s, []string{args[0], args[2], "ex", args[1]})
+		}},
+		"psetex": {3, func(s *Server, args []string) interface{} {
+			return cmdSet(s, []string{args[0], args[2], "px", args[1]})
+		}},
+		"getset": {2, cmdGetSet},
+		"mget":   {1, cmdMGet},
+		"mset":   {2, cmdMSet},
+		"incr":   {1, func(s *Server, args []string) interface{} { return s.incrBy(args[0], "1") }},
+		"incrby": {2, func(s *Server, args []string) interface{} { return s.incrBy(args[0], args[1]) }},
+		"decr":   {1, func(s *Server, args []string) interface{} { return s.decrBy(args[0], "1") }},
+		"decrby": {2, func(s *Server, args []string) interface{} { return s.decrBy(args[0], args[1]) }},
+		"append": {2, cmdAppend},
+		"strlen": {1, cmdStrLen},
+
+		"hset":    {3, cmdHSet},
+		"hmset":   {3, func(s *Server, args []string) interface{} { return replyOK(cmdHSet(s, args)) }},
+		"hsetnx":  {3, cmdHSetNX},
+		"hget":    {2, cmdHGet},
+		"hmget":   {2, cmdHMGet},
+		"hgetall": {1, cmdHGetAll},
+		"hdel":    {2, cmdHDel},
+		"hexists": {2, cmdHExists},
+		"hlen":    {1, cmdHLen},
+		"hkeys":   {1, cmdHKeys},
+		"hvals":   {1, cmdHVals},
+		"hincrby": {3, cmdHIncrBy},
+
+		"lpush":  {2, cmdPush(true)},
+		"rpush":  {2, cmdPush(false)},
+		"lpop":   {1, cmdPop(true)},
+		"rpop":   {1, cmdPop(false)},
+		"llen":   {1, cmdLLen},
+		"lrange": {3, cmdLRange},
+
+		"sadd":      {2, cmdSAdd},
+		"srem":      {2, cmdSRem},
+		"smembers":  {1, cmdSMembers},
+		"sismember": {2, cmdSIsMember},
+		"scard":     {1, cmdSCard},
+
+		"zadd":             {3, cmdZAdd},
+		"zincrby":          {3, cmdZIncrBy},
+		"zrem":             {2, cmdZRem},
+		"zscore":           {2, cmdZScore},
+		"zcard":            {1, cmdZCard},
+		"zrange":           {3, cmdZRange(false)},
+		"zrevrange":        {3, cmdZRange(true)},
+		"zrangebyscore":    {3, cmdZRangeByScore},
+		"zremrangebyscore": {3, cmdZRemRangeByScore},
+	}
+}
+
+func cmdPing(s *Server, args []string) interface{} {
+	if len(args) > 0 {
+		return args[0]
+	}
+
+	return status("PONG")
+}
+
+func cmdClient(s *Server, args []string) interface{} {
+	switch strings.ToLower(args[0]) {
+	case "setname":
+		return okReply
+	case "getname":
+		return nilReply{}
+	case "id":
+		return 1
+	}
+
+	return okReply
+}
+
+func cmdFlush(s *Server, args []string) interface{} {
+	s.data = map[string]*entry{}
+
+	return okReply
+}
+
+func cmdDBSize(s *Server, args []string) interface{} {
+	n := 0
+	for key := range s.data {
+		if s.lookup(key) != nil {
+			n++
+		}
+	}
+
+	return n
+}
+
+func cmdDel(s *Server, args []string) interface{} {
+	n := 0
+	for _, key := range args {
+		if s.lookup(key) != nil {
+			delete(s.data, key)
+			n++
+		}
+	}
+
+	return n
+}
+
+func cmdExists(s *Server, args []string) interface{} {
+	n := 0
+	for _, key := range args {
+		if s.lookup(key) != nil {
+			n++
+		}
+	}
+
+	return n
+}
+
+func cmdExpire(unit time.Duration) func(s *Server, args []string) interface{} {
+	return func(s *Server, args []string) interface{} {
+		n, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return notInt
+		}
+
+		e := s.lookup(args[0])
+		if e == nil {
+			return 0
+		}
+
+		e.expireAt = s.now().Add(time.Duration(n) * unit)
+
+		return 1
+	}
+}
+
+func cmdTTL(unit time.Duration) func(s *Server, args []string) interface{} {
+	return func(s *Server, args []string) interface{} {
+		e := s.lookup(args[0])
+		if e == nil {
+			return -2
+		}
+		if e.expireAt.IsZero() {
+			return -1
+		}
+
+		return int64((e.expireAt.Sub(s.now()) + unit/2) / unit)
+	}
+}
+
+func cmdPersist(s *Server, args []string) interface{} {
+	e := s.lookup(args[0])
+	if e == nil || e.expireAt.IsZero() {
+		return 0
+	}
+
+	e.expireAt = time.Time{}
+
+	return 1
+}
+
+func cmdType(s *Server, args []string) interface{} {
+	e := s.lookup(args[0])
+	if e == nil {
+		return status("none")
+	}
+
+	return status(e.kind)
+}
+
+func cmdKeys(s *Server, args []string) interface{} {
+	return s.match(args[0])
+}
+
+func cmdScan(s *Server, args []string) interface{} {
+	pattern := "*"
+	for i := 1; i+1 < len(args); i += 2 {
+		if strings.ToLower(args[i]) == "match" {
+			pattern = args[i+1]
+		}
+	}
+
+	return []interface{}{"0", s.match(pattern)}
+}
+
+func (s *Server) match(pattern string) []string {
+	keys := []string{}
+	for key := range s.data {
+		if ok, _ := path.Match(pattern, key); ok && s.lookup(key) != nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// get entry of kind, created when missing and create is true
+func (s *Server) get(key, kind string, create bool) (*entry, interface{}) {
+	e := s.lookup(key)
+	if e == nil {
+		if !create {
+			return nil, nil
+		}
+
+		e = &entry{kind: kind}
+		switch kind {
+		case "hash":
+			e.hash = map[string]string{}
+		case "set":
+			e.set = map[string]struct{}{}
+		case "zset":
+			e.zset = map[string]float64{}
+		}
+		s.data[key] = e
+	}
+
+	if e.kind != kind {
+		return nil, wrongType
+	}
+
+	return e, nil
+}
+
+func cmdGet(s *Server, args []string) interface{} {
+	e, err := s.get(args[0], "string", false)
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		return nilReply{}
+	}
+
+	return e.str
+}
+
+func cmdSet(s *Server, args []string) interface{} {
+	key, value := args[0], args[1]
+	var (
+		ttl     time.Duration
+		nx, xx  bool
+		keepTTL bool
+	)
+
+	for i := 2; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "nx":
+			nx = true
+		case "xx":
+			xx = true
+		case "keepttl":
+			keepTTL = true
+		case "ex", "px":
+			if i+1 >= len(args) {
+				return syntaxErr
+			}
+			n, err := strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil {
+				return notInt
+			}
+			unit := time.Second
+			if strings.ToLower(args[i]) == "px" {
+				unit = time.Millisecond
+			}
+			ttl = time.Duration(n) * unit
+			i++
+		default:
+			return syntaxErr
+		}
+	}
+
+	old := s.lookup(key)
+	if (nx && old != nil) || (xx && old == nil) {
+		return nilReply{}
+	}
+
+	e := &entry{kind: "string", str: value}
+	if ttl > 0 {
+		e.expireAt = s.now().Add(ttl)
+	} else if keepTTL && old != nil {
+		e.expireAt = old.expireAt
+	}
+	s.data[key] = e
+
+	return okReply
+}
+
+func cmdGetSet(s *Server, args []string) interface{} {
+	old := cmdGet(s, args[:1])
+	if _, isErr := old.(errReply); isErr {
+		return old
+	}
+
+	s.data[args[0]] = &entry{kind: "string", str: args[1]}
+
+	return old
+}
+
+func cmdMGet(s *Server, args []string) interface{} {
+	values := make([]interface{}, 0, len(args))
+	for _, key := range args {
+		e := s.lookup(key)
+		if e == nil || e.kind != "string" {
+			values = append(values, nilReply{})
+			continue
+		}
+		values = append(values, e.str)
+	}
+
+	return values
+}
+
+func cmdMSet(s *Server, args []string) interface{} {
+	if len(args)%2 != 0 {
+		return errReply("ERR wrong number of arguments for 'mset' command")
+	}
+
+	for i := 0; i < len(args); i += 2 {
+		s.data[args[i]] = &entry{kind: "string", str: args[i+1]}
+	}
+
+	return okReply
+}
+
+func (s *Server) decrBy(key, by string) interface{} {
+	delta, err := strconv.ParseInt(by, 10, 64)
+	if err != nil {
+		return notInt
+	}
+
+	return s.incr(key, -delta)
+}
+
+func (s *Server) incrBy(key, by string) interface{} {
+	delta, err := strconv.ParseInt(by, 10, 64)
+	if err != nil {
+		return notInt
+	}
+
+	return s.incr(key, delta)
+}
+
+func (s *Server) incr(key string, delta int64) interface{} {
+	var err error
+
+	e, errR := s.get(key, "string", true)
+	if errR != nil {
+		return errR
+	}
+
+	n := int64(0)
+	if e.str != "" {
+		if n, err = strconv.ParseInt(e.str, 10, 64); err != nil {
+			return notInt
+		}
+	}
+
+	n += delta
+	e.str = strconv.FormatInt(n, 10)
+
+	return n
+}
+
+func cmdAppend(s *Server, args []string) interface{} {
+	e, err := s.get(args[0], "string", true)
+	if err != nil {
+		return err
+	}
+
+	e.str += args[1]
+
+	return len(e.str)
+}
+
+func cmdStrLen(s *Server, args []string) interface{} {
+	e, err := s.get(args[0], "string", false)
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		return 0
+	}
+
+	return len(e.str)
+}
+
+func cmdHSet(s *Server, args []string) interface{} {
+	if len(args)%2 != 1 {
+		return errReply("ERR wrong number of arguments for 'hset' command")
+	}
+
+	e, err := s.get(args[0], "hash", true)
+	if err != nil {
+		return err
+	}
+
+	n := 0
+	for i := 1; i+1 < len(args); i += 2 {
+		if _, ok := e.hash[args[i]]; !ok {
+			n++
+		}
+		e.hash[args[i]] = args[i+1]
+	}
+
+	return n
+}
+
+func cmdHSetNX(s *Server, args []string) interface{} {
+	e, err := s.get(args[0], "hash", true)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := e.hash[args[1]]; ok {
+		return 0
+	}
+	e.hash[args[1]] = args[2]
+
+	return 1
+}
+
+func cmdHGet(s *Server, args []string) interface{} {
+	e, err := s.get(args[0], "hash", false)
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		return nilReply{}
+	}
+
+	v, ok := e.hash[args[1]]
+	if !ok {
+		return nilReply{}
+	}
+
+	return v
+}
+
+func cmdHMGet(s *Server, args []string) interface{} {
+	e, err := s.get(args[0], "hash", false)
+	if err != nil {
+		return err
+	}
+
+	values := make([]interface{}, 0, len(args)-1)
+	for _, field := range args[1:] {
+		if e == nil {
+			values = append(values, nilReply{})
+			continue
+		}
+		if v, ok := e.hash[field]; ok {
+			values = append(values, v)
+		} else {
+			values = append(values, nilReply{})
+		}
+	}
+
+	return values
+}
+
+func cmdHGetAll(s *Server, args []string) interface{} {
+	e, err := s.get(args[0], "hash", false)
+	if err != nil {
+		return err
+	}
+
+	values := []string{}
+	if e == nil {
+		return values
+	}
+
+	for _, field := range sortedFields(e.hash) {
+		values = append(values, field, e.hash[field])
+	}
+
+	return values
+}
+
+func cmdHDel(s *Server, args []string) interface{} {
+	e, err := s.get(args[0], "hash", false)
+	if err != nil || e == nil {
+		return orZero(err)
+	}
+
+	n := 0
+	for _, field := range args[1:] {
+		if _, ok := e.hash[field]; ok {
+			delete(e.hash, field)
+			n++
+		}
+	}
+	if len(e.hash) == 0 {
+		delete(s.data, args[0])
+	}
+
+	return n
+}
+
+func cmdHExists(s *Server, args []string) interface{} {
+	e, err := s.get(args[0], "hash", false)
+	if err != nil || e == nil {
+		return orZero(err)
+	}
+
+	_, ok := e.hash[args[1]]
+
+	return boolInt(ok)
+}
+
+func cmdHLen(s *Server, args []string) interface{} {
+	e, err := s.get(args[0], "hash", false)
+	if err != nil || e == nil {
+		return orZero(err)
+	}
+
+	return len(e.hash)
+}
+
+func cmdHKeys(s *Server, args []string) interface{} {
+	e, err := s.get(args[0], "hash", false)
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		return []string{}
+	}
+
+	return sortedFields(e.hash)
+}
+
+func cmdHVals(s *Server, args []string) interface{} {
+	e, err := s.get(args[0], "hash", false)
+	if err != nil {
+		return err
+	}
+
+	values := []string{}
+	if e == nil {
+		return values
+	}
+
+	for _, field := range sortedFields(e.hash) {
+		values = append(values, e.hash[field])
+	}
+
+	return values
+}
+
+func cmdHIncrBy(s *Server, args []string) interface{} {
+	delta, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return notInt
+	}
+
+	e, errR := s.get(args[0], "hash", true)
+	if errR != nil {
+		return errR
+	}
+
+	n, _ := strconv.ParseInt(e.hash[args[1]], 10, 64)
+	n += delta
+	e.hash[args[1]] = strconv.FormatInt(n, 10)
+
+	return n
+}
+
+func cmdPush(left bool) func(s *Server, args []string) interface{} {
+	return func(s *Server, args []string) interface{} {
+		e, err := s.get(args[0], "list", true)
+		if err != nil {
+			return err
+		}
+
+		for _, v := range args[1:] {
+			if left {
+				e.list = append([]string{v}, e.list...)
+			} else {
+				e.list = append(e.list, v)
+			}
+		}
+
+		return len(e.list)
+	}
+}
+
+func cmdPop(left bool) func(s *Server, args []string) interface{} {
+	return func(s *Server, args []string) interface{} {
+		e, err := s.get(args[0], "list", false)
+		if err != nil {
+			return err
+		}
+		if e == nil || len(e.list) == 0 {
+			return nilReply{}
+		}
+
+		var v string
+		if left {
+			v, e.list = e.list[0], e.list[1:]
+		} else {
+			v, e.list = e.list[len(e.list)-1], e.list[:len(e.list)-1]
+		}
+		if len(e.list) == 0 {
+			delete(s.data, args[0])
+		}
+
+		return v
+	}
+}
+
+func cmdLLen(s *Server, args []string) interface{} {
+	e, err := s.get(args[0], "list", false)
+	if err != nil || e == nil {
+		return orZero(err)
+	}
+
+	return len(e.list)
+}
+
+func cmdLRange(s *Server, args []string) interface{} {
+	e, err := s.get(args[0], "list", false)
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		return []string{}
+	}
+
+	start, stop, ok := rangeIndexes(args[1], args[2], len(e.list))
+	if !ok {
+		return []string{}
+	}
+
+	return append([]string(nil), e.list[start:stop+1]...)
+}
+
+func cmdSAdd(s *Server, args []string) interface{} {
+	e, err := s.get(args[0], "set", true)
+	if err != nil {
+		return err
+	}
+
+	n := 0
+	for _, member := range args[1:] {
+		if _, ok := e.set[member]; !ok {
+			e.set[member] = struct{}{}
+			n++
+		}
+	}
+
+	return n
+}
+
+func cmdSRem(s *Server, args []string) interface{} {
+	e, err := s.get(args[0], "set", false)
+	if err != nil || e == nil {
+		return orZero(err)
+	}
+
+	n := 0
+	for _, member := range args[1:] {
+		if _, ok := e.set[member]; ok {
+			delete(e.set, member)
+			n++
+		}
+	}
+	if len(e.set) == 0 {
+		delete(s.data, args[0])
+	}
+
+	return n
+}
+
+func cmdSMembers(s *Server, args []string) interface{} {
+	e, err := s.get(args[0], "set", false)
+	if err != nil {
+		return err
+	}
+
+	members := []string{}
+	if e == nil {
+		return members
+	}
+
+	for member := range e.set {
+		members = append(members, member)
+	}
+	sort.Strings(members)
+
+	return members
+}
+
+func cmdSIsMember(s *Server, args []string) interface{} {
+	e, err := s.get(args[0], "set", false)
+	if err != nil || e == nil {
+		return orZero(err)
+	}
+
+	_, ok := e.set[args[1]]
+
+	return boolInt(ok)
+}
+
+func cmdSCard(s *Server, args []string) interface{} {
+	e, err := s.get(args[0], "set", false)
+	if err != nil || e == nil {
+		return orZero(err)
+	}
+
+	return len(e.set)
+}
+
+func cmdZAdd(s *Server, args []string) interface{} {
+	i := 1
+	nx, xx := false, false
+	for ; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "nx":
+			nx = true
+			continue
+		case "xx":
+			xx = true
+			continue
+		}
+		break
+	}
+
+	pairs := args[i:]
+	if len(pairs) == 0 || len(pairs)%2 != 0 {
+		return syntaxErr
+	}
+
+	e, err := s.get(args[0], "zset", true)
+	if err != nil {
+		return err
+	}
+
+	n := 0
+	for j := 0; j < len(pairs); j += 2 {
+		score, err := strconv.ParseFloat(pairs[j], 64)
+		if err != nil {
+			return errReply("ERR value is not a valid float")
+		}
+
+		_, exists := e.zset[pairs[j+1]]
+		if (nx && exists) || (xx && !exists) {
+			continue
+		}
+		if !exists {
+			n++
+		}
+		e.zset[pairs[j+1]] = score
+	}
+
+	return n
+}
+
+func cmdZIncrBy(s *Server, args []string) interface{} {
+	delta, err := strconv.ParseFloat(args[1], 64)
+	if err != nil {
+		return errReply("ERR value is not a valid float")
+	}
+
+	e, errR := s.get(args[0], "zset", true)
+	if errR != nil {
+		return errR
+	}
+
+	e.zset[args[2]] += delta
+
+	return formatFloat(e.zset[args[2]])
+}
+
+func cmdZRem(s *Server, args []string) interface{} {
+	e, err := s.get(args[0], "zset", false)
+	if err != nil || e == nil {
+		return orZero(err)
+	}
+
+	n := 0
+	for _, member := range args[1:] {
+		if _, ok := e.zset[member]; ok {
+			delete(e.zset, member)
+			n++
+		}
+	}
+	if len(e.zset) == 0 {
+		delete(s.data, args[0])
+	}
+
+	return n
+}
+
+func cmdZScore(s *Server, args []string) interface{} {
+	e, err := s.get(args[0], "zset", false)
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		return nilReply{}
+	}
+
+	score, ok := e.zset[args[1]]
+	if !ok {
+		return nilReply{}
+	}
+
+	return formatFloat(score)
+}
+
+func cmdZCard(s *Server, args []string) interface{} {
+	e, err := s.get(args[0], "zset", false)
+	if err != nil || e == nil {
+		return orZero(err)
+	}
+
+	return len(e.zset)
+}
+
+func cmdZRange(rev bool) func(s *Server, args []string) interface{} {
+	return func(s *Server, args []string) interface{} {
+		e, err := s.get(args[0], "zset", false)
+		if err != nil {
+			return err
+		}
+		if e == nil {
+			return []string{}
+		}
+
+		members := sortedMembers(e.zset, rev)
+		start, stop, ok := rangeIndexes(args[1], args[2], len(members))
+		if !ok {
+			return []string{}
+		}
+
+		withScores := len(args) > 3 && strings.ToLower(args[3]) == "withscores"
+
+		return memberReply(e.zset, members[start:stop+1], withScores)
+	}
+}
+
+func cmdZRangeByScore(s *Server, args []string) interface{} {
+	e, err := s.get(args[0], "zset", false)
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		return []string{}
+	}
+
+	min, max := parseScore(args[1]), parseScore(args[2])
+	withScores := false
+	offset, count := 0, -1
+	for i := 3; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "withscores":
+			withScores = true
+		case "limit":
+			if i+2 >= len(args) {
+				return syntaxErr
+			}
+			offset, _ = strconv.Atoi(args[i+1])
+			count, _ = strconv.Atoi(args[i+2])
+			i += 2
+		}
+	}
+
+	members := []string{}
+	for _, member := range sortedMembers(e.zset, false) {
+		if score := e.zset[member]; min.below(score) && max.above(score) {
+			members = append(members, member)
+		}
+	}
+
+	if offset > len(members) {
+		offset = len(members)
+	}
+	members = members[offset:]
+	if count >= 0 && count < len(members) {
+		members = members[:count]
+	}
+
+	return memberReply(e.zset, members, withScores)
+}
+
+func cmdZRemRangeByScore(s *Server, args []string) interface{} {
+	e, err := s.get(args[0], "zset", false)
+	if err != nil || e == nil {
+		return orZero(err)
+	}
+
+	min, max := parseScore(args[1]), parseScore(args[2])
+	n := 0
+	for member, score := range e.zset {
+		if min.below(score) && max.above(score) {
+			delete(e.zset, member)
+			n++
+		}
+	}
+	if len(e.zset) == 0 {
+		delete(s.data, args[0])
+	}
+
+	return n
+}
+
+func sortedFields(m map[string]string) []string {
+	fields := make([]string, 0, len(m))
+	for field := range m {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	return fields
+}
+
+func sortedMembers(zset map[string]float64, rev bool) []string {
+	members := make([]string, 0, len(zset))
+	for member := range zset {
+		members = append(members, member)
+	}
+
+	sort.Slice(members, func(i, j int) bool {
+		a, b := zset[members[i]], zset[members[j]]
+		if a == b {
+			if rev {
+				return members[i] > members[j]
+			}
+			return members[i] < members[j]
+		}
+		if rev {
+			return a > b
+		}
+		return a < b
+	})
+
+	return members
+}
+
+func memberReply(zset map[string]float64, members []string, withScores bool) []string {
+	if !withScores {
+		return append([]string(nil), members...)
+	}
+
+	reply := make([]string, 0, len(members)*2)
+	for _, member := range members {
+		reply = append(reply, member, formatFloat(zset[member]))
+	}
+
+	return reply
+}
+
+// rangeIndexes normalize LRANGE/ZRANGE style start and stop
+func rangeIndexes(startArg, stopArg string, n int) (int, int, bool) {
+	start, err1 := strconv.Atoi(startArg)
+	stop, err2 := strconv.Atoi(stopArg)
+	if err1 != nil || err2 != nil {
+		return 0, 0, false
+	}
+
+	if start < 0 {
+		start += n
+	}
+	if stop < 0 {
+		stop += n
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= n {
+		stop = n - 1
+	}
+
+	return start, stop, start <= stop && start < n
+}
+
+// scoreBound ZRANGEBYSCORE min or max, "(" prefix makes it exclusive
+type scoreBound struct {
+	value     float64
+	exclusive bool
+}
+
+func parseScore(s string) scoreBound {
+	switch strings.ToLower(s) {
+	case "-inf":
+		return scoreBound{value: math.Inf(-1)}
+	case "+inf", "inf":
+		return scoreBound{value: math.Inf(1)}
+	}
+
+	exclusive := strings.HasPrefix(s, "(")
+	f, _ := strconv.ParseFloat(strings.TrimPrefix(s, "("), 64)
+
+	return scoreBound{value: f, exclusive: exclusive}
+}
+
+// below bound used as min
+func (b scoreBound) below(score float64) bool {
+	if b.exclusive {
+		return b.value < score
+	}
+
+	return b.value <= score
+}
+
+// above bound used as max
+func (b scoreBound) above(score float64) bool {
+	if b.exclusive {
+		return b.value > score
+	}
+
+	return b.value >= score
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', 17, 64)
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+func orZero(err interface{}) interface{} {
+	if err != nil {
+		return err
+	}
+
+	return 0
+}
+
+func replyOK(reply interface{}) interface{} {
+	if _, isErr := reply.(errReply); isErr {
+		return reply
+	}
+
+	return okReply
+}
@@ -0,0 +1,69 @@
+package redistest
+
+import "path"
+
+// subscribe handle SUBSCRIBE, PSUBSCRIBE, UNSUBSCRIBE and PUNSUBSCRIBE, one confirmation is replied per channel
+func (s *Server) subscribe(cn *conn, args []string) interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cn.channels == nil {
+		cn.channels, cn.patterns = map[string]struct{}{}, map[string]struct{}{}
+	}
+
+	kind, names := args[0], args[1:]
+	subs := cn.channels
+	if kind == "psubscribe" || kind == "punsubscribe" {
+		subs = cn.patterns
+	}
+
+	if len(names) == 0 && (kind == "unsubscribe" || kind == "punsubscribe") {
+		for name := range subs {
+			names = append(names, name)
+		}
+		if len(names) == 0 {
+			return []interface{}{kind, nilReply{}, len(cn.channels) + len(cn.patterns)}
+		}
+	}
+
+	replies := make(multiReply, 0, len(names))
+	for _, name := range names {
+		if kind == "subscribe" || kind == "psubscribe" {
+			subs[name] = struct{}{}
+		} else {
+			delete(subs, name)
+		}
+
+		replies = append(replies, []interface{}{kind, name, len(cn.channels) + len(cn.patterns)})
+	}
+
+	if len(cn.channels)+len(cn.patterns) > 0 {
+		s.subs[cn] = struct{}{}
+	} else {
+		delete(s.subs, cn)
+	}
+
+	return replies
+}
+
+// cmdPublish push message to subscribers of channel, s.mu is held
+func cmdPublish(s *Server, args []string) interface{} {
+	channel, message := args[0], args[1]
+	receivers := 0
+
+	for cn := range s.subs {
+		if _, ok := cn.channels[channel]; ok {
+			cn.write([]interface{}{"message", channel, message})
+			receivers++
+		}
+
+		for pattern := range cn.patterns {
+			if ok, _ := path.Match(pattern, channel); ok {
+				cn.write([]interface{}{"pmessage", pattern, channel, message})
+				receivers++
+			}
+		}
+	}
+
+	return receivers
+}
@@ -0,0 +1,88 @@
+// Package redistest fake redis for unit tests of code depending on boxgo/redis.
+//
+//	func TestCache(t *testing.T) {
+//		r, srv := redistest.New(t)
+//		...
+//		srv.AssertCalled(t, "set", "key")
+//	}
+//
+// Pub/sub is supported, Lua scripts run only when stubbed by Go functions of Server.Script.
+package redistest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/boxgo/redis"
+)
+
+var seq uint64
+
+// New start a fake server and a *redis.Redis connected to it, both are closed and the instance is unregistered
+// when test finished
+func New(t testing.TB) (*redis.Redis, *Server) {
+	t.Helper()
+
+	s, err := NewServer()
+	if err != nil {
+		t.Fatalf("redistest: start server error: %v", err)
+	}
+
+	name := fmt.Sprintf("redistest-%d", atomic.AddUint64(&seq, 1))
+	r := redis.New(name)
+	r.Address = []string{s.Addr()}
+	r.ConfigDidLoad(context.Background())
+
+	t.Cleanup(func() {
+		r.Shutdown(context.Background())
+		redis.Unregister(name)
+		s.Close()
+	})
+
+	return r, s
+}
+
+// UseDefault replace redis.Default with a fake one, restored when test finished
+func UseDefault(t testing.TB) *Server {
+	t.Helper()
+
+	r, s := New(t)
+
+	old := redis.Default
+	redis.Default = r
+	t.Cleanup(func() {
+		redis.Default = old
+	})
+
+	return s
+}
+
+// AssertCalled fail test when no command named name with leading args is executed
+func (s *Server) AssertCalled(t testing.TB, name string, args ...string) {
+	t.Helper()
+
+	if s.Called(name, args...) == 0 {
+		t.Errorf("redistest: expected command %s %s, executed:\n%s", name, strings.Join(args, " "), s.dump())
+	}
+}
+
+// AssertNotCalled fail test when command named name is executed
+func (s *Server) AssertNotCalled(t testing.TB, name string) {
+	t.Helper()
+
+	if n := s.Called(name); n != 0 {
+		t.Errorf("redistest: unexpected command %s executed %d times", name, n)
+	}
+}
+
+func (s *Server) dump() string {
+	lines := []string{}
+	for _, cmd := range s.Commands() {
+		lines = append(lines, "  "+strings.Join(cmd, " "))
+	}
+
+	return strings.Join(lines, "\n")
+}
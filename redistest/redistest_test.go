@@ -0,0 +1,93 @@
+package redistest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boxgo/redis"
+)
+
+func TestNew(t *testing.T) {
+	var name string
+
+	t.Run("cleanup", func(t *testing.T) {
+		r, srv := New(t)
+
+		if err := r.Set("key", "value", time.Minute).Err(); err != nil {
+			t.Fatal(err)
+		}
+		srv.AssertCalled(t, "set", "key", "value")
+
+		name = r.Instance()
+	})
+
+	if redis.Get(name) != nil {
+		t.Errorf("instance %s is registered after cleanup", name)
+	}
+}
+
+func TestExpire(t *testing.T) {
+	r, srv := New(t)
+
+	if err := r.Set("key", "value", time.Minute).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.FastForward(time.Minute)
+
+	if n := r.Exists("key").Val(); n != 0 {
+		t.Errorf("expected expired key, got %d", n)
+	}
+}
+
+func TestPubSub(t *testing.T) {
+	r, _ := New(t)
+
+	sub := r.PSubscribe("news.*")
+	defer sub.Close()
+
+	if _, err := sub.Receive(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := r.Publish("news.sport", "goal").Result(); err != nil || n != 1 {
+		t.Fatalf("publish got %d %v", n, err)
+	}
+
+	select {
+	case msg := <-sub.Channel():
+		if msg.Channel != "news.sport" || msg.Payload != "goal" || msg.Pattern != "news.*" {
+			t.Errorf("unexpected message %+v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("message is not delivered")
+	}
+}
+
+func TestScript(t *testing.T) {
+	r, srv := New(t)
+
+	const src = "return redis.call('incrby', KEYS[1], ARGV[1])"
+	srv.Script(src, func(call func(args ...string) (interface{}, error), keys, args []string) (interface{}, error) {
+		return call("incrby", keys[0], args[0])
+	})
+
+	if n, err := r.Eval(src, []string{"counter"}, 2).Int64(); err != nil || n != 2 {
+		t.Fatalf("eval got %d %v", n, err)
+	}
+
+	if err := r.Eval("return 1", nil).Err(); err == nil {
+		t.Error("expected error of script not stubbed")
+	}
+
+	if err := r.EvalSha("0000", nil).Err(); err == nil || !strings.HasPrefix(err.Error(), "NOSCRIPT") {
+		t.Errorf("expected NOSCRIPT error, got %v", err)
+	}
+
+	ctx := context.Background()
+	if n, err := r.DoContext(ctx, "evalsha", scriptHash(src), 1, "counter", 3).Int64(); err != nil || n != 5 {
+		t.Errorf("evalsha got %d %v", n, err)
+	}
+}
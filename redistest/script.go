@@ -0,0 +1,148 @@
+package redistest
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+type (
+	// ScriptFunc Go stand-in of a Lua script, it runs atomically like the script on a real server.
+	// call runs a command like redis.call, replies are nil, int64, string or []interface{}.
+	// The reply of the script is nil, bool, int, int64, string, []interface{} or an error reply.
+	ScriptFunc func(call func(args ...string) (interface{}, error), keys, args []string) (interface{}, error)
+
+	script struct {
+		src string
+		fn  ScriptFunc
+	}
+)
+
+// Script stub the Lua script of source src by fn, EVAL and EVALSHA of other scripts fail
+func (s *Server) Script(src string, fn ScriptFunc) {
+	s.mu.Lock()
+	s.scripts[scriptHash(src)] = script{src: src, fn: fn}
+	s.mu.Unlock()
+}
+
+func scriptHash(src string) string {
+	sum := sha1.Sum([]byte(src))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func cmdEval(s *Server, args []string) interface{} {
+	sc, ok := s.scripts[scriptHash(args[0])]
+	if !ok {
+		return errReply("ERR redistest: script not stubbed, stub it by Server.Script")
+	}
+
+	return s.runScript(sc, args[1:])
+}
+
+func cmdEvalSha(s *Server, args []string) interface{} {
+	sc, ok := s.scripts[strings.ToLower(args[0])]
+	if !ok {
+		return errReply("NOSCRIPT No matching script. Please use EVAL.")
+	}
+
+	return s.runScript(sc, args[1:])
+}
+
+func cmdScript(s *Server, args []string) interface{} {
+	switch strings.ToLower(args[0]) {
+	case "load":
+		if len(args) < 2 {
+			return syntaxErr
+		}
+		if _, ok := s.scripts[scriptHash(args[1])]; !ok {
+			return errReply("ERR redistest: script not stubbed, stub it by Server.Script")
+		}
+		return scriptHash(args[1])
+	case "exists":
+		exists := make([]interface{}, 0, len(args)-1)
+		for _, hash := range args[1:] {
+			_, ok := s.scripts[strings.ToLower(hash)]
+			exists = append(exists, boolInt(ok))
+		}
+		return exists
+	case "flush":
+		return okReply
+	}
+
+	return syntaxErr
+}
+
+// runScript run stubbed script of numkeys key [key ...] arg [arg ...], s.mu is held
+func (s *Server) runScript(sc script, args []string) interface{} {
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 0 || n > len(args)-1 {
+		return errReply("ERR Number of keys can't be greater than number of args")
+	}
+
+	call := func(args ...string) (interface{}, error) {
+		if len(args) == 0 {
+			return nil, errors.New("ERR Please specify at least one argument for redis.call()")
+		}
+
+		cmd := append([]string{strings.ToLower(args[0])}, args[1:]...)
+
+		return scriptValue(s.exec(cmd))
+	}
+
+	reply, err := sc.fn(call, args[1:1+n], args[1+n:])
+	if err != nil {
+		return errReply(err.Error())
+	}
+
+	return scriptReply(reply)
+}
+
+// scriptValue reply of a command seen by scripts
+func scriptValue(reply interface{}) (interface{}, error) {
+	switch v := reply.(type) {
+	case errReply:
+		return nil, errors.New(string(v))
+	case status:
+		return string(v), nil
+	case nilReply:
+		return nil, nil
+	case int:
+		return int64(v), nil
+	case []string:
+		values := make([]interface{}, len(v))
+		for i, item := range v {
+			values[i] = item
+		}
+		return values, nil
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for i, item := range v {
+			values[i], _ = scriptValue(item)
+		}
+		return values, nil
+	}
+
+	return reply, nil
+}
+
+// scriptReply reply of script written back, bools follow the Lua conversion
+func scriptReply(reply interface{}) interface{} {
+	switch v := reply.(type) {
+	case bool:
+		if v {
+			return 1
+		}
+		return nilReply{}
+	case []interface{}:
+		replies := make([]interface{}, len(v))
+		for i, item := range v {
+			replies[i] = scriptReply(item)
+		}
+		return replies
+	}
+
+	return reply
+}
@@ -0,0 +1,394 @@
+package redistest
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// Server in-memory fake redis server speaking RESP, it records every executed command.
+	// Lua scripts run only when stubbed by Script, cluster commands are not supported.
+	Server struct {
+		ln       net.Listener
+		mu       sync.Mutex
+		data     map[string]*entry
+		commands [][]string
+		offset   time.Duration
+		wg       sync.WaitGroup
+		conns    map[net.Conn]struct{}
+		closed   bool
+		scripts  map[string]script
+		subs     map[*conn]struct{}
+	}
+
+	entry struct {
+		kind     string
+		str      string
+		hash     map[string]string
+		list     []string
+		set      map[string]struct{}
+		zset     map[string]float64
+		expireAt time.Time
+	}
+
+	// reply values written back to client
+	status   string
+	errReply string
+	nilReply struct{}
+
+	// multiReply replies written one after another, e.g. confirmations of SUBSCRIBE channels
+	multiReply []interface{}
+
+	conn struct {
+		mu       sync.Mutex // guards writes, messages are pushed by publishers of other connections
+		rw       *bufio.ReadWriter
+		multi    [][]string
+		inTx     bool
+		channels map[string]struct{}
+		patterns map[string]struct{}
+	}
+)
+
+const okReply = status("OK")
+
+// NewServer start a fake server on a random local port
+func NewServer() (*Server, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		ln:      ln,
+		data:    map[string]*entry{},
+		conns:   map[net.Conn]struct{}{},
+		scripts: map[string]script{},
+		subs:    map[*conn]struct{}{},
+	}
+
+	s.wg.Add(1)
+	go s.accept()
+
+	return s, nil
+}
+
+// Addr host:port of server
+func (s *Server) Addr() string {
+	return s.ln.Addr().String()
+}
+
+// Close stop server and close all connections
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mu.Unlock()
+
+	err := s.ln.Close()
+	s.wg.Wait()
+
+	return err
+}
+
+// Commands executed commands in order, names are lower case
+func (s *Server) Commands() [][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	commands := make([][]string, len(s.commands))
+	copy(commands, s.commands)
+
+	return commands
+}
+
+// Called number of executed commands named name whose leading args equal args
+func (s *Server) Called(name string, args ...string) int {
+	n := 0
+
+	for _, cmd := range s.Commands() {
+		if cmd[0] != strings.ToLower(name) || len(cmd)-1 < len(args) {
+			continue
+		}
+
+		match := true
+		for i, arg := range args {
+			if cmd[i+1] != arg {
+				match = false
+				break
+			}
+		}
+		if match {
+			n++
+		}
+	}
+
+	return n
+}
+
+// ResetCommands forget executed commands
+func (s *Server) ResetCommands() {
+	s.mu.Lock()
+	s.commands = nil
+	s.mu.Unlock()
+}
+
+// FlushAll remove all keys
+func (s *Server) FlushAll() {
+	s.mu.Lock()
+	s.data = map[string]*entry{}
+	s.mu.Unlock()
+}
+
+// FastForward move server clock forward, keys expire accordingly
+func (s *Server) FastForward(d time.Duration) {
+	s.mu.Lock()
+	s.offset += d
+	s.mu.Unlock()
+}
+
+// Keys all live keys
+func (s *Server) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]string, 0, len(s.data))
+	for key := range s.data {
+		if s.lookup(key) != nil {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+func (s *Server) accept() {
+	defer s.wg.Done()
+
+	for {
+		c, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			c.Close()
+			return
+		}
+		s.conns[c] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go s.serve(c)
+	}
+}
+
+func (s *Server) serve(c net.Conn) {
+	defer s.wg.Done()
+	cn := &conn{rw: bufio.NewReadWriter(bufio.NewReader(c), bufio.NewWriter(c))}
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c)
+		delete(s.subs, cn)
+		s.mu.Unlock()
+		c.Close()
+	}()
+
+	for {
+		args, err := readCommand(cn.rw.Reader)
+		if err != nil {
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		args[0] = strings.ToLower(args[0])
+		if args[0] == "quit" {
+			cn.write(okReply)
+			return
+		}
+
+		if err := cn.write(s.execConn(cn, args)); err != nil {
+			return
+		}
+	}
+}
+
+// execConn handle transaction and subscription state of connection
+func (s *Server) execConn(cn *conn, args []string) interface{} {
+	switch args[0] {
+	case "subscribe", "psubscribe", "unsubscribe", "punsubscribe":
+		s.record(args)
+		return s.subscribe(cn, args)
+	case "multi":
+		s.record(args)
+		cn.inTx, cn.multi = true, nil
+		return okReply
+	case "discard":
+		s.record(args)
+		cn.inTx, cn.multi = false, nil
+		return okReply
+	case "exec":
+		s.record(args)
+		if !cn.inTx {
+			return errReply("ERR EXEC without MULTI")
+		}
+
+		s.mu.Lock()
+		replies := make([]interface{}, 0, len(cn.multi))
+		for _, queued := range cn.multi {
+			replies = append(replies, s.exec(queued))
+		}
+		s.mu.Unlock()
+
+		cn.inTx, cn.multi = false, nil
+		return replies
+	}
+
+	if cn.inTx {
+		s.record(args)
+		cn.multi = append(cn.multi, args)
+		return status("QUEUED")
+	}
+
+	s.record(args)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.exec(args)
+}
+
+// write reply to connection and flush it
+func (cn *conn) write(reply interface{}) error {
+	cn.mu.Lock()
+	defer cn.mu.Unlock()
+
+	writeReply(cn.rw.Writer, reply)
+
+	return cn.rw.Flush()
+}
+
+func (s *Server) record(args []string) {
+	s.mu.Lock()
+	s.commands = append(s.commands, append([]string(nil), args...))
+	s.mu.Unlock()
+}
+
+func (s *Server) now() time.Time {
+	return time.Now().Add(s.offset)
+}
+
+// lookup live entry of key, expired entry is removed
+func (s *Server) lookup(key string) *entry {
+	e, ok := s.data[key]
+	if !ok {
+		return nil
+	}
+
+	if !e.expireAt.IsZero() && !s.now().Before(e.expireAt) {
+		delete(s.data, key)
+		return nil
+	}
+
+	return e
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(line) == 0 {
+		return nil, nil
+	}
+
+	if line[0] != '*' {
+		return strings.Fields(line), nil
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		line, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 || line[0] != '$' {
+			return nil, errors.New("redistest: expected bulk string")
+		}
+
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+
+		args = append(args, string(buf[:size]))
+	}
+
+	return args, nil
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func writeReply(w *bufio.Writer, reply interface{}) {
+	switch v := reply.(type) {
+	case status:
+		fmt.Fprintf(w, "+%s\r\n", v)
+	case errReply:
+		fmt.Fprintf(w, "-%s\r\n", v)
+	case int:
+		fmt.Fprintf(w, ":%d\r\n", v)
+	case int64:
+		fmt.Fprintf(w, ":%d\r\n", v)
+	case string:
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(v), v)
+	case nilReply, nil:
+		w.WriteString("$-1\r\n")
+	case []string:
+		fmt.Fprintf(w, "*%d\r\n", len(v))
+		for _, item := range v {
+			writeReply(w, item)
+		}
+	case []interface{}:
+		fmt.Fprintf(w, "*%d\r\n", len(v))
+		for _, item := range v {
+			writeReply(w, item)
+		}
+	case multiReply:
+		for _, item := range v {
+			writeReply(w, item)
+		}
+	default:
+		writeReply(w, errReply(fmt.Sprintf("ERR redistest: unsupported reply %T", v)))
+	}
+}
@@ -0,0 +1,119 @@
+package redis
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	// ReentrantLock lock which can be locked again by its owner, it is released when unlocked as many times.
+	// Owners are identified by caller provided ids (e.g. job id), since goroutines have none.
+	ReentrantLock struct {
+		locker *Locker
+		name   string
+		owner  string
+	}
+)
+
+var (
+	// KEYS[1]: lock hash owner => count. ARGV[1]: owner, ARGV[2]: ttl ms. returns count, 0 when held by another owner
+	reentrantLockScript = newScript(`
+if redis.call('exists', KEYS[1]) == 0 or redis.call('hexists', KEYS[1], ARGV[1]) == 1 then
+	local n = redis.call('hincrby', KEYS[1], ARGV[1], 1)
+	redis.call('pexpire', KEYS[1], ARGV[2])
+	return n
+end
+return 0
+`)
+
+	// KEYS[1]: lock hash. ARGV[1]: owner, ARGV[2]: ttl ms. returns remaining count, -1 when not held
+	reentrantUnlockScript = newScript(`
+if redis.call('hexists', KEYS[1], ARGV[1]) == 0 then
+	return -1
+end
+local n = redis.call('hincrby', KEYS[1], ARGV[1], -1)
+if n > 0 then
+	redis.call('pexpire', KEYS[1], ARGV[2])
+	return n
+end
+redis.call('del', KEYS[1])
+return 0
+`)
+
+	// KEYS[1]: hash lock. ARGV[1]: field of owner, ARGV[2]: ttl ms
+	refreshHashLockScript = newScript(`
+if redis.call('hexists', KEYS[1], ARGV[1]) == 1 then
+	return redis.call('pexpire', KEYS[1], ARGV[2])
+end
+return 0
+`)
+)
+
+// Reentrant reentrant lock name owned by owner
+func (l *Locker) Reentrant(name, owner string) *ReentrantLock {
+	return &ReentrantLock{
+		locker: l,
+		name:   name,
+		owner:  owner,
+	}
+}
+
+// TryLock lock once, ErrNotObtained is returned when it is held by another owner
+func (rl *ReentrantLock) TryLock(ctx context.Context) error {
+	l := rl.locker
+
+	n, err := reentrantLockScript.run(ctx, l.redis, []string{rl.key()}, rl.owner, l.ttl.Milliseconds()).Int64()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotObtained
+	}
+
+	return nil
+}
+
+// Lock retry until locked or ctx is done
+func (rl *ReentrantLock) Lock(ctx context.Context) error {
+	for {
+		err := rl.TryLock(ctx)
+		if err != ErrNotObtained {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(rl.locker.retry):
+		}
+	}
+}
+
+// Unlock decrease hold count, the lock is released when it reaches zero.
+// ErrLockNotHeld is returned when the owner does not hold it.
+func (rl *ReentrantLock) Unlock(ctx context.Context) error {
+	l := rl.locker
+
+	n, err := reentrantUnlockScript.run(ctx, l.redis, []string{rl.key()}, rl.owner, l.ttl.Milliseconds()).Int64()
+	if err == nil && n < 0 {
+		err = ErrLockNotHeld
+	}
+
+	return err
+}
+
+// Refresh extend ttl of the lock, ErrLockNotHeld is returned when the owner does not hold it
+func (rl *ReentrantLock) Refresh(ctx context.Context) error {
+	l := rl.locker
+
+	n, err := refreshHashLockScript.run(ctx, l.redis, []string{rl.key()}, rl.owner, l.ttl.Milliseconds()).Int64()
+	if err == nil && n == 0 {
+		err = ErrLockNotHeld
+	}
+
+	return err
+}
+
+func (rl *ReentrantLock) key() string {
+	return rl.locker.key(rl.name) + ":reentrant"
+}
@@ -0,0 +1,169 @@
+package redis
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+var (
+	// KEYS[1]: claim. ARGV[1]: token
+	releaseClaimScript = newScript(`
+if redis.call('get', KEYS[1]) == ARGV[1] then
+	return redis.call('del', KEYS[1])
+end
+return 0
+`)
+)
+
+type (
+	// RefreshCoordinator advisory coordination of cache refreshes across instances.
+	// The first instance announcing a key refreshes it, peers wait for its done notification and reuse the result.
+	RefreshCoordinator struct {
+		redis   *Redis
+		prefix  string
+		once    sync.Once
+		mu      sync.Mutex
+		waiters map[string][]chan struct{}
+		pubsub  *redis.PubSub
+	}
+)
+
+// NewRefreshCoordinator new a refresh coordinator, keys and channel are prefixed by prefix.
+func NewRefreshCoordinator(r *Redis, prefix string) *RefreshCoordinator {
+	return &RefreshCoordinator{
+		redis:   r,
+		prefix:  prefix,
+		waiters: make(map[string][]chan struct{}),
+	}
+}
+
+// Refresh run refresh for key unless a peer announced it, in which case wait up to ttl for the peer.
+// refreshed is false when the peer's result should be reused.
+// When the peer does not finish in ttl, refresh is run by this instance, claiming the key again if the peer's claim expired.
+func (rc *RefreshCoordinator) Refresh(ctx context.Context, key string, ttl time.Duration, refresh func(ctx context.Context) error) (refreshed bool, err error) {
+	rc.once.Do(rc.listen)
+
+	token, err := rc.claim(ctx, key, ttl)
+	if err != nil {
+		return false, err
+	}
+
+	if token == "" {
+		wait := rc.wait(key)
+		defer rc.cancel(key, wait)
+
+		// the claim is written on the master, a replica may not have it yet
+		if n, err := rc.redis.DoContext(ForceMaster(ctx), "exists", rc.claimKey(key)).Int64(); err == nil && n == 0 {
+			return false, nil
+		}
+
+		timer := time.NewTimer(ttl)
+		defer timer.Stop()
+
+		select {
+		case <-wait:
+			return false, nil
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-timer.C:
+		}
+
+		if token, err = rc.claim(ctx, key, ttl); err != nil {
+			return false, err
+		}
+	}
+
+	err = refresh(ctx)
+
+	// release only the own claim, a claim of another instance is left to it
+	if token != "" {
+		releaseClaimScript.run(ctx, rc.redis, []string{rc.claimKey(key)}, token)
+	}
+	rc.redis.DoContext(ctx, "publish", rc.channel(), key)
+
+	return true, err
+}
+
+// Close stop listening done notifications
+func (rc *RefreshCoordinator) Close() error {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if rc.pubsub == nil {
+		return nil
+	}
+
+	return rc.pubsub.Close()
+}
+
+// claim claim key with a unique owner token, the token is empty when another instance holds the claim
+func (rc *RefreshCoordinator) claim(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	token := randomID()
+
+	err := rc.redis.DoContext(ctx, append(setArgs(rc.claimKey(key), token, ttl), "nx")...).Err()
+	if err == redis.Nil {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func (rc *RefreshCoordinator) listen() {
+	ps := rc.redis.Subscribe(rc.channel())
+
+	rc.mu.Lock()
+	rc.pubsub = ps
+	rc.mu.Unlock()
+
+	go func() {
+		for msg := range ps.Channel() {
+			rc.mu.Lock()
+			for _, ch := range rc.waiters[msg.Payload] {
+				close(ch)
+			}
+			delete(rc.waiters, msg.Payload)
+			rc.mu.Unlock()
+		}
+	}()
+}
+
+func (rc *RefreshCoordinator) wait(key string) chan struct{} {
+	ch := make(chan struct{})
+
+	rc.mu.Lock()
+	rc.waiters[key] = append(rc.waiters[key], ch)
+	rc.mu.Unlock()
+
+	return ch
+}
+
+func (rc *RefreshCoordinator) cancel(key string, ch chan struct{}) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	waiters := rc.waiters[key]
+	for i, w := range waiters {
+		if w == ch {
+			rc.waiters[key] = append(waiters[:i], waiters[i+1:]...)
+			break
+		}
+	}
+
+	if len(rc.waiters[key]) == 0 {
+		delete(rc.waiters, key)
+	}
+}
+
+func (rc *RefreshCoordinator) claimKey(key string) string {
+	return rc.prefix + ":refreshing:" + key
+}
+
+func (rc *RefreshCoordinator) channel() string {
+	return rc.prefix + ":refresh:done"
+}
@@ -0,0 +1,81 @@
+package redis
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+var (
+	instancesMu sync.RWMutex
+	instances   = map[string]*Redis{}
+)
+
+// Get a redis instance by name, nil if it is not created by New
+func Get(name string) *Redis {
+	instancesMu.RLock()
+	defer instancesMu.RUnlock()
+
+	return instances[name]
+}
+
+// All redis instances created by New, sorted by name
+func All() []*Redis {
+	instancesMu.RLock()
+	defer instancesMu.RUnlock()
+
+	all := make([]*Redis, 0, len(instances))
+	for _, r := range instances {
+		all = append(all, r)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].name < all[j].name
+	})
+
+	return all
+}
+
+// ServeAll serve all configured instances, stop at the first error
+func ServeAll(ctx context.Context) error {
+	for _, r := range All() {
+		if r.UniversalClient == nil {
+			continue
+		}
+
+		if err := r.Serve(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ShutdownAll shutdown all instances, return the first error
+func ShutdownAll(ctx context.Context) error {
+	var first error
+
+	for _, r := range All() {
+		if err := r.Shutdown(ctx); err != nil && first == nil {
+			first = err
+		}
+	}
+
+	return first
+}
+
+// Unregister remove the instance named name, e.g. instances of tests, it is no longer served by ServeAll
+func Unregister(name string) {
+	instancesMu.Lock()
+	delete(instances, name)
+	instancesMu.Unlock()
+}
+
+// register the instance, an instance with the same name is replaced
+func register(r *Redis) *Redis {
+	instancesMu.Lock()
+	instances[r.name] = r
+	instancesMu.Unlock()
+
+	return r
+}
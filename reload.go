@@ -0,0 +1,234 @@
+package redis
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// reloadState password of new connections and connections dialed by the box by generation
+	reloadState struct {
+		mu         sync.Mutex
+		password   atomic.Value // string
+		generation uint64
+		connsMu    sync.Mutex
+		conns      map[*trackedConn]struct{}
+	}
+
+	// trackedConn connection dialed by the box, closed when its generation is drained
+	trackedConn struct {
+		net.Conn
+		gen   uint64
+		state *reloadState
+		once  sync.Once
+	}
+)
+
+const (
+	defaultDialTimeout = 5 * time.Second
+	defaultReloadGrace = 30 * time.Second
+)
+
+// Reload rebuild the client from reloaded config, e.g. address list, password and pool size, and swap it atomically.
+// Commands use the new client at once, the old client is closed after reloadGrace to drain its commands in flight.
+// Connections of replicas dialed before are closed then too, replicas reconnect with the new password.
+// Replica addresses, timeouts and hooks enabled by config take effect after restart.
+func (r *Redis) Reload(ctx context.Context) error {
+	swap, ok := r.UniversalClient.(*swapClient)
+	if !ok {
+		return fmt.Errorf("redis: reload %s: config is not loaded", r.Instance())
+	}
+
+	state := &r.reload
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	oldPassword := state.password.Load()
+
+	password, err := r.fetchPassword(ctx)
+	if err != nil {
+		return err
+	}
+
+	state.password.Store(password)
+
+	// verify new settings with a fresh connection before the client is swapped
+	conn, err := r.dial(ctx, "tcp", r.Address[0])
+	if err != nil {
+		state.password.Store(oldPassword)
+
+		return fmt.Errorf("redis: reload %s: %w", r.Instance(), err)
+	}
+	conn.Close()
+
+	gen := atomic.AddUint64(&state.generation, 1)
+	prev := swap.swap(r.newClient(r.options()))
+
+	grace := r.ReloadGrace
+	if grace <= 0 {
+		grace = defaultReloadGrace
+	}
+	time.AfterFunc(grace, func() {
+		if err := prev.Close(); err != nil {
+			r.logf("close client replaced by reload: %v", err)
+		}
+		state.drain(gen)
+	})
+
+	r.logf("reloaded, the replaced client is closed in %s", grace)
+
+	return nil
+}
+
+// dial connect and authenticate with the current settings
+func (r *Redis) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	state := &r.reload
+
+	timeout := r.dialTimeout
+	if timeout <= 0 {
+		timeout = defaultDialTimeout
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: 5 * time.Minute,
+	}
+
+	conn, err := dialer.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	// the client does not wrap connections of a custom dialer
+	if r.tlsConfig != nil {
+		tlsConn := tls.Client(conn, r.tlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	// sentinel clients authenticate masters with the password option
+	if r.MasterName == "" {
+		if err := r.authenticate(ctx, conn); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return state.track(conn), nil
+}
+
+// authenticate auth with the cached password, fetch it again from the provider when it is rejected
+func (r *Redis) authenticate(ctx context.Context, conn net.Conn) error {
+	state := &r.reload
+
+	password, _ := state.password.Load().(string)
+	if password == "" && r.credentials != nil {
+		var err error
+		if password, err = r.fetchPassword(ctx); err != nil {
+			return err
+		}
+		state.password.Store(password)
+	}
+
+	if password == "" {
+		return nil
+	}
+
+	err := auth(conn, r.username, password)
+	if err == nil || r.credentials == nil || !isAuthError(err) {
+		return err
+	}
+
+	if password, err = r.fetchPassword(ctx); err != nil {
+		return err
+	}
+	state.password.Store(password)
+
+	return auth(conn, r.username, password)
+}
+
+// auth send AUTH (with ACL username if it is set) before the client initializes the connection, so that SELECT and OnConnect are authenticated
+func auth(conn net.Conn, username, password string) error {
+	conn.SetDeadline(time.Now().Add(defaultDialTimeout))
+	defer conn.SetDeadline(time.Time{})
+
+	var err error
+	if username != "" {
+		_, err = fmt.Fprintf(conn, "*3\r\n$4\r\nAUTH\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n", len(username), username, len(password), password)
+	} else {
+		_, err = fmt.Fprintf(conn, "*2\r\n$4\r\nAUTH\r\n$%d\r\n%s\r\n", len(password), password)
+	}
+	if err != nil {
+		return err
+	}
+
+	// read byte by byte, nothing after the reply line may be consumed
+	line := make([]byte, 0, 8)
+	for b := make([]byte, 1); len(line) == 0 || line[len(line)-1] != '\n'; {
+		if _, err := conn.Read(b); err != nil {
+			return err
+		}
+		line = append(line, b[0])
+	}
+
+	if line[0] == '-' {
+		return errors.New(strings.TrimSpace(string(line[1:])))
+	}
+
+	return nil
+}
+
+func (s *reloadState) track(conn net.Conn) net.Conn {
+	tc := &trackedConn{
+		Conn:  conn,
+		gen:   atomic.LoadUint64(&s.generation),
+		state: s,
+	}
+
+	s.connsMu.Lock()
+	if s.conns == nil {
+		s.conns = make(map[*trackedConn]struct{})
+	}
+	s.conns[tc] = struct{}{}
+	s.connsMu.Unlock()
+
+	return tc
+}
+
+// drain close connections dialed before generation gen
+func (s *reloadState) drain(gen uint64) {
+	s.connsMu.Lock()
+	stale := make([]*trackedConn, 0)
+	for tc := range s.conns {
+		if tc.gen < gen {
+			stale = append(stale, tc)
+		}
+	}
+	s.connsMu.Unlock()
+
+	for _, tc := range stale {
+		tc.Close()
+	}
+}
+
+// Close close connection and stop tracking it
+func (tc *trackedConn) Close() error {
+	tc.once.Do(func() {
+		tc.state.connsMu.Lock()
+		delete(tc.state.conns, tc)
+		tc.state.connsMu.Unlock()
+	})
+
+	return tc.Conn.Close()
+}
@@ -0,0 +1,266 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type (
+	// CachedRepository cache of entities of a source of truth, e.g. a database table.
+	// Reads load missed entities by the loader, updates are written to the source by the writer then cached (write-through),
+	// or cached then written by a background flusher (write-behind).
+	CachedRepository struct {
+		cache   *Cache
+		name    string
+		load    RepositoryLoader
+		write   RepositoryWriter
+		options RepositoryOptions
+
+		mu      sync.Mutex
+		pending map[string]interface{} // queued writes of write-behind, the last update of an entity wins
+		flushMu sync.Mutex
+		done    chan struct{}
+		closed  sync.Once
+		wg      sync.WaitGroup
+
+		counter *prometheus.CounterVec
+	}
+
+	// RepositoryLoader load entity id from the source
+	RepositoryLoader func(ctx context.Context, id string) (interface{}, error)
+
+	// RepositoryWriter write entity id to the source
+	RepositoryWriter func(ctx context.Context, id string, v interface{}) error
+
+	// RepositoryOptions options of cached repository
+	RepositoryOptions struct {
+		TTL           time.Duration                                // ttl of cached entities, default 10m
+		TTLPolicy     func(id string, v interface{}) time.Duration // ttl per entity, TTL is used when it returns 0
+		Codec         Codec                                        // JSONCodec by default
+		WriteBehind   bool                                         // cache updates and write them to the source in background
+		FlushInterval time.Duration                                // flush interval of write-behind, default 1s
+		QueueSize     int                                          // max queued entities of write-behind, a Save flushes when it is full, default 1024
+	}
+)
+
+// Results of the repository metrics
+const (
+	RepositoryHit        = "hit"
+	RepositoryMiss       = "miss"
+	RepositoryWrite      = "write"
+	RepositoryWriteError = "write_error"
+)
+
+var (
+	// ErrRepositoryClosed save after the write-behind repository is closed
+	ErrRepositoryClosed = errors.New("redis: repository is closed")
+)
+
+// NewCachedRepository new a cached repository, keys of entities are prefixed by prefix which also names its metrics.
+// Close a write-behind repository to flush queued updates.
+func NewCachedRepository(r *Redis, prefix string, load RepositoryLoader, write RepositoryWriter, opts ...RepositoryOptions) *CachedRepository {
+	var options RepositoryOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	if options.TTL <= 0 {
+		options.TTL = 10 * time.Minute
+	}
+	if options.FlushInterval <= 0 {
+		options.FlushInterval = time.Second
+	}
+	if options.QueueSize <= 0 {
+		options.QueueSize = 1024
+	}
+
+	repo := &CachedRepository{
+		cache:   NewCache(r, prefix, options.Codec),
+		name:    prefix,
+		load:    load,
+		write:   write,
+		options: options,
+		pending: make(map[string]interface{}),
+		done:    make(chan struct{}),
+	}
+
+	if r.Metrics {
+		repo.counter = mustRegister(prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: r.metrics.Namespace,
+				Subsystem: r.metrics.Subsystem,
+				Name:      "redis_repository_requests_total",
+				Help:      "redis cached repository requests by result total",
+			},
+			[]string{"redis_instance", "repository", "result"},
+		)).(*prometheus.CounterVec)
+	}
+
+	if options.WriteBehind {
+		repo.wg.Add(1)
+		go repo.flushLoop()
+	}
+
+	return repo
+}
+
+// Get decode entity id into v, load it from the source and cache it when missed.
+func (repo *CachedRepository) Get(ctx context.Context, id string, v interface{}) error {
+	c := repo.cache
+
+	if ok, err := c.Get(ctx, id, v); err != nil || ok {
+		if ok {
+			repo.count(RepositoryHit)
+		}
+		return err
+	}
+	repo.count(RepositoryMiss)
+
+	if err := c.redis.waitLoad(ctx); err != nil {
+		return err
+	}
+
+	val, err := repo.load(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	data, err := c.store(ctx, id, val, repo.ttl(id, val))
+	if err != nil {
+		return err
+	}
+
+	return c.decode(id, data, v)
+}
+
+// Save update entity id. Write-through writes the source then caches v, the cached entity is invalidated when caching failed.
+// Write-behind caches v and queues it for the flusher.
+func (repo *CachedRepository) Save(ctx context.Context, id string, v interface{}) error {
+	if repo.options.WriteBehind {
+		return repo.enqueue(ctx, id, v)
+	}
+
+	if err := repo.write(ctx, id, v); err != nil {
+		repo.count(RepositoryWriteError)
+		return err
+	}
+	repo.count(RepositoryWrite)
+
+	if err := repo.cache.Set(ctx, id, v, repo.ttl(id, v)); err != nil {
+		repo.cache.Delete(ctx, id)
+		return err
+	}
+
+	return nil
+}
+
+// Invalidate delete cached entities, they are loaded from the source on next reads.
+// Queued writes of write-behind are still flushed.
+func (repo *CachedRepository) Invalidate(ctx context.Context, ids ...string) error {
+	return repo.cache.Delete(ctx, ids...)
+}
+
+// Flush write queued updates of write-behind to the source. An entity failed to write is invalidated,
+// so reads go to the source instead of serving the lost update, the first error is returned.
+func (repo *CachedRepository) Flush(ctx context.Context) error {
+	repo.flushMu.Lock()
+	defer repo.flushMu.Unlock()
+
+	repo.mu.Lock()
+	pending := repo.pending
+	repo.pending = make(map[string]interface{}, len(pending))
+	repo.mu.Unlock()
+
+	var first error
+	for id, v := range pending {
+		if err := repo.write(ctx, id, v); err != nil {
+			repo.count(RepositoryWriteError)
+			repo.cache.redis.logf("repository %s: write %s: %v", repo.name, id, err)
+			repo.cache.Delete(ctx, id)
+
+			if first == nil {
+				first = err
+			}
+			continue
+		}
+		repo.count(RepositoryWrite)
+	}
+
+	return first
+}
+
+// Close stop the flusher of write-behind and flush queued updates
+func (repo *CachedRepository) Close(ctx context.Context) error {
+	if !repo.options.WriteBehind {
+		return nil
+	}
+
+	repo.closed.Do(func() {
+		close(repo.done)
+	})
+	repo.wg.Wait()
+
+	return repo.Flush(ctx)
+}
+
+func (repo *CachedRepository) enqueue(ctx context.Context, id string, v interface{}) error {
+	select {
+	case <-repo.done:
+		return ErrRepositoryClosed
+	default:
+	}
+
+	if err := repo.cache.Set(ctx, id, v, repo.ttl(id, v)); err != nil {
+		return err
+	}
+
+	repo.mu.Lock()
+	repo.pending[id] = v
+	full := len(repo.pending) >= repo.options.QueueSize
+	repo.mu.Unlock()
+
+	// the queue is bounded, writers are slowed down to the speed of the source
+	if full {
+		return repo.Flush(ctx)
+	}
+
+	return nil
+}
+
+func (repo *CachedRepository) flushLoop() {
+	defer repo.wg.Done()
+
+	ticker := time.NewTicker(repo.options.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-repo.done:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), repo.options.FlushInterval*10)
+		repo.Flush(ctx)
+		cancel()
+	}
+}
+
+func (repo *CachedRepository) ttl(id string, v interface{}) time.Duration {
+	if repo.options.TTLPolicy != nil {
+		if ttl := repo.options.TTLPolicy(id, v); ttl > 0 {
+			return ttl
+		}
+	}
+
+	return repo.options.TTL
+}
+
+func (repo *CachedRepository) count(result string) {
+	if repo.counter != nil {
+		repo.counter.WithLabelValues(repo.cache.redis.Instance(), repo.name, result).Inc()
+	}
+}
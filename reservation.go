@@ -0,0 +1,146 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+type (
+	// Reservation stock reservation with holds, holds release automatically on expiry unless confirmed.
+	Reservation struct {
+		redis  *Redis
+		prefix string
+	}
+)
+
+var (
+	// ErrHoldNotFound hold is not found, it has been confirmed, released or expired
+	ErrHoldNotFound = errors.New("redis: hold not found")
+	// ErrInvalidHold quantity or hold ttl of a reservation is not positive
+	ErrInvalidHold = errors.New("redis: reservation quantity and hold ttl must be positive")
+)
+
+// reservationReap give expired holds back to stock.
+// KEYS: stock, holds zset, hold qty hash. ARGV[1]: now ms
+const reservationReap = `
+local expired = redis.call('zrangebyscore', KEYS[2], '-inf', ARGV[1])
+for _, id in ipairs(expired) do
+	local q = redis.call('hget', KEYS[3], id)
+	if q then
+		redis.call('incrby', KEYS[1], q)
+	end
+	redis.call('hdel', KEYS[3], id)
+	redis.call('zrem', KEYS[2], id)
+end
+`
+
+var (
+	// ARGV: now ms, qty, expire at ms, hold id
+	reservationReserveScript = newScript(reservationReap + `
+local qty = tonumber(ARGV[2])
+local stock = tonumber(redis.call('get', KEYS[1]) or '0')
+if stock < qty then
+	return 0
+end
+redis.call('decrby', KEYS[1], qty)
+redis.call('zadd', KEYS[2], ARGV[3], ARGV[4])
+redis.call('hset', KEYS[3], ARGV[4], qty)
+return 1
+`)
+
+	// ARGV: now ms, hold id, give back (1 release, 0 confirm)
+	reservationSettleScript = newScript(reservationReap + `
+local q = redis.call('hget', KEYS[3], ARGV[2])
+if not q then
+	return 0
+end
+if ARGV[3] == '1' then
+	redis.call('incrby', KEYS[1], q)
+end
+redis.call('hdel', KEYS[3], ARGV[2])
+redis.call('zrem', KEYS[2], ARGV[2])
+return 1
+`)
+
+	// ARGV: now ms
+	reservationAvailableScript = newScript(reservationReap + `
+return tonumber(redis.call('get', KEYS[1]) or '0')
+`)
+)
+
+// NewReservation new a stock reservation helper, keys are prefixed by prefix.
+func NewReservation(r *Redis, prefix string) *Reservation {
+	return &Reservation{
+		redis:  r,
+		prefix: prefix,
+	}
+}
+
+// SetStock set available stock of sku.
+func (res *Reservation) SetStock(ctx context.Context, sku string, qty int64) error {
+	return res.redis.DoContext(ctx, "set", res.stockKey(sku), qty).Err()
+}
+
+// Available stock of sku, expired holds are returned to stock first.
+func (res *Reservation) Available(ctx context.Context, sku string) (int64, error) {
+	return reservationAvailableScript.run(ctx, res.redis, res.keys(sku), unixMilli(time.Now())).Int64()
+}
+
+// TryReserve decrement stock by qty atomically and hold it for holdTTL.
+// ok is false when stock is insufficient, ErrInvalidHold is returned when qty or holdTTL is not positive.
+func (res *Reservation) TryReserve(ctx context.Context, sku string, qty int64, holdTTL time.Duration) (holdID string, ok bool, err error) {
+	if qty <= 0 || holdTTL <= 0 {
+		return "", false, ErrInvalidHold
+	}
+
+	now := time.Now()
+	holdID = randomID()
+
+	n, err := reservationReserveScript.run(ctx, res.redis, res.keys(sku), unixMilli(now), qty, unixMilli(now.Add(holdTTL)), holdID).Int64()
+	if err != nil || n == 0 {
+		return "", false, err
+	}
+
+	return holdID, true, nil
+}
+
+// Confirm the hold, the reserved stock is consumed.
+func (res *Reservation) Confirm(ctx context.Context, sku, holdID string) error {
+	return res.settle(ctx, sku, holdID, false)
+}
+
+// Release the hold, the reserved stock is returned.
+func (res *Reservation) Release(ctx context.Context, sku, holdID string) error {
+	return res.settle(ctx, sku, holdID, true)
+}
+
+func (res *Reservation) settle(ctx context.Context, sku, holdID string, giveBack bool) error {
+	back := 0
+	if giveBack {
+		back = 1
+	}
+
+	n, err := reservationSettleScript.run(ctx, res.redis, res.keys(sku), unixMilli(time.Now()), holdID, back).Int64()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrHoldNotFound
+	}
+
+	return nil
+}
+
+// keys use hash tag so that all keys of a sku are in the same cluster slot.
+func (res *Reservation) keys(sku string) []string {
+	return []string{
+		res.stockKey(sku),
+		res.prefix + ":{" + sku + "}:holds",
+		res.prefix + ":{" + sku + "}:hold",
+	}
+}
+
+func (res *Reservation) stockKey(sku string) string {
+	return res.prefix + ":{" + sku + "}:stock"
+}
@@ -0,0 +1,154 @@
+package redis_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/boxgo/redis"
+	"github.com/boxgo/redis/redistest"
+)
+
+// KEYS: stock, holds zset, hold qty hash
+func newReservationServer(t *testing.T) (*redis.Redis, *redistest.Server) {
+	r, srv := redistest.New(t)
+
+	reap := func(call func(args ...string) (interface{}, error), keys []string, now string) {
+		expired, _ := call("zrangebyscore", keys[1], "-inf", now)
+		for _, id := range expired.([]interface{}) {
+			if q, _ := call("hget", keys[2], id.(string)); q != nil {
+				call("incrby", keys[0], q.(string))
+			}
+			call("hdel", keys[2], id.(string))
+			call("zrem", keys[1], id.(string))
+		}
+	}
+	stock := func(call func(args ...string) (interface{}, error), keys []string) int64 {
+		v, _ := call("get", keys[0])
+		s, _ := v.(string)
+		n, _ := strconv.ParseInt(s, 10, 64)
+		return n
+	}
+
+	srv.Script(redis.ReservationReserveScript, func(call func(args ...string) (interface{}, error), keys, args []string) (interface{}, error) {
+		reap(call, keys, args[0])
+
+		qty, _ := strconv.ParseInt(args[1], 10, 64)
+		if stock(call, keys) < qty {
+			return int64(0), nil
+		}
+		call("decrby", keys[0], args[1])
+		call("zadd", keys[1], args[2], args[3])
+		call("hset", keys[2], args[3], args[1])
+		return int64(1), nil
+	})
+	srv.Script(redis.ReservationSettleScript, func(call func(args ...string) (interface{}, error), keys, args []string) (interface{}, error) {
+		reap(call, keys, args[0])
+
+		q, _ := call("hget", keys[2], args[1])
+		if q == nil {
+			return int64(0), nil
+		}
+		if args[2] == "1" {
+			call("incrby", keys[0], q.(string))
+		}
+		call("hdel", keys[2], args[1])
+		call("zrem", keys[1], args[1])
+		return int64(1), nil
+	})
+	srv.Script(redis.ReservationAvailableScript, func(call func(args ...string) (interface{}, error), keys, args []string) (interface{}, error) {
+		reap(call, keys, args[0])
+
+		return stock(call, keys), nil
+	})
+
+	return r, srv
+}
+
+func TestReservation(t *testing.T) {
+	r, _ := newReservationServer(t)
+	ctx := context.Background()
+	res := redis.NewReservation(r, "stock")
+
+	if err := res.SetStock(ctx, "sku", 5); err != nil {
+		t.Fatal(err)
+	}
+
+	confirmed, ok, err := res.TryReserve(ctx, "sku", 3, time.Minute)
+	if err != nil || !ok || confirmed == "" {
+		t.Fatalf("TryReserve of available stock got %q %v %v", confirmed, ok, err)
+	}
+	if _, ok, err := res.TryReserve(ctx, "sku", 3, time.Minute); err != nil || ok {
+		t.Fatalf("TryReserve of insufficient stock got %v %v", ok, err)
+	}
+	released, ok, err := res.TryReserve(ctx, "sku", 2, time.Minute)
+	if err != nil || !ok {
+		t.Fatalf("TryReserve of the remaining stock got %v %v", ok, err)
+	}
+
+	if n, err := res.Available(ctx, "sku"); err != nil || n != 0 {
+		t.Fatalf("Available with all stock held got %d %v", n, err)
+	}
+
+	if err := res.Confirm(ctx, "sku", confirmed); err != nil {
+		t.Fatal(err)
+	}
+	if err := res.Release(ctx, "sku", released); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := res.Available(ctx, "sku"); err != nil || n != 2 {
+		t.Fatalf("Available after confirm and release got %d %v, want 2", n, err)
+	}
+
+	for _, id := range []string{confirmed, released, "unknown"} {
+		if err := res.Release(ctx, "sku", id); err != redis.ErrHoldNotFound {
+			t.Errorf("Release of settled hold %s expected ErrHoldNotFound, got %v", id, err)
+		}
+	}
+}
+
+func TestReservationExpired(t *testing.T) {
+	r, _ := newReservationServer(t)
+	ctx := context.Background()
+	res := redis.NewReservation(r, "stock")
+
+	if err := res.SetStock(ctx, "sku", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	hold, ok, err := res.TryReserve(ctx, "sku", 1, 50*time.Millisecond)
+	if err != nil || !ok {
+		t.Fatalf("TryReserve got %v %v", ok, err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	if n, err := res.Available(ctx, "sku"); err != nil || n != 1 {
+		t.Fatalf("Available after the hold expired got %d %v, want 1", n, err)
+	}
+	if err := res.Confirm(ctx, "sku", hold); err != redis.ErrHoldNotFound {
+		t.Fatalf("Confirm of an expired hold expected ErrHoldNotFound, got %v", err)
+	}
+}
+
+func TestReservationInvalid(t *testing.T) {
+	r, srv := newReservationServer(t)
+	ctx := context.Background()
+	res := redis.NewReservation(r, "stock")
+
+	for _, tt := range []struct {
+		qty int64
+		ttl time.Duration
+	}{
+		{0, time.Minute},
+		{-1, time.Minute},
+		{1, 0},
+		{1, -time.Second},
+	} {
+		if _, _, err := res.TryReserve(ctx, "sku", tt.qty, tt.ttl); err != redis.ErrInvalidHold {
+			t.Errorf("TryReserve of %d for %s expected ErrInvalidHold, got %v", tt.qty, tt.ttl, err)
+		}
+	}
+	srv.AssertNotCalled(t, "evalsha")
+}
@@ -0,0 +1,150 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// RetryBudget retries allowed across all calls sharing a request context, so that a partial outage is not amplified
+	// by every layer retrying on its own.
+	RetryBudget struct {
+		mu    sync.Mutex
+		limit int
+		used  int
+	}
+
+	retryBudgetKey struct{}
+)
+
+var (
+	// ErrRetryBudgetExhausted retry refused because the request used up its retry budget
+	ErrRetryBudgetExhausted = errors.New("redis: retry budget exhausted")
+)
+
+const (
+	defaultRetryAttempts = 3
+	defaultRetryBackoff  = 10 * time.Millisecond
+)
+
+// WithRetryBudget ctx allowing at most retries retries in Retry and Transaction calls made with it or its children.
+func WithRetryBudget(ctx context.Context, retries int) context.Context {
+	return context.WithValue(ctx, retryBudgetKey{}, &RetryBudget{limit: retries})
+}
+
+// RetryBudgetFrom retry budget of ctx, nil when ctx has no budget
+func RetryBudgetFrom(ctx context.Context) *RetryBudget {
+	budget, _ := ctx.Value(retryBudgetKey{}).(*RetryBudget)
+
+	return budget
+}
+
+// Used retries used
+func (b *RetryBudget) Used() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.used
+}
+
+// Remaining retries left
+func (b *RetryBudget) Remaining() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.limit - b.used
+}
+
+// take a retry from the budget, a nil budget is unlimited
+func (b *RetryBudget) take() bool {
+	if b == nil {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.used >= b.limit {
+		return false
+	}
+	b.used++
+
+	return true
+}
+
+// Retry run fn at most retryAttempts times while it fails with a transient error (timeout, connection refused, READONLY, LOADING, TRYAGAIN, CLUSTERDOWN).
+// Each attempt is bounded by retryTimeout doubled after every attempt, and waits jittered exponential backoff before it.
+// Retries are taken from the retry budget of ctx, when it is exhausted the last error is returned wrapped in ErrRetryBudgetExhausted.
+// Set maxRetries to 0 when budgets are used, or commands are still retried by the client itself.
+func (r *Redis) Retry(ctx context.Context, fn func(ctx context.Context) error) error {
+	attempts := r.RetryAttempts
+	if attempts <= 0 {
+		attempts = defaultRetryAttempts
+	}
+
+	backoff := r.RetryBackoff
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+
+	budget := RetryBudgetFrom(ctx)
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if !budget.take() {
+				return fmt.Errorf("%w: %v", ErrRetryBudgetExhausted, err)
+			}
+
+			wait := backoff << uint(attempt-1)
+			wait = wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+
+		err = r.attempt(ctx, attempt, fn)
+		if err == nil || ctx.Err() != nil || !retryable(err) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// attempt run fn with the progressive timeout of attempt
+func (r *Redis) attempt(ctx context.Context, attempt int, fn func(ctx context.Context) error) error {
+	if r.RetryTimeout <= 0 {
+		return fn(ctx)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.RetryTimeout<<uint(attempt))
+	defer cancel()
+
+	return fn(ctx)
+}
+
+// retryable transient error which may succeed on retry
+func retryable(err error) bool {
+	switch ErrorClass(err) {
+	case ErrClassTimeout, ErrClassConnRefused, ErrClassReadOnly:
+		return true
+	}
+
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return true
+	}
+
+	msg := err.Error()
+
+	return strings.HasPrefix(msg, "LOADING") || strings.HasPrefix(msg, "TRYAGAIN") || strings.HasPrefix(msg, "CLUSTERDOWN")
+}
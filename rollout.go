@@ -0,0 +1,125 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Rollout features of this package, a behavior enabled by config is gated by its feature once a percent is declared
+const (
+	FeatureCoalesce     = "coalesce"
+	FeatureReadReplicas = "readReplicas"
+	FeatureFallback     = "fallback"
+	FeatureCompression  = "compression"
+)
+
+// FeatureEnabled progressive rollout gate of this package's own behaviors.
+// Percentages come from config rollout and are overridden by the hash at rolloutKey.
+// Each process is bucketed by hostname and pid, so a feature is on for a stable subset of instances.
+func (r *Redis) FeatureEnabled(feature string) bool {
+	percent, ok := r.rolloutPercent(feature)
+
+	return ok && r.bucketed(feature, percent)
+}
+
+// rolledOut gate of behaviors already enabled by config: on while feature has no rollout percent,
+// otherwise on for the percent of instances only.
+func (r *Redis) rolledOut(feature string) bool {
+	percent, ok := r.rolloutPercent(feature)
+
+	return !ok || r.bucketed(feature, percent)
+}
+
+func (r *Redis) rolloutPercent(feature string) (int, bool) {
+	percent, ok := r.Rollout[feature]
+
+	if stored, _ := r.rolloutStored.Load().(map[string]int); stored != nil {
+		if p, exists := stored[feature]; exists {
+			percent, ok = p, true
+		}
+	}
+
+	return percent, ok
+}
+
+func (r *Redis) bucketed(feature string, percent int) bool {
+	if percent <= 0 {
+		return false
+	}
+	if percent >= 100 {
+		return true
+	}
+
+	return rolloutBucket(feature, instanceID()) < percent
+}
+
+// SetRollout store rollout percent of feature at rolloutKey, instances pick it up on next refresh.
+func (r *Redis) SetRollout(ctx context.Context, feature string, percent int) error {
+	if r.RolloutKey == "" {
+		return fmt.Errorf("redis: rolloutKey is not configured")
+	}
+
+	return r.DoContext(ctx, "hset", r.RolloutKey, feature, percent).Err()
+}
+
+// refreshRollout load rollout percentages at rolloutKey periodically until shutdown
+func (r *Redis) refreshRollout(done <-chan struct{}) {
+	interval := r.RolloutRefresh
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		r.loadRollout()
+
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Redis) loadRollout() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	val, err := r.DoContext(ctx, "hgetall", r.RolloutKey).Result()
+	if err != nil {
+		return
+	}
+
+	values, _ := val.([]interface{})
+	stored := make(map[string]int, len(values)/2)
+
+	for i := 0; i+1 < len(values); i += 2 {
+		percent, err := strconv.Atoi(fmt.Sprint(values[i+1]))
+		if err != nil {
+			continue
+		}
+		stored[fmt.Sprint(values[i])] = percent
+	}
+
+	r.rolloutStored.Store(stored)
+}
+
+func rolloutBucket(feature, instance string) int {
+	h := fnv.New32a()
+	h.Write([]byte(feature + ":" + instance))
+
+	return int(h.Sum32() % 100)
+}
+
+// instanceID hostname and pid of this process
+func instanceID() string {
+	hostname, _ := os.Hostname()
+
+	return fmt.Sprintf("%s:%d", hostname, os.Getpid())
+}
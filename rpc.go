@@ -0,0 +1,239 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	// RPC request-reply over pub/sub, requests are not persisted and are lost when no server is subscribed.
+	// Replies of an RPC are received on its own reply channel and matched by correlation id.
+	RPC struct {
+		redis   *Redis
+		prefix  string
+		timeout time.Duration
+		reply   string
+		once    sync.Once
+		ready   chan struct{}
+		err     error
+		mu      sync.Mutex
+		pending map[string]chan rpcMessage
+		pubsub  *redis.PubSub
+	}
+
+	// RPCHandler handle payload of a request, the returned error is sent back to the caller as RPCError
+	RPCHandler func(ctx context.Context, payload []byte) ([]byte, error)
+
+	// RPCError error returned by the remote handler
+	RPCError struct {
+		Message string
+	}
+
+	rpcMessage struct {
+		ID       string `json:"id"`
+		Reply    string `json:"reply,omitempty"`
+		Payload  []byte `json:"payload,omitempty"`
+		Error    string `json:"error,omitempty"`
+		Deadline int64  `json:"deadline,omitempty"`
+	}
+)
+
+var (
+	// ErrNoResponder no server is subscribed to the topic
+	ErrNoResponder = errors.New("redis: rpc no responder")
+)
+
+const (
+	defaultRPCTimeout = 5 * time.Second
+)
+
+// NewRPC new a rpc helper, channels are prefixed by prefix. timeout is used when ctx of Call has no deadline, default is 5s.
+func NewRPC(r *Redis, prefix string, timeout time.Duration) *RPC {
+	if timeout <= 0 {
+		timeout = defaultRPCTimeout
+	}
+
+	return &RPC{
+		redis:   r,
+		prefix:  prefix,
+		timeout: timeout,
+		reply:   prefix + ":rpc:reply:" + randomID(),
+		ready:   make(chan struct{}),
+		pending: make(map[string]chan rpcMessage),
+	}
+}
+
+// Call publish payload to topic and wait for the reply.
+// Err is ErrNoResponder when no server is subscribed, *RPCError when the handler failed.
+func (c *RPC) Call(ctx context.Context, topic string, payload []byte) ([]byte, error) {
+	c.once.Do(c.listen)
+	<-c.ready
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	deadline, _ := ctx.Deadline()
+
+	req := rpcMessage{
+		ID:       randomID(),
+		Reply:    c.reply,
+		Payload:  payload,
+		Deadline: unixMilli(deadline),
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan rpcMessage, 1)
+	c.mu.Lock()
+	c.pending[req.ID] = ch
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, req.ID)
+		c.mu.Unlock()
+	}()
+
+	n, err := c.redis.DoContext(ctx, "publish", c.channel(topic), data).Int64()
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, ErrNoResponder
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case resp := <-ch:
+		if resp.Error != "" {
+			return nil, &RPCError{Message: resp.Error}
+		}
+		return resp.Payload, nil
+	}
+}
+
+// Serve handle requests of topic concurrently and reply, block until ctx done.
+// ctx of handler expires at the caller's deadline.
+func (c *RPC) Serve(ctx context.Context, topic string, handler RPCHandler) error {
+	ps := c.redis.Subscribe(c.channel(topic))
+	defer ps.Close()
+
+	if _, err := ps.Receive(); err != nil {
+		return err
+	}
+
+	ch := ps.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-ch:
+			if !ok {
+				return nil
+			}
+
+			req := rpcMessage{}
+			if err := json.Unmarshal([]byte(msg.Payload), &req); err != nil || req.Reply == "" {
+				continue
+			}
+
+			go c.handle(ctx, req, handler)
+		}
+	}
+}
+
+// Close stop receiving replies, pending calls time out
+func (c *RPC) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.pubsub == nil {
+		return nil
+	}
+
+	return c.pubsub.Close()
+}
+
+func (c *RPC) handle(ctx context.Context, req rpcMessage, handler RPCHandler) {
+	if req.Deadline > 0 {
+		deadline := time.Unix(0, req.Deadline*int64(time.Millisecond))
+		if time.Now().After(deadline) {
+			return
+		}
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+
+	payload, err := handler(ctx, req.Payload)
+
+	resp := rpcMessage{ID: req.ID, Payload: payload}
+	if err != nil {
+		resp = rpcMessage{ID: req.ID, Error: err.Error()}
+	}
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return
+	}
+
+	c.redis.DoContext(ctx, "publish", req.Reply, data)
+}
+
+func (c *RPC) listen() {
+	defer close(c.ready)
+
+	ps := c.redis.Subscribe(c.reply)
+	if _, err := ps.Receive(); err != nil {
+		ps.Close()
+		c.err = err
+		return
+	}
+
+	c.mu.Lock()
+	c.pubsub = ps
+	c.mu.Unlock()
+
+	go func() {
+		for msg := range ps.Channel() {
+			resp := rpcMessage{}
+			if err := json.Unmarshal([]byte(msg.Payload), &resp); err != nil {
+				continue
+			}
+
+			c.mu.Lock()
+			ch := c.pending[resp.ID]
+			c.mu.Unlock()
+
+			if ch != nil {
+				select {
+				case ch <- resp:
+				default:
+				}
+			}
+		}
+	}()
+}
+
+func (c *RPC) channel(topic string) string {
+	return c.prefix + ":rpc:" + topic
+}
+
+func (e *RPCError) Error() string {
+	return "redis: rpc remote error: " + e.Message
+}
@@ -0,0 +1,214 @@
+package redis
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	// RWLock read-write lock: many readers or one writer.
+	// A waiting writer blocks new readers, so writers are not starved. Readers missing refresh for ttl are expired.
+	RWLock struct {
+		locker *Locker
+		name   string
+		owner  string
+	}
+)
+
+var (
+	// KEYS[1]: lock hash, KEYS[2]: writer intent. ARGV[1]: owner, ARGV[2]: now ms, ARGV[3]: ttl ms.
+	// hash fields: mode, r:<owner> read count, t:<owner> reader expiry, w:<owner> writer
+	rlockScript = newScript(`
+local fields = redis.call('hgetall', KEYS[1])
+for i = 1, #fields, 2 do
+	local field = fields[i]
+	if string.sub(field, 1, 2) == 't:' and tonumber(fields[i + 1]) <= tonumber(ARGV[2]) then
+		redis.call('hdel', KEYS[1], field, 'r:' .. string.sub(field, 3))
+	end
+end
+if redis.call('hlen', KEYS[1]) == 1 then
+	redis.call('del', KEYS[1])
+end
+local mode = redis.call('hget', KEYS[1], 'mode')
+local held = redis.call('hexists', KEYS[1], 'r:' .. ARGV[1]) == 1
+if mode == 'write' or (not held and redis.call('exists', KEYS[2]) == 1) then
+	return 0
+end
+redis.call('hset', KEYS[1], 'mode', 'read')
+redis.call('hincrby', KEYS[1], 'r:' .. ARGV[1], 1)
+redis.call('hset', KEYS[1], 't:' .. ARGV[1], ARGV[2] + ARGV[3])
+redis.call('pexpire', KEYS[1], ARGV[3])
+return 1
+`)
+
+	// KEYS[1]: lock hash, KEYS[2]: writer intent. ARGV[1]: owner, ARGV[2]: now ms, ARGV[3]: ttl ms
+	wlockScript = newScript(`
+local fields = redis.call('hgetall', KEYS[1])
+for i = 1, #fields, 2 do
+	local field = fields[i]
+	if string.sub(field, 1, 2) == 't:' and tonumber(fields[i + 1]) <= tonumber(ARGV[2]) then
+		redis.call('hdel', KEYS[1], field, 'r:' .. string.sub(field, 3))
+	end
+end
+if redis.call('hlen', KEYS[1]) == 1 then
+	redis.call('del', KEYS[1])
+end
+if redis.call('exists', KEYS[1]) == 1 then
+	redis.call('set', KEYS[2], ARGV[1], 'px', ARGV[3])
+	return 0
+end
+redis.call('hset', KEYS[1], 'mode', 'write', 'w:' .. ARGV[1], 1)
+redis.call('pexpire', KEYS[1], ARGV[3])
+if redis.call('get', KEYS[2]) == ARGV[1] then
+	redis.call('del', KEYS[2])
+end
+return 1
+`)
+
+	// KEYS[1]: lock hash. ARGV[1]: owner. returns -1 when not held
+	runlockScript = newScript(`
+if redis.call('hexists', KEYS[1], 'r:' .. ARGV[1]) == 0 then
+	return -1
+end
+local n = redis.call('hincrby', KEYS[1], 'r:' .. ARGV[1], -1)
+if n <= 0 then
+	redis.call('hdel', KEYS[1], 'r:' .. ARGV[1], 't:' .. ARGV[1])
+end
+if redis.call('hlen', KEYS[1]) == 1 then
+	redis.call('del', KEYS[1])
+end
+return n
+`)
+
+	// KEYS[1]: lock hash. ARGV[1]: owner
+	wunlockScript = newScript(`
+if redis.call('hexists', KEYS[1], 'w:' .. ARGV[1]) == 0 then
+	return -1
+end
+return redis.call('del', KEYS[1])
+`)
+
+	// KEYS[1]: lock hash. ARGV[1]: owner, ARGV[2]: now ms, ARGV[3]: ttl ms
+	rrefreshScript = newScript(`
+if redis.call('hexists', KEYS[1], 'r:' .. ARGV[1]) == 0 then
+	return 0
+end
+redis.call('hset', KEYS[1], 't:' .. ARGV[1], ARGV[2] + ARGV[3])
+local ttl = redis.call('pttl', KEYS[1])
+if ttl < tonumber(ARGV[3]) then
+	redis.call('pexpire', KEYS[1], ARGV[3])
+end
+return 1
+`)
+)
+
+// RWLock read-write lock name, each RWLock value is an owner, read locks of an owner are reentrant
+func (l *Locker) RWLock(name string) *RWLock {
+	return &RWLock{
+		locker: l,
+		name:   name,
+		owner:  randomID(),
+	}
+}
+
+// TryRLock read lock once, ErrNotObtained is returned when it is write locked or a writer is waiting
+func (rw *RWLock) TryRLock(ctx context.Context) error {
+	return rw.try(ctx, rlockScript)
+}
+
+// RLock read lock, retry until locked or ctx is done
+func (rw *RWLock) RLock(ctx context.Context) error {
+	return rw.wait(ctx, rw.TryRLock)
+}
+
+// TryLock write lock once, ErrNotObtained is returned when it is locked
+func (rw *RWLock) TryLock(ctx context.Context) error {
+	return rw.try(ctx, wlockScript)
+}
+
+// Lock write lock, retry until locked or ctx is done
+func (rw *RWLock) Lock(ctx context.Context) error {
+	return rw.wait(ctx, rw.TryLock)
+}
+
+// RUnlock release a read lock, ErrLockNotHeld is returned when the owner does not hold it
+func (rw *RWLock) RUnlock(ctx context.Context) error {
+	n, err := runlockScript.run(ctx, rw.locker.redis, []string{rw.key()}, rw.owner).Int64()
+	if err == nil && n < 0 {
+		err = ErrLockNotHeld
+	}
+
+	return err
+}
+
+// Unlock release the write lock, ErrLockNotHeld is returned when the owner does not hold it
+func (rw *RWLock) Unlock(ctx context.Context) error {
+	n, err := wunlockScript.run(ctx, rw.locker.redis, []string{rw.key()}, rw.owner).Int64()
+	if err == nil && n < 0 {
+		err = ErrLockNotHeld
+	}
+
+	return err
+}
+
+// RRefresh extend ttl of read lock of the owner
+func (rw *RWLock) RRefresh(ctx context.Context) error {
+	l := rw.locker
+
+	n, err := rrefreshScript.run(ctx, l.redis, []string{rw.key()}, rw.owner, unixMilli(time.Now()), l.ttl.Milliseconds()).Int64()
+	if err == nil && n == 0 {
+		err = ErrLockNotHeld
+	}
+
+	return err
+}
+
+// Refresh extend ttl of write lock of the owner
+func (rw *RWLock) Refresh(ctx context.Context) error {
+	l := rw.locker
+
+	n, err := refreshHashLockScript.run(ctx, l.redis, []string{rw.key()}, "w:"+rw.owner, l.ttl.Milliseconds()).Int64()
+	if err == nil && n == 0 {
+		err = ErrLockNotHeld
+	}
+
+	return err
+}
+
+func (rw *RWLock) try(ctx context.Context, s *script) error {
+	l := rw.locker
+
+	n, err := s.run(ctx, l.redis, rw.keys(), rw.owner, unixMilli(time.Now()), l.ttl.Milliseconds()).Int64()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotObtained
+	}
+
+	return nil
+}
+
+func (rw *RWLock) wait(ctx context.Context, try func(ctx context.Context) error) error {
+	for {
+		err := try(ctx)
+		if err != ErrNotObtained {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(rw.locker.retry):
+		}
+	}
+}
+
+// keys use hash tag so that the lock and writer intent are in the same cluster slot.
+func (rw *RWLock) key() string {
+	return rw.locker.prefix + ":{" + rw.name + "}:rw"
+}
+
+func (rw *RWLock) keys() []string {
+	return []string{rw.key(), rw.locker.prefix + ":{" + rw.name + "}:writer"}
+}
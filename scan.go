@@ -0,0 +1,174 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	// DeleteOptions options of DeleteByPattern
+	DeleteOptions struct {
+		DryRun    bool             // only report matched keys, do not delete
+		BatchSize int              // keys unlinked per pipeline, default is 100
+		Rate      int              // max keys deleted per second, default is unlimited
+		OnKey     func(key string) // called for each matched key
+	}
+)
+
+// ScanKeys iterate keys matching pattern with SCAN on every master node, fn is never called concurrently.
+// count is the SCAN count hint, default is 100.
+func (r *Redis) ScanKeys(ctx context.Context, pattern string, count int64, fn func(key string) error) error {
+	if count <= 0 {
+		count = 100
+	}
+
+	mu := sync.Mutex{}
+
+	return r.forEachMaster(func(node redis.UniversalClient, addr string) error {
+		var cursor uint64
+
+		for {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			keys, next, err := node.Scan(cursor, pattern, count).Result()
+			if err != nil {
+				return err
+			}
+
+			for _, key := range keys {
+				mu.Lock()
+				err := fn(key)
+				mu.Unlock()
+
+				if err != nil {
+					return err
+				}
+			}
+
+			if next == 0 {
+				return nil
+			}
+			cursor = next
+		}
+	})
+}
+
+// DeleteByPattern delete keys matching pattern with SCAN and UNLINK in batches, return the number of matched keys.
+// Never use KEYS in production. Unless it is a dry run, ctx should carry a token of OpDeleteByPattern when opsKey is configured.
+func (r *Redis) DeleteByPattern(ctx context.Context, pattern string, opts ...DeleteOptions) (int64, error) {
+	opt := DeleteOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	if opt.DryRun {
+		return r.deleteByPattern(ctx, pattern, opt)
+	}
+
+	done, err := r.authorize(ctx, OpDeleteByPattern, pattern)
+	if err != nil {
+		return 0, err
+	}
+
+	total, err := r.deleteByPattern(ctx, pattern, opt)
+	done(err)
+
+	return total, err
+}
+
+// FlushNamespace delete all keys of namespace, i.e. keys prefixed by "namespace:".
+// ctx should carry a token of OpFlushNamespace when opsKey is configured.
+func (r *Redis) FlushNamespace(ctx context.Context, namespace string, opts ...DeleteOptions) (int64, error) {
+	if namespace == "" {
+		return 0, errors.New("redis: flush namespace: namespace is required")
+	}
+
+	opt := DeleteOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	target := namespace + ":*"
+	if opt.DryRun {
+		return r.deleteByPattern(ctx, target, opt)
+	}
+
+	done, err := r.authorize(ctx, OpFlushNamespace, target)
+	if err != nil {
+		return 0, err
+	}
+
+	total, err := r.deleteByPattern(ctx, target, opt)
+	done(err)
+
+	return total, err
+}
+
+func (r *Redis) deleteByPattern(ctx context.Context, pattern string, opt DeleteOptions) (int64, error) {
+	if opt.BatchSize <= 0 {
+		opt.BatchSize = 100
+	}
+
+	var (
+		total int64
+		batch = make([]string, 0, opt.BatchSize)
+		start = time.Now()
+	)
+
+	flush := func() error {
+		if len(batch) == 0 || opt.DryRun {
+			batch = batch[:0]
+			return nil
+		}
+
+		// keys of a batch may be in different slots, unlink them one by one in a pipeline
+		_, err := r.Pipelined(func(pipe redis.Pipeliner) error {
+			for _, key := range batch {
+				pipe.Unlink(key)
+			}
+			return nil
+		})
+		batch = batch[:0]
+		if err != nil {
+			return err
+		}
+
+		if opt.Rate > 0 {
+			expected := time.Duration(float64(total) / float64(opt.Rate) * float64(time.Second))
+			if wait := expected - time.Since(start); wait > 0 {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(wait):
+				}
+			}
+		}
+
+		return nil
+	}
+
+	err := r.ScanKeys(ctx, pattern, int64(opt.BatchSize), func(key string) error {
+		total++
+		if opt.OnKey != nil {
+			opt.OnKey(key)
+		}
+
+		batch = append(batch, key)
+		if len(batch) >= opt.BatchSize {
+			return flush()
+		}
+
+		return nil
+	})
+	if err != nil {
+		return total, err
+	}
+
+	return total, flush()
+}
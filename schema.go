@@ -0,0 +1,306 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	// Validator validate value written to key
+	Validator func(key string, value []byte) error
+
+	// ValidationError write rejected by schema validation
+	ValidationError struct {
+		Key    string
+		Prefix string
+		Err    error
+	}
+
+	// schemaRegistry validators and namespace policies by key prefix
+	schemaRegistry struct {
+		mu         sync.RWMutex
+		schemas    []schemaEntry
+		namespaces []namespaceEntry
+	}
+
+	schemaEntry struct {
+		prefix    string
+		validator Validator
+	}
+
+	schemaHook struct {
+		r *Redis
+	}
+
+	jsonSchema struct {
+		Type                 interface{}            `json:"type"`
+		Required             []string               `json:"required"`
+		Properties           map[string]*jsonSchema `json:"properties"`
+		AdditionalProperties *bool                  `json:"additionalProperties"`
+		Items                *jsonSchema            `json:"items"`
+		Enum                 []interface{}          `json:"enum"`
+		MinLength            *int                   `json:"minLength"`
+		MaxLength            *int                   `json:"maxLength"`
+		Minimum              *float64               `json:"minimum"`
+		Maximum              *float64               `json:"maximum"`
+	}
+)
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("redis: value of %s rejected by schema %s: %s", e.Key, e.Prefix, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// RegisterSchema validate values written by SET family commands to keys starting with prefix.
+// The longest matching prefix wins.
+func (r *Redis) RegisterSchema(prefix string, validator Validator) {
+	r.schemas.mu.Lock()
+	defer r.schemas.mu.Unlock()
+
+	r.schemas.schemas = append(r.schemas.schemas, schemaEntry{prefix: prefix, validator: validator})
+	sort.SliceStable(r.schemas.schemas, func(i, j int) bool {
+		return len(r.schemas.schemas[i].prefix) > len(r.schemas.schemas[j].prefix)
+	})
+}
+
+// JSONSchema validator of a JSON Schema subset:
+// type, required, properties, additionalProperties, items, enum, minLength, maxLength, minimum, maximum.
+func JSONSchema(schema []byte) (Validator, error) {
+	s := &jsonSchema{}
+	if err := json.Unmarshal(schema, s); err != nil {
+		return nil, err
+	}
+
+	return func(key string, value []byte) error {
+		var v interface{}
+		if err := json.Unmarshal(value, &v); err != nil {
+			return fmt.Errorf("malformed json: %w", err)
+		}
+
+		return s.validate("$", v)
+	}, nil
+}
+
+// validate value of key, nil when no schema matches
+func (sr *schemaRegistry) validate(key string, value []byte) error {
+	sr.mu.RLock()
+	defer sr.mu.RUnlock()
+
+	for _, entry := range sr.schemas {
+		if !strings.HasPrefix(key, entry.prefix) {
+			continue
+		}
+
+		if err := entry.validator(key, value); err != nil {
+			return &ValidationError{Key: key, Prefix: entry.prefix, Err: err}
+		}
+		return nil
+	}
+
+	return nil
+}
+
+func (sr *schemaRegistry) empty() bool {
+	sr.mu.RLock()
+	defer sr.mu.RUnlock()
+
+	return len(sr.schemas) == 0
+}
+
+func (h schemaHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	if isRaw(ctx) {
+		return ctx, nil
+	}
+
+	return ctx, h.check(cmd)
+}
+
+func (h schemaHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	return nil
+}
+
+func (h schemaHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	for _, cmd := range cmds {
+		if err := h.check(cmd); err != nil {
+			return ctx, err
+		}
+	}
+
+	return ctx, nil
+}
+
+func (h schemaHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	return nil
+}
+
+func (h schemaHook) check(cmd redis.Cmder) error {
+	if h.r.schemas.empty() {
+		return nil
+	}
+
+	for _, kv := range writtenValues(cmd) {
+		if err := h.r.schemas.validate(kv.key, kv.value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type keyValue struct {
+	key   string
+	value []byte
+}
+
+// writtenValues key/value pairs written by SET family commands
+func writtenValues(cmd redis.Cmder) []keyValue {
+	args := cmd.Args()
+	pair := func(k, v int) []keyValue {
+		if len(args) <= v {
+			return nil
+		}
+		return []keyValue{{key: fmt.Sprint(args[k]), value: argBytes(args[v])}}
+	}
+
+	switch strings.ToLower(cmd.Name()) {
+	case "set", "setnx", "getset":
+		return pair(1, 2)
+	case "setex", "psetex":
+		return pair(1, 3)
+	case "mset", "msetnx":
+		kvs := make([]keyValue, 0, len(args)/2)
+		for i := 1; i+1 < len(args); i += 2 {
+			kvs = append(kvs, keyValue{key: fmt.Sprint(args[i]), value: argBytes(args[i+1])})
+		}
+		return kvs
+	}
+
+	return nil
+}
+
+func argBytes(arg interface{}) []byte {
+	switch v := arg.(type) {
+	case []byte:
+		return v
+	case string:
+		return []byte(v)
+	default:
+		return []byte(fmt.Sprint(v))
+	}
+}
+
+func (s *jsonSchema) validate(path string, v interface{}) error {
+	if s.Type != nil && !s.typeMatches(v) {
+		return fmt.Errorf("%s: expected type %v", path, s.Type)
+	}
+
+	if len(s.Enum) > 0 {
+		found := false
+		for _, e := range s.Enum {
+			if fmt.Sprint(e) == fmt.Sprint(v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: value is not in enum", path)
+		}
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := val[name]; !ok {
+				return fmt.Errorf("%s: missing required property %s", path, name)
+			}
+		}
+		for name, prop := range val {
+			sub, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					return fmt.Errorf("%s: unexpected property %s", path, name)
+				}
+				continue
+			}
+			if err := sub.validate(path+"."+name, prop); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range val {
+				if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		if s.MinLength != nil && len([]rune(val)) < *s.MinLength {
+			return fmt.Errorf("%s: shorter than %d", path, *s.MinLength)
+		}
+		if s.MaxLength != nil && len([]rune(val)) > *s.MaxLength {
+			return fmt.Errorf("%s: longer than %d", path, *s.MaxLength)
+		}
+	case float64:
+		if s.Minimum != nil && val < *s.Minimum {
+			return fmt.Errorf("%s: less than %v", path, *s.Minimum)
+		}
+		if s.Maximum != nil && val > *s.Maximum {
+			return fmt.Errorf("%s: greater than %v", path, *s.Maximum)
+		}
+	}
+
+	return nil
+}
+
+func (s *jsonSchema) typeMatches(v interface{}) bool {
+	types := []string{}
+	switch t := s.Type.(type) {
+	case string:
+		types = append(types, t)
+	case []interface{}:
+		for _, item := range t {
+			types = append(types, fmt.Sprint(item))
+		}
+	}
+
+	for _, t := range types {
+		switch val := v.(type) {
+		case nil:
+			if t == "null" {
+				return true
+			}
+		case bool:
+			if t == "boolean" {
+				return true
+			}
+		case string:
+			if t == "string" {
+				return true
+			}
+		case float64:
+			if t == "number" || (t == "integer" && val == float64(int64(val))) {
+				return true
+			}
+		case []interface{}:
+			if t == "array" {
+				return true
+			}
+		case map[string]interface{}:
+			if t == "object" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
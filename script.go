@@ -0,0 +1,77 @@
+package redis
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"strings"
+	"sync"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	// script lua script executed by EVALSHA, fall back to EVAL when redis has not cached it
+	script struct {
+		src  string
+		hash string
+	}
+
+	// scripter client running scripts, *Redis and go-redis clients
+	scripter interface {
+		DoContext(ctx context.Context, args ...interface{}) *redis.Cmd
+	}
+)
+
+var (
+	// scriptsByHash scripts by sha1, for running EVALSHA commands on another server
+	scriptsByHash sync.Map
+
+	// compare and set keeping ttl. KEYS[1]: key. ARGV: old value, new value
+	casScript = newScript(`
+if redis.call('get', KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+local ttl = redis.call('pttl', KEYS[1])
+if ttl > 0 then
+	redis.call('set', KEYS[1], ARGV[2], 'px', ttl)
+else
+	redis.call('set', KEYS[1], ARGV[2])
+end
+return 1
+`)
+)
+
+func newScript(src string) *script {
+	sum := sha1.Sum([]byte(src))
+
+	s := &script{
+		src:  src,
+		hash: hex.EncodeToString(sum[:]),
+	}
+	scriptsByHash.Store(s.hash, s)
+
+	return s
+}
+
+// run script with context
+func (s *script) run(ctx context.Context, c scripter, keys []string, args ...interface{}) *redis.Cmd {
+	cmd := c.DoContext(ctx, s.args("evalsha", s.hash, keys, args)...)
+
+	if err := cmd.Err(); err != nil && strings.HasPrefix(err.Error(), "NOSCRIPT") {
+		cmd = c.DoContext(ctx, s.args("eval", s.src, keys, args)...)
+	}
+
+	return cmd
+}
+
+func (s *script) args(name, body string, keys []string, args []interface{}) []interface{} {
+	cmdArgs := make([]interface{}, 0, 3+len(keys)+len(args))
+	cmdArgs = append(cmdArgs, name, body, len(keys))
+
+	for _, key := range keys {
+		cmdArgs = append(cmdArgs, key)
+	}
+
+	return append(cmdArgs, args...)
+}
@@ -0,0 +1,188 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type (
+	// Semaphore fair distributed counting semaphore.
+	// Holders and waiters are queued by ticket in a ZSET, a waiter is granted only when permits of all owners
+	// queued before it and its own fit the limit, so later small requests can not starve earlier big ones.
+	// Owners missing heartbeats for ttl are expired.
+	Semaphore struct {
+		redis  *Redis
+		prefix string
+		name   string
+		limit  int
+		ttl    time.Duration
+		retry  time.Duration
+	}
+
+	// Permit permits acquired from a semaphore
+	Permit struct {
+		sem   *Semaphore
+		owner string
+		n     int
+	}
+)
+
+var (
+	// ErrPermitNotHeld permit expired or released
+	ErrPermitNotHeld = errors.New("redis: permit not held")
+
+	// KEYS: timeouts zset, owners zset, ticket counter, permits hash.
+	// ARGV[1]: owner, ARGV[2]: permits, ARGV[3]: limit, ARGV[4]: now ms, ARGV[5]: ttl ms. returns 1 when granted
+	semaphoreAcquireScript = newScript(`
+local dead = redis.call('zrangebyscore', KEYS[1], '-inf', ARGV[4])
+for _, owner in ipairs(dead) do
+	redis.call('zrem', KEYS[2], owner)
+	redis.call('hdel', KEYS[4], owner)
+end
+redis.call('zremrangebyscore', KEYS[1], '-inf', ARGV[4])
+if not redis.call('zscore', KEYS[2], ARGV[1]) then
+	redis.call('zadd', KEYS[2], redis.call('incr', KEYS[3]), ARGV[1])
+	redis.call('hset', KEYS[4], ARGV[1], ARGV[2])
+end
+redis.call('zadd', KEYS[1], ARGV[4] + ARGV[5], ARGV[1])
+for _, key in ipairs(KEYS) do
+	redis.call('pexpire', key, ARGV[5] * 2)
+end
+local used = 0
+for _, owner in ipairs(redis.call('zrange', KEYS[2], 0, -1)) do
+	used = used + tonumber(redis.call('hget', KEYS[4], owner) or 0)
+	if owner == ARGV[1] then
+		break
+	end
+end
+if used <= tonumber(ARGV[3]) then
+	return 1
+end
+return 0
+`)
+
+	// KEYS: timeouts zset, owners zset, ticket counter, permits hash. ARGV[1]: owner
+	semaphoreReleaseScript = newScript(`
+redis.call('zrem', KEYS[1], ARGV[1])
+redis.call('hdel', KEYS[4], ARGV[1])
+return redis.call('zrem', KEYS[2], ARGV[1])
+`)
+
+	// KEYS: timeouts zset, owners zset, ticket counter, permits hash. ARGV[1]: owner, ARGV[2]: now ms, ARGV[3]: ttl ms
+	semaphoreRefreshScript = newScript(`
+if not redis.call('zscore', KEYS[2], ARGV[1]) then
+	return 0
+end
+local score = redis.call('zscore', KEYS[1], ARGV[1])
+if not score or tonumber(score) <= tonumber(ARGV[2]) then
+	return 0
+end
+redis.call('zadd', KEYS[1], ARGV[2] + ARGV[3], ARGV[1])
+for _, key in ipairs(KEYS) do
+	redis.call('pexpire', key, ARGV[3] * 2)
+end
+return 1
+`)
+)
+
+// NewSemaphore new a semaphore named name with limit permits, keys are prefixed by prefix.
+// Holders must Refresh within ttl, ttl is 10s if it is not positive.
+func NewSemaphore(r *Redis, prefix, name string, limit int, ttl time.Duration) *Semaphore {
+	if ttl <= 0 {
+		ttl = defaultLockTTL
+	}
+
+	return &Semaphore{
+		redis:  r,
+		prefix: prefix,
+		name:   name,
+		limit:  limit,
+		ttl:    ttl,
+		retry:  defaultLockRetry,
+	}
+}
+
+// Acquire n permits, wait in queue until they are granted or ctx is done
+func (s *Semaphore) Acquire(ctx context.Context, n int) (*Permit, error) {
+	if n <= 0 || n > s.limit {
+		return nil, fmt.Errorf("redis: semaphore %s can not grant %d of %d permits", s.name, n, s.limit)
+	}
+
+	p := &Permit{sem: s, owner: randomID(), n: n}
+
+	for {
+		granted, err := s.try(ctx, p)
+		if err != nil {
+			p.Release(context.Background())
+			return nil, err
+		}
+		if granted {
+			return p, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			// leave the queue, otherwise waiters behind wait until the ticket expires
+			p.Release(context.Background())
+			return nil, ctx.Err()
+		case <-time.After(s.retry):
+		}
+	}
+}
+
+// TryAcquire n permits once, ErrNotObtained is returned when they are not available
+func (s *Semaphore) TryAcquire(ctx context.Context, n int) (*Permit, error) {
+	if n <= 0 || n > s.limit {
+		return nil, fmt.Errorf("redis: semaphore %s can not grant %d of %d permits", s.name, n, s.limit)
+	}
+
+	p := &Permit{sem: s, owner: randomID(), n: n}
+
+	granted, err := s.try(ctx, p)
+	if err == nil && !granted {
+		err = ErrNotObtained
+	}
+	if err != nil {
+		p.Release(context.Background())
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (s *Semaphore) try(ctx context.Context, p *Permit) (bool, error) {
+	n, err := semaphoreAcquireScript.run(ctx, s.redis, s.keys(), p.owner, p.n, s.limit, unixMilli(time.Now()), s.ttl.Milliseconds()).Int64()
+
+	return n == 1, err
+}
+
+// keys use hash tag so that keys of a semaphore are in the same cluster slot.
+func (s *Semaphore) keys() []string {
+	base := s.prefix + ":{" + s.name + "}"
+
+	return []string{base + ":timeouts", base + ":owners", base + ":ticket", base + ":permits"}
+}
+
+// Refresh extend ttl of permit, ErrPermitNotHeld is returned when it expired
+func (p *Permit) Refresh(ctx context.Context) error {
+	s := p.sem
+
+	n, err := semaphoreRefreshScript.run(ctx, s.redis, s.keys(), p.owner, unixMilli(time.Now()), s.ttl.Milliseconds()).Int64()
+	if err == nil && n == 0 {
+		err = ErrPermitNotHeld
+	}
+
+	return err
+}
+
+// Release permit, ErrPermitNotHeld is returned when it expired before
+func (p *Permit) Release(ctx context.Context) error {
+	n, err := semaphoreReleaseScript.run(ctx, p.sem.redis, p.sem.keys(), p.owner).Int64()
+	if err == nil && n == 0 {
+		err = ErrPermitNotHeld
+	}
+
+	return err
+}
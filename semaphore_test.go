@@ -0,0 +1,176 @@
+package redis_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/boxgo/redis"
+	"github.com/boxgo/redis/redistest"
+)
+
+// KEYS: timeouts zset, owners zset, ticket counter, permits hash
+func newSemaphoreServer(t *testing.T) (*redis.Redis, *redistest.Server) {
+	r, srv := redistest.New(t)
+
+	srv.Script(redis.SemaphoreAcquireScript, func(call func(args ...string) (interface{}, error), keys, args []string) (interface{}, error) {
+		dead, _ := call("zrangebyscore", keys[0], "-inf", args[3])
+		for _, owner := range dead.([]interface{}) {
+			call("zrem", keys[1], owner.(string))
+			call("hdel", keys[3], owner.(string))
+		}
+		call("zremrangebyscore", keys[0], "-inf", args[3])
+
+		if score, _ := call("zscore", keys[1], args[0]); score == nil {
+			ticket, _ := call("incr", keys[2])
+			call("zadd", keys[1], strconv.FormatInt(ticket.(int64), 10), args[0])
+			call("hset", keys[3], args[0], args[1])
+		}
+
+		now, _ := strconv.ParseInt(args[3], 10, 64)
+		ttl, _ := strconv.ParseInt(args[4], 10, 64)
+		call("zadd", keys[0], strconv.FormatInt(now+ttl, 10), args[0])
+
+		used := 0
+		owners, _ := call("zrange", keys[1], "0", "-1")
+		for _, owner := range owners.([]interface{}) {
+			permits, _ := call("hget", keys[3], owner.(string))
+			if s, ok := permits.(string); ok {
+				n, _ := strconv.Atoi(s)
+				used += n
+			}
+			if owner == args[0] {
+				break
+			}
+		}
+
+		if limit, _ := strconv.Atoi(args[2]); used <= limit {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	})
+	srv.Script(redis.SemaphoreReleaseScript, func(call func(args ...string) (interface{}, error), keys, args []string) (interface{}, error) {
+		call("zrem", keys[0], args[0])
+		call("hdel", keys[3], args[0])
+		return call("zrem", keys[1], args[0])
+	})
+	srv.Script(redis.SemaphoreRefreshScript, func(call func(args ...string) (interface{}, error), keys, args []string) (interface{}, error) {
+		if score, _ := call("zscore", keys[1], args[0]); score == nil {
+			return int64(0), nil
+		}
+
+		now, _ := strconv.ParseInt(args[1], 10, 64)
+		score, _ := call("zscore", keys[0], args[0])
+		s, _ := score.(string)
+		if deadline, err := strconv.ParseFloat(s, 64); err != nil || deadline <= float64(now) {
+			return int64(0), nil
+		}
+
+		ttl, _ := strconv.ParseInt(args[2], 10, 64)
+		call("zadd", keys[0], strconv.FormatInt(now+ttl, 10), args[0])
+		return int64(1), nil
+	})
+
+	return r, srv
+}
+
+func TestSemaphoreLimit(t *testing.T) {
+	r, _ := newSemaphoreServer(t)
+	ctx := context.Background()
+	s := redis.NewSemaphore(r, "sem", "jobs", 3, time.Minute)
+
+	if _, err := s.TryAcquire(ctx, 4); err == nil {
+		t.Fatal("TryAcquire above the limit expected an error")
+	}
+
+	a, err := s.TryAcquire(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.TryAcquire(ctx, 2); err != redis.ErrNotObtained {
+		t.Fatalf("TryAcquire of unavailable permits expected ErrNotObtained, got %v", err)
+	}
+	b, err := s.TryAcquire(ctx, 1)
+	if err != nil {
+		t.Fatalf("TryAcquire of the remaining permit: %v", err)
+	}
+
+	if err := a.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh of a held permit: %v", err)
+	}
+	if err := a.Release(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Release(ctx); err != redis.ErrPermitNotHeld {
+		t.Fatalf("second Release expected ErrPermitNotHeld, got %v", err)
+	}
+	if err := a.Refresh(ctx); err != redis.ErrPermitNotHeld {
+		t.Fatalf("Refresh of a released permit expected ErrPermitNotHeld, got %v", err)
+	}
+
+	if _, err := s.TryAcquire(ctx, 2); err != nil {
+		t.Fatalf("TryAcquire after Release: %v", err)
+	}
+	b.Release(ctx)
+}
+
+func TestSemaphoreFair(t *testing.T) {
+	r, _ := newSemaphoreServer(t)
+	ctx := context.Background()
+	s := redis.NewSemaphore(r, "sem", "jobs", 3, time.Minute)
+
+	a, err := s.TryAcquire(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a waiter of 2 permits is queued before later requests
+	granted := make(chan *redis.Permit)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		p, err := s.Acquire(ctx, 2)
+		if err != nil {
+			t.Error(err)
+		}
+		granted <- p
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	if _, err := s.TryAcquire(ctx, 1); err != redis.ErrNotObtained {
+		t.Fatalf("TryAcquire behind a waiter expected ErrNotObtained, got %v", err)
+	}
+
+	a.Release(ctx)
+
+	select {
+	case p := <-granted:
+		if p == nil {
+			t.Fatal("waiter not granted")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("waiter not granted after Release")
+	}
+}
+
+func TestSemaphoreExpired(t *testing.T) {
+	r, _ := newSemaphoreServer(t)
+	ctx := context.Background()
+	s := redis.NewSemaphore(r, "sem", "jobs", 3, 50*time.Millisecond)
+
+	a, err := s.TryAcquire(ctx, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	if err := a.Refresh(ctx); err != redis.ErrPermitNotHeld {
+		t.Fatalf("Refresh after ttl expected ErrPermitNotHeld, got %v", err)
+	}
+	if _, err := s.TryAcquire(ctx, 3); err != nil {
+		t.Fatalf("TryAcquire of permits of an expired owner: %v", err)
+	}
+}
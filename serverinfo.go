@@ -0,0 +1,204 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type (
+	// serverCollector export INFO, LATENCY LATEST and SLOWLOG of redis servers
+	serverCollector struct {
+		redis *Redis
+
+		info     *prometheus.GaugeVec
+		lag      *prometheus.GaugeVec
+		latency  *prometheus.GaugeVec
+		slowlogs *prometheus.CounterVec
+
+		mu        sync.Mutex
+		slowlogID map[string]int64
+	}
+)
+
+// serverInfoFields INFO fields exported by name
+var serverInfoFields = map[string]string{
+	"used_memory":                    "used_memory_bytes",
+	"used_memory_rss":                "used_memory_rss_bytes",
+	"maxmemory":                      "maxmemory_bytes",
+	"connected_clients":              "connected_clients",
+	"blocked_clients":                "blocked_clients",
+	"evicted_keys":                   "evicted_keys",
+	"expired_keys":                   "expired_keys",
+	"keyspace_hits":                  "keyspace_hits",
+	"keyspace_misses":                "keyspace_misses",
+	"instantaneous_ops_per_sec":      "ops_per_sec",
+	"connected_slaves":               "connected_replicas",
+	"master_link_down_since_seconds": "master_link_down_seconds",
+}
+
+// collectServerInfo export server side metrics of every master periodically until shutdown
+func (r *Redis) collectServerInfo(done <-chan struct{}) {
+	c := &serverCollector{
+		redis:     r,
+		slowlogID: map[string]int64{},
+		info: mustRegister(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: r.metrics.Namespace,
+				Subsystem: r.metrics.Subsystem,
+				Name:      "redis_server_info",
+				Help:      "redis server INFO fields, e.g. used_memory_bytes, connected_clients, evicted_keys",
+			},
+			[]string{"redis_instance", "node", "field"},
+		)).(*prometheus.GaugeVec),
+		lag: mustRegister(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: r.metrics.Namespace,
+				Subsystem: r.metrics.Subsystem,
+				Name:      "redis_server_replication_lag_bytes",
+				Help:      "redis replication offset of the master minus the offset acknowledged by the replica",
+			},
+			[]string{"redis_instance", "node", "replica"},
+		)).(*prometheus.GaugeVec),
+		latency: mustRegister(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: r.metrics.Namespace,
+				Subsystem: r.metrics.Subsystem,
+				Name:      "redis_server_latency_latest_seconds",
+				Help:      "redis latest latency spike by event of LATENCY LATEST, requires latency-monitor-threshold",
+			},
+			[]string{"redis_instance", "node", "event"},
+		)).(*prometheus.GaugeVec),
+		slowlogs: mustRegister(prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: r.metrics.Namespace,
+				Subsystem: r.metrics.Subsystem,
+				Name:      "redis_server_slowlog_total",
+				Help:      "redis server slowlog entries total, counted from the latest 128 entries of each interval",
+			},
+			[]string{"redis_instance", "node"},
+		)).(*prometheus.CounterVec),
+	}
+
+	interval := r.ServerInfoInterval
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		if r.Paused() {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		err := r.forEachMaster(func(node redis.UniversalClient, addr string) error {
+			return c.collect(ctx, node, addr)
+		})
+		cancel()
+
+		if err != nil {
+			r.logf("server info: %v", err)
+		}
+	}
+}
+
+func (c *serverCollector) collect(ctx context.Context, node redis.UniversalClient, addr string) error {
+	instance := c.redis.Instance()
+
+	info, err := node.DoContext(ctx, "info").Text()
+	if err != nil {
+		return fmt.Errorf("info of %s: %w", addr, err)
+	}
+
+	for field, name := range serverInfoFields {
+		if v, err := strconv.ParseFloat(infoField(info, field), 64); err == nil {
+			c.info.WithLabelValues(instance, addr, name).Set(v)
+		}
+	}
+
+	// slaveN:ip=x,port=y,state=online,offset=z,lag=n
+	if offset, err := strconv.ParseInt(infoField(info, "master_repl_offset"), 10, 64); err == nil {
+		for i := 0; ; i++ {
+			replica := infoField(info, "slave"+strconv.Itoa(i))
+			if replica == "" {
+				break
+			}
+
+			attrs := map[string]string{}
+			for _, kv := range strings.Split(replica, ",") {
+				if i := strings.IndexByte(kv, '='); i > 0 {
+					attrs[kv[:i]] = kv[i+1:]
+				}
+			}
+
+			if acked, err := strconv.ParseInt(attrs["offset"], 10, 64); err == nil {
+				c.lag.WithLabelValues(instance, addr, attrs["ip"]+":"+attrs["port"]).Set(float64(offset - acked))
+			}
+		}
+	}
+
+	// [event, timestamp, latest ms, max ms]
+	if events, err := node.DoContext(ctx, "latency", "latest").Result(); err == nil {
+		items, _ := events.([]interface{})
+		for _, item := range items {
+			event, ok := item.([]interface{})
+			if !ok || len(event) < 3 {
+				continue
+			}
+			if ms, ok := event[2].(int64); ok {
+				c.latency.WithLabelValues(instance, addr, fmt.Sprint(event[0])).Set(float64(ms) / 1000)
+			}
+		}
+	}
+
+	// [id, timestamp, duration us, args, ...], newest first
+	entries, err := node.DoContext(ctx, "slowlog", "get", 128).Result()
+	if err != nil {
+		return fmt.Errorf("slowlog of %s: %w", addr, err)
+	}
+
+	items, _ := entries.([]interface{})
+	ids := make([]int64, 0, len(items))
+	newest := int64(-1)
+	for _, item := range items {
+		entry, ok := item.([]interface{})
+		if !ok || len(entry) == 0 {
+			continue
+		}
+
+		if id, ok := entry[0].(int64); ok {
+			ids = append(ids, id)
+			if id > newest {
+				newest = id
+			}
+		}
+	}
+
+	c.mu.Lock()
+	last, seen := c.slowlogID[addr]
+	c.slowlogID[addr] = newest
+	c.mu.Unlock()
+
+	// the first collection only sets the baseline, ids restart after SLOWLOG RESET or redis restart
+	count := 0
+	for _, id := range ids {
+		if seen && (id > last || newest < last) {
+			count++
+		}
+	}
+
+	c.slowlogs.WithLabelValues(instance, addr).Add(float64(count))
+
+	return nil
+}
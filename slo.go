@@ -0,0 +1,79 @@
+package redis
+
+import (
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	sloLatency = "latency"
+	sloError   = "error"
+)
+
+// newSLOMetrics good/bad event counters and objectives of configured SLOs.
+// Burn rate of a window is rate(bad)/rate(good+bad)/(1-objective).
+func (r *Redis) newSLOMetrics() {
+	if r.SLOLatency <= 0 && r.SLOErrorTarget <= 0 {
+		return
+	}
+
+	r.sloEvents = mustRegister(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: r.metrics.Namespace,
+			Subsystem: r.metrics.Subsystem,
+			Name:      "redis_slo_events_total",
+			Help:      "redis SLO good and bad events total",
+		},
+		[]string{"redis_instance", "slo", "result"},
+	)).(*prometheus.CounterVec)
+
+	objective := mustRegister(prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: r.metrics.Namespace,
+			Subsystem: r.metrics.Subsystem,
+			Name:      "redis_slo_objective",
+			Help:      "redis SLO objective ratio of good events",
+		},
+		[]string{"redis_instance", "slo"},
+	)).(*prometheus.GaugeVec)
+
+	if r.SLOLatency > 0 {
+		objective.WithLabelValues(r.Instance(), sloLatency).Set(r.SLOLatencyTarget)
+	}
+	if r.SLOErrorTarget > 0 {
+		objective.WithLabelValues(r.Instance(), sloError).Set(r.SLOErrorTarget)
+	}
+}
+
+// reportSLO count a command or pipeline as one event of each SLO, not affected by metrics sampling
+func (r *Redis) reportSLO(elapsed time.Duration, cmds []redis.Cmder) {
+	if r.sloEvents == nil {
+		return
+	}
+
+	if r.SLOLatency > 0 {
+		r.sloEvents.WithLabelValues(r.Instance(), sloLatency, sloResult(elapsed <= r.SLOLatency)).Inc()
+	}
+
+	if r.SLOErrorTarget > 0 {
+		good := true
+		for _, cmd := range cmds {
+			if err := cmd.Err(); err != nil && err != redis.Nil {
+				good = false
+				break
+			}
+		}
+
+		r.sloEvents.WithLabelValues(r.Instance(), sloError, sloResult(good)).Inc()
+	}
+}
+
+func sloResult(good bool) string {
+	if good {
+		return "good"
+	}
+
+	return "bad"
+}
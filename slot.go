@@ -0,0 +1,46 @@
+package redis
+
+import (
+	"strings"
+
+	"github.com/go-redis/redis/v7"
+)
+
+// slotCount number of redis cluster slots
+const slotCount = 16384
+
+// keySlot cluster slot of key, respecting {hash tag}
+func keySlot(key string) int {
+	if s := strings.IndexByte(key, '{'); s > -1 {
+		if e := strings.IndexByte(key[s+1:], '}'); e > 0 {
+			key = key[s+1 : s+e+1]
+		}
+	}
+
+	return int(crc16(key) % slotCount)
+}
+
+// crc16 CRC16-CCITT (XMODEM) used by redis cluster
+func crc16(s string) uint16 {
+	var crc uint16
+
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+
+	return crc
+}
+
+// isCluster client is a cluster client
+func (r *Redis) isCluster() bool {
+	_, ok := r.client().(*redis.ClusterClient)
+
+	return ok
+}
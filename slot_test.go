@@ -0,0 +1,58 @@
+package redis
+
+import "testing"
+
+func TestCRC16(t *testing.T) {
+	tests := []struct {
+		in   string
+		want uint16
+	}{
+		{"", 0},
+		{"123456789", 0x31c3},
+		{"a", 0x7c87},
+	}
+
+	for _, tt := range tests {
+		if got := crc16(tt.in); got != tt.want {
+			t.Errorf("crc16(%q) = %#x, want %#x", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestKeySlot(t *testing.T) {
+	tests := []struct {
+		key  string
+		want int
+	}{
+		{"foo", 12182},
+		{"bar", 5061},
+		{"hello", 866},
+		{"123456789", 12739},
+	}
+
+	for _, tt := range tests {
+		if got := keySlot(tt.key); got != tt.want {
+			t.Errorf("keySlot(%q) = %d, want %d", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestKeySlotHashTag(t *testing.T) {
+	tests := []struct {
+		key, same string
+	}{
+		{"{user1000}.following", "user1000"},
+		{"{user1000}.followers", "user1000"},
+		{"foo{bar}{zap}", "bar"},
+		{"foo{{bar}}zap", "{bar"},
+		// empty or unclosed tags hash the whole key
+		{"foo{}{bar}", "foo{}{bar}"},
+		{"foo{bar", "foo{bar"},
+	}
+
+	for _, tt := range tests {
+		if got, want := keySlot(tt.key), int(crc16(tt.same)%slotCount); got != want {
+			t.Errorf("keySlot(%q) = %d, want slot of %q %d", tt.key, got, tt.same, want)
+		}
+	}
+}
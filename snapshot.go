@@ -0,0 +1,298 @@
+package redis
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	// MetricsSnapshot current state of the box for dashboards, admin endpoints and tests that do not scrape prometheus.
+	// Counters are since start of the process, latency quantiles are of the latest observed commands.
+	MetricsSnapshot struct {
+		Instance  string                     `json:"instance"`
+		Ready     bool                       `json:"ready"`
+		ColdStart bool                       `json:"coldStart"`
+		Paused    bool                       `json:"paused"`
+		Commands  map[string]CommandSnapshot `json:"commands"`
+		Errors    map[string]int64           `json:"errors"`
+		Latency   LatencySnapshot            `json:"latency"`
+		Prefixes  map[string]LatencySnapshot `json:"prefixes,omitempty"`
+		Pool      *PoolSnapshot              `json:"pool,omitempty"`
+		Locks     map[string]LockStats       `json:"locks,omitempty"`
+		TakenAt   time.Time                  `json:"takenAt"`
+	}
+
+	// CommandSnapshot counters of a command name, pipelines are counted by their commands
+	CommandSnapshot struct {
+		Calls  int64 `json:"calls"`
+		Errors int64 `json:"errors"`
+	}
+
+	// LatencySnapshot latency quantiles of the latest commands or pipelines
+	LatencySnapshot struct {
+		Samples int           `json:"samples"`
+		P50     time.Duration `json:"p50"`
+		P90     time.Duration `json:"p90"`
+		P99     time.Duration `json:"p99"`
+		Max     time.Duration `json:"max"`
+	}
+
+	// PoolSnapshot connection pool stats of the primary client
+	PoolSnapshot struct {
+		Hits       uint32 `json:"hits"`
+		Misses     uint32 `json:"misses"`
+		Timeouts   uint32 `json:"timeouts"`
+		TotalConns uint32 `json:"totalConns"`
+		IdleConns  uint32 `json:"idleConns"`
+		StaleConns uint32 `json:"staleConns"`
+	}
+
+	// snapshotStats in-process counters behind MetricsSnapshot
+	snapshotStats struct {
+		mu       sync.Mutex
+		commands map[string]*CommandSnapshot
+		errors   map[string]int64
+		latency  [snapshotSamples]time.Duration
+		samples  int
+		lockers  []*Locker
+		prefixes []string
+		window   time.Duration
+		byPrefix map[string]*prefixSamples
+	}
+
+	// prefixSamples latest latency samples of a key prefix with their time
+	prefixSamples struct {
+		latency [snapshotSamples]time.Duration
+		at      [snapshotSamples]int64
+		samples int
+	}
+)
+
+const (
+	snapshotSamples = 1024
+)
+
+// GetMetricsSnapshot current counters, latency quantiles, pool stats and lock stats of registered lockers.
+// The snapshot is JSON serializable, the admin handler serves it at /metrics.
+func (r *Redis) GetMetricsSnapshot() MetricsSnapshot {
+	s := &r.snapshot
+
+	snap := MetricsSnapshot{
+		Instance:  r.Instance(),
+		Ready:     r.Ready(),
+		ColdStart: r.ColdStart(),
+		Paused:    r.Paused(),
+		Commands:  map[string]CommandSnapshot{},
+		Errors:    map[string]int64{},
+		TakenAt:   time.Now(),
+	}
+
+	s.mu.Lock()
+	for name, c := range s.commands {
+		snap.Commands[name] = *c
+	}
+	for class, n := range s.errors {
+		snap.Errors[class] = n
+	}
+	n := s.samples
+	if n > snapshotSamples {
+		n = snapshotSamples
+	}
+	latency := make([]time.Duration, n)
+	copy(latency, s.latency[:n])
+	lockers := append([]*Locker(nil), s.lockers...)
+	prefixed := len(s.prefixes) > 0
+	s.mu.Unlock()
+
+	snap.Latency = latencySnapshot(latency)
+	if prefixed {
+		snap.Prefixes = r.LatencyByPrefix()
+	}
+
+	if p, ok := r.client().(interface{ PoolStats() *redis.PoolStats }); ok {
+		if stats := p.PoolStats(); stats != nil {
+			snap.Pool = &PoolSnapshot{
+				Hits:       stats.Hits,
+				Misses:     stats.Misses,
+				Timeouts:   stats.Timeouts,
+				TotalConns: stats.TotalConns,
+				IdleConns:  stats.IdleConns,
+				StaleConns: stats.StaleConns,
+			}
+		}
+	}
+
+	for _, l := range lockers {
+		if snap.Locks == nil {
+			snap.Locks = map[string]LockStats{}
+		}
+		for name, stats := range l.Stats() {
+			snap.Locks[l.prefix+":"+name] = stats
+		}
+	}
+
+	return snap
+}
+
+// LatencyByPrefix latency quantiles of commands by latencyPrefixes over the latencyWindow, keys of no prefix are "other".
+// Pipelines are counted once in each prefix of their keys. The admin handler serves it at /latency.
+func (r *Redis) LatencyByPrefix() map[string]LatencySnapshot {
+	s := &r.snapshot
+	since := time.Now().Add(-s.window).UnixNano()
+
+	s.mu.Lock()
+	windows := make(map[string][]time.Duration, len(s.byPrefix))
+	for prefix, ps := range s.byPrefix {
+		n := ps.samples
+		if n > snapshotSamples {
+			n = snapshotSamples
+		}
+
+		latency := make([]time.Duration, 0, n)
+		for i := 0; i < n; i++ {
+			if ps.at[i] >= since {
+				latency = append(latency, ps.latency[i])
+			}
+		}
+		windows[prefix] = latency
+	}
+	s.mu.Unlock()
+
+	res := make(map[string]LatencySnapshot, len(windows))
+	for prefix, latency := range windows {
+		res[prefix] = latencySnapshot(latency)
+	}
+
+	return res
+}
+
+// setPrefixes break down latency by prefixes over window
+func (s *snapshotStats) setPrefixes(prefixes []string, window time.Duration) {
+	sorted := append([]string(nil), prefixes...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return len(sorted[i]) > len(sorted[j])
+	})
+
+	if window <= 0 {
+		window = time.Minute
+	}
+
+	s.mu.Lock()
+	s.prefixes, s.window = sorted, window
+	s.mu.Unlock()
+}
+
+// record command counters and latency for the snapshot
+func (s *snapshotStats) record(elapsed time.Duration, cmds []redis.Cmder) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.prefixes) > 0 {
+		s.recordPrefixes(elapsed, cmds)
+	}
+
+	if s.commands == nil {
+		s.commands = map[string]*CommandSnapshot{}
+		s.errors = map[string]int64{}
+	}
+
+	for _, cmd := range cmds {
+		c := s.commands[cmd.Name()]
+		if c == nil {
+			c = &CommandSnapshot{}
+			s.commands[cmd.Name()] = c
+		}
+		c.Calls++
+
+		switch class := ErrorClass(cmd.Err()); class {
+		case ErrClassOK, ErrClassNil:
+		default:
+			c.Errors++
+			s.errors[class]++
+		}
+	}
+
+	s.latency[s.samples%snapshotSamples] = elapsed
+	s.samples++
+}
+
+// recordPrefixes record latency of each prefix of keys of cmds once, mu is held
+func (s *snapshotStats) recordPrefixes(elapsed time.Duration, cmds []redis.Cmder) {
+	if s.byPrefix == nil {
+		s.byPrefix = map[string]*prefixSamples{}
+	}
+
+	now := time.Now().UnixNano()
+	seen := map[string]bool{}
+	for _, cmd := range cmds {
+		key, ok := commandKey(cmd)
+		if !ok {
+			continue
+		}
+
+		prefix := matchPrefix(s.prefixes, key)
+		if seen[prefix] {
+			continue
+		}
+		seen[prefix] = true
+
+		ps := s.byPrefix[prefix]
+		if ps == nil {
+			ps = &prefixSamples{}
+			s.byPrefix[prefix] = ps
+		}
+
+		i := ps.samples % snapshotSamples
+		ps.latency[i], ps.at[i] = elapsed, now
+		ps.samples++
+	}
+}
+
+// latencySnapshot quantiles of samples, samples are sorted
+func latencySnapshot(samples []time.Duration) LatencySnapshot {
+	n := len(samples)
+	if n == 0 {
+		return LatencySnapshot{}
+	}
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+
+	return LatencySnapshot{
+		Samples: n,
+		P50:     samples[n*50/100],
+		P90:     samples[n*90/100],
+		P99:     samples[n*99/100],
+		Max:     samples[n-1],
+	}
+}
+
+// addLocker include stats of l in snapshots
+func (s *snapshotStats) addLocker(l *Locker) {
+	s.mu.Lock()
+	s.lockers = append(s.lockers, l)
+	s.mu.Unlock()
+}
+
+func (r *Redis) handleMetrics(w http.ResponseWriter, req *http.Request) {
+	writeJSON(w, r.GetMetricsSnapshot())
+}
+
+func (r *Redis) handleLatency(w http.ResponseWriter, req *http.Request) {
+	latency := r.LatencyByPrefix()
+
+	if prefix := req.FormValue("prefix"); prefix != "" {
+		snap, ok := latency[prefix]
+		if !ok {
+			http.Error(w, "prefix has no samples in window", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, snap)
+		return
+	}
+
+	writeJSON(w, latency)
+}
@@ -0,0 +1,462 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type (
+	// StreamConsumer consumer group member as a box, messages are handled at least once.
+	// Failed messages stay pending and are redelivered after minIdle, messages delivered maxDeliveries times
+	// are moved to the dead-letter stream with failure metadata, to be inspected and replayed.
+	StreamConsumer struct {
+		Stream        string        `config:"stream" help:"Consumed stream, default is <name>"`
+		Group         string        `config:"group" help:"Consumer group, default is <name>"`
+		Consumer      string        `config:"consumer" help:"Consumer name in the group, default is <hostname>-<pid>"`
+		Batch         int           `config:"batch" default:"10" help:"Messages read per batch, default is 10"`
+		MinIdle       time.Duration `config:"minIdle" default:"30s" help:"Pending messages idle longer are redelivered, default is 30s"`
+		MaxDeliveries int64         `config:"maxDeliveries" default:"5" help:"Deliveries of a message before it is moved to the dead-letter stream, default is 5"`
+		DeadLetter    string        `config:"deadLetter" help:"Dead-letter stream, default is <stream>:dlq"`
+
+		Component
+		handler StreamHandler
+		done    chan struct{}
+		wg      sync.WaitGroup
+	}
+
+	// StreamHandler handle a message, the message is redelivered when it failed
+	StreamHandler func(ctx context.Context, msg redis.XMessage) error
+
+	// DeadLetter message moved to the dead-letter stream
+	DeadLetter struct {
+		ID         string                 `json:"id"`        // id in the dead-letter stream
+		Stream     string                 `json:"stream"`    // original stream
+		MessageID  string                 `json:"messageId"` // id in the original stream
+		Group      string                 `json:"group"`
+		Consumer   string                 `json:"consumer"`
+		Deliveries int64                  `json:"deliveries"`
+		Error      string                 `json:"error"` // error of the last failed delivery
+		At         time.Time              `json:"at"`
+		Values     map[string]interface{} `json:"values"`
+	}
+
+	// groupReader read a stream as a consumer group member, shared by consumer boxes
+	groupReader struct {
+		redis         *Redis
+		group         string
+		consumer      string
+		batch         int
+		minIdle       time.Duration
+		maxDeliveries int64
+		deadLetter    func(stream string) string
+		handler       StreamHandler
+		deadLetters   *prometheus.CounterVec
+	}
+)
+
+const (
+	streamBlock = time.Second
+
+	// deadLetterField prefix of metadata fields of dead letters
+	deadLetterField = "dlq:"
+)
+
+var (
+	// ErrNoHandler consumer is served without a handler
+	ErrNoHandler = errors.New("redis: stream consumer without handler")
+)
+
+// NewStreamConsumer new a stream consumer box named name, messages are handled by handler
+func NewStreamConsumer(name string, r *Redis, handler StreamHandler) *StreamConsumer {
+	return &StreamConsumer{
+		Component: NewComponent(name, r),
+		handler:   handler,
+	}
+}
+
+// ConfigWillLoad config will load
+func (c *StreamConsumer) ConfigWillLoad(context.Context) {
+
+}
+
+// ConfigDidLoad set defaults
+func (c *StreamConsumer) ConfigDidLoad(context.Context) {
+	if c.Stream == "" {
+		c.Stream = c.name
+	}
+	if c.Group == "" {
+		c.Group = c.name
+	}
+	if c.DeadLetter == "" {
+		c.DeadLetter = c.Stream + ":dlq"
+	}
+}
+
+// Serve start consuming
+func (c *StreamConsumer) Serve(context.Context) error {
+	if c.handler == nil {
+		return ErrNoHandler
+	}
+
+	reader := newGroupReader(c.Component.redis, c.Group, c.Consumer, c.Batch, c.MinIdle, c.MaxDeliveries, c.handler,
+		func(string) string { return c.DeadLetter })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.done = make(chan struct{})
+	go func(done <-chan struct{}) {
+		<-done
+		cancel()
+	}(c.done)
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		reader.run(ctx, c.Stream)
+	}()
+
+	return nil
+}
+
+// Shutdown stop consuming, the current message is finished first
+func (c *StreamConsumer) Shutdown(context.Context) error {
+	if c.done != nil {
+		close(c.done)
+		c.done = nil
+	}
+
+	c.wg.Wait()
+
+	return nil
+}
+
+// DeadLetters oldest count dead letters
+func (c *StreamConsumer) DeadLetters(ctx context.Context, count int64) ([]DeadLetter, error) {
+	return readDeadLetters(ctx, c.Component.redis, c.DeadLetter, count)
+}
+
+// Replay append dead letters ids to their original streams as new messages and delete them from the dead-letter stream
+func (c *StreamConsumer) Replay(ctx context.Context, ids ...string) error {
+	return replayDeadLetters(ctx, c.Component.redis, c.DeadLetter, ids...)
+}
+
+// Discard delete dead letters ids, ctx should carry a token of OpPurgeDLQ when opsKey is configured
+func (c *StreamConsumer) Discard(ctx context.Context, ids ...string) (err error) {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	done, err := c.Component.redis.authorize(ctx, OpPurgeDLQ, c.DeadLetter)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		done(err)
+	}()
+
+	args := []interface{}{"xdel", c.DeadLetter}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	return c.Component.redis.DoContext(ctx, args...).Err()
+}
+
+// AppendStream append a message of values to stream, MAXLEN ~ maxLen trims it when positive.
+// The trace context of ctx is added in trace: fields when a message tracer is set, consumers link their spans to it.
+func AppendStream(ctx context.Context, r *Redis, stream string, values map[string]interface{}, maxLen int64) (string, error) {
+	args := []interface{}{"xadd", stream}
+	if maxLen > 0 {
+		args = append(args, "maxlen", "~", maxLen)
+	}
+	args = append(args, "*")
+	for field, value := range values {
+		args = append(args, field, value)
+	}
+	for field, value := range r.injectTrace(ctx) {
+		args = append(args, traceField+field, value)
+	}
+
+	return r.DoContext(ctx, args...).Text()
+}
+
+func newGroupReader(r *Redis, group, consumer string, batch int, minIdle time.Duration, maxDeliveries int64, handler StreamHandler, deadLetter func(string) string) *groupReader {
+	if consumer == "" {
+		consumer = hostname + "-" + strconv.Itoa(os.Getpid())
+	}
+	if batch <= 0 {
+		batch = 10
+	}
+	if minIdle <= 0 {
+		minIdle = 30 * time.Second
+	}
+	if maxDeliveries <= 0 {
+		maxDeliveries = 5
+	}
+
+	g := &groupReader{
+		redis:         r,
+		group:         group,
+		consumer:      consumer,
+		batch:         batch,
+		minIdle:       minIdle,
+		maxDeliveries: maxDeliveries,
+		deadLetter:    deadLetter,
+		handler:       handler,
+	}
+
+	if r.Metrics {
+		g.deadLetters = mustRegister(prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: r.metrics.Namespace,
+				Subsystem: r.metrics.Subsystem,
+				Name:      "redis_stream_dead_letters_total",
+				Help:      "redis stream messages moved to dead-letter streams total",
+			},
+			[]string{"redis_instance", "stream", "group"},
+		)).(*prometheus.CounterVec)
+	}
+
+	return g
+}
+
+// run consume stream until ctx is done
+func (g *groupReader) run(ctx context.Context, stream string) {
+	r := g.redis
+
+	for ctx.Err() == nil {
+		if err := g.consume(ctx, stream); err != nil && ctx.Err() == nil {
+			r.logf("stream %s group %s: %v", stream, g.group, err)
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+		}
+	}
+}
+
+func (g *groupReader) consume(ctx context.Context, stream string) error {
+	r := g.redis
+
+	err := r.DoContext(ctx, "xgroup", "create", stream, g.group, "$", "mkstream").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+
+	for {
+		if err := r.waitConsumersResumed(ctx); err != nil {
+			return err
+		}
+
+		if err := g.reclaim(ctx, stream); err != nil {
+			return err
+		}
+
+		cmd := redis.NewXStreamSliceCmd("xreadgroup", "group", g.group, g.consumer,
+			"count", g.batch, "block", streamBlock.Milliseconds(), "streams", stream, ">")
+		err := r.ProcessContext(ctx, cmd)
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, s := range cmd.Val() {
+			for _, msg := range s.Messages {
+				g.handle(ctx, stream, msg)
+			}
+		}
+	}
+}
+
+// handle msg in a consumer span, ack it when handled or record the error for the dead letter
+func (g *groupReader) handle(ctx context.Context, stream string, msg redis.XMessage) {
+	r := g.redis
+
+	carrier, ready := streamTrace(msg)
+	spanCtx, end := r.startConsumer(ctx, stream, carrier, ready)
+	err := g.handler(spanCtx, msg)
+	end(err)
+
+	if err != nil {
+		r.DoContext(ctx, "hset", stream+":errors", msg.ID, err.Error())
+		return
+	}
+
+	r.DoContext(ctx, "xack", stream, g.group, msg.ID)
+	r.DoContext(ctx, "hdel", stream+":errors", msg.ID)
+}
+
+// reclaim redeliver pending messages idle longer than minIdle, messages delivered maxDeliveries times are dead-lettered
+func (g *groupReader) reclaim(ctx context.Context, stream string) error {
+	r := g.redis
+
+	pending := redis.NewXPendingExtCmd("xpending", stream, g.group, "-", "+", g.batch)
+	if err := r.ProcessContext(ctx, pending); err != nil {
+		return err
+	}
+
+	for _, p := range pending.Val() {
+		if p.Idle < g.minIdle {
+			continue
+		}
+
+		if p.RetryCount >= g.maxDeliveries {
+			if err := g.bury(ctx, stream, p); err != nil {
+				return err
+			}
+			continue
+		}
+
+		claimed := redis.NewXMessageSliceCmd("xclaim", stream, g.group, g.consumer, g.minIdle.Milliseconds(), p.ID)
+		if err := r.ProcessContext(ctx, claimed); err != nil {
+			return err
+		}
+
+		for _, msg := range claimed.Val() {
+			g.handle(ctx, stream, msg)
+		}
+	}
+
+	return nil
+}
+
+// bury move pending message p to the dead-letter stream.
+// p is claimed with minIdle first, so a message claimed or acked by another consumer meanwhile is left to it.
+func (g *groupReader) bury(ctx context.Context, stream string, p redis.XPendingExt) error {
+	r := g.redis
+	dlq := g.deadLetter(stream)
+
+	// servers before 7.0 reply a nil entry for trimmed messages, which fails parsing with redis.Nil
+	messages := redis.NewXMessageSliceCmd("xclaim", stream, g.group, g.consumer, g.minIdle.Milliseconds(), p.ID)
+	err := r.ProcessContext(ctx, messages)
+	if err != nil && err != redis.Nil {
+		return err
+	}
+	if err == nil && len(messages.Val()) == 0 {
+		return nil
+	}
+
+	reason, _ := r.DoContext(ForceMaster(ctx), "hget", stream+":errors", p.ID).Text()
+
+	// trimmed messages are acked only
+	if msgs := messages.Val(); err == nil && len(msgs) > 0 {
+		args := []interface{}{"xadd", dlq, "*"}
+		for field, value := range msgs[0].Values {
+			args = append(args, field, value)
+		}
+		args = append(args,
+			deadLetterField+"stream", stream,
+			deadLetterField+"id", p.ID,
+			deadLetterField+"group", g.group,
+			deadLetterField+"consumer", p.Consumer,
+			deadLetterField+"deliveries", p.RetryCount,
+			deadLetterField+"error", reason,
+			deadLetterField+"at", unixMilli(time.Now()),
+		)
+
+		if err := r.DoContext(ctx, args...).Err(); err != nil {
+			return err
+		}
+	}
+
+	if err := r.DoContext(ctx, "xack", stream, g.group, p.ID).Err(); err != nil {
+		return err
+	}
+	r.DoContext(ctx, "hdel", stream+":errors", p.ID)
+
+	if g.deadLetters != nil {
+		g.deadLetters.WithLabelValues(r.Instance(), stream, g.group).Inc()
+	}
+	r.logf("stream %s group %s message %s moved to %s after %d deliveries: %s", stream, g.group, p.ID, dlq, p.RetryCount, reason)
+
+	return nil
+}
+
+func readDeadLetters(ctx context.Context, r *Redis, dlq string, count int64) ([]DeadLetter, error) {
+	if count <= 0 {
+		count = 100
+	}
+
+	cmd := redis.NewXMessageSliceCmd("xrange", dlq, "-", "+", "count", count)
+	if err := r.ProcessContext(ctx, cmd); err != nil {
+		return nil, err
+	}
+
+	letters := make([]DeadLetter, 0, len(cmd.Val()))
+	for _, msg := range cmd.Val() {
+		letters = append(letters, parseDeadLetter(msg))
+	}
+
+	return letters, nil
+}
+
+func replayDeadLetters(ctx context.Context, r *Redis, dlq string, ids ...string) error {
+	for _, id := range ids {
+		cmd := redis.NewXMessageSliceCmd("xrange", dlq, id, id)
+		if err := r.ProcessContext(ctx, cmd); err != nil {
+			return err
+		}
+		if len(cmd.Val()) == 0 {
+			return fmt.Errorf("redis: dead letter %s of %s not found", id, dlq)
+		}
+
+		letter := parseDeadLetter(cmd.Val()[0])
+
+		args := []interface{}{"xadd", letter.Stream, "*"}
+		for field, value := range letter.Values {
+			args = append(args, field, value)
+		}
+		if err := r.DoContext(ctx, args...).Err(); err != nil {
+			return err
+		}
+
+		if err := r.DoContext(ctx, "xdel", dlq, id).Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func parseDeadLetter(msg redis.XMessage) DeadLetter {
+	letter := DeadLetter{ID: msg.ID, Values: map[string]interface{}{}}
+
+	for field, value := range msg.Values {
+		if !strings.HasPrefix(field, deadLetterField) {
+			letter.Values[field] = value
+			continue
+		}
+
+		s := fmt.Sprint(value)
+		switch strings.TrimPrefix(field, deadLetterField) {
+		case "stream":
+			letter.Stream = s
+		case "id":
+			letter.MessageID = s
+		case "group":
+			letter.Group = s
+		case "consumer":
+			letter.Consumer = s
+		case "deliveries":
+			letter.Deliveries, _ = strconv.ParseInt(s, 10, 64)
+		case "error":
+			letter.Error = s
+		case "at":
+			ms, _ := strconv.ParseInt(s, 10, 64)
+			letter.At = time.Unix(0, ms*int64(time.Millisecond))
+		}
+	}
+
+	return letter
+}
@@ -0,0 +1,73 @@
+package redis
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-redis/redis/v7"
+)
+
+//go:generate go run ./internal/swapgen -o swapclient.go
+
+type (
+	// swapClient client of the box, delegating to a client swapped atomically by Reload.
+	// Hooks added to it are added to swapped clients too, hooks of the box are added by newClient.
+	swapClient struct {
+		value   atomic.Value // clientValue
+		hooksMu sync.Mutex
+		hooks   []redis.Hook
+	}
+
+	clientValue struct {
+		redis.UniversalClient
+	}
+)
+
+func newSwapClient(client redis.UniversalClient) *swapClient {
+	c := &swapClient{}
+	c.value.Store(clientValue{client})
+
+	return c
+}
+
+func (c *swapClient) current() redis.UniversalClient {
+	return c.value.Load().(clientValue).UniversalClient
+}
+
+// AddHook add hook to the current and swapped clients
+func (c *swapClient) AddHook(hook redis.Hook) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+
+	c.hooks = append(c.hooks, hook)
+	c.current().AddHook(hook)
+}
+
+// Close close the current client
+func (c *swapClient) Close() error {
+	return c.current().Close()
+}
+
+// swap replace the current client by next, the replaced client is returned to be closed after draining
+func (c *swapClient) swap(next redis.UniversalClient) redis.UniversalClient {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+
+	for _, hook := range c.hooks {
+		next.AddHook(hook)
+	}
+
+	prev := c.current()
+	c.value.Store(clientValue{next})
+
+	return prev
+}
+
+// client current underlying client, for type assertions of client kinds
+func (r *Redis) client() redis.UniversalClient {
+	if c, ok := r.UniversalClient.(*swapClient); ok {
+		return c.current()
+	}
+
+	return r.UniversalClient
+}
@@ -0,0 +1,1010 @@
+// Code generated by internal/swapgen from redis.UniversalClient. DO NOT EDIT.
+
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+func (c *swapClient) Append(key string, value string) *redis.IntCmd {
+	return c.current().Append(key, value)
+}
+
+func (c *swapClient) BLPop(timeout time.Duration, keys ...string) *redis.StringSliceCmd {
+	return c.current().BLPop(timeout, keys...)
+}
+
+func (c *swapClient) BRPop(timeout time.Duration, keys ...string) *redis.StringSliceCmd {
+	return c.current().BRPop(timeout, keys...)
+}
+
+func (c *swapClient) BRPopLPush(source string, destination string, timeout time.Duration) *redis.StringCmd {
+	return c.current().BRPopLPush(source, destination, timeout)
+}
+
+func (c *swapClient) BZPopMax(timeout time.Duration, keys ...string) *redis.ZWithKeyCmd {
+	return c.current().BZPopMax(timeout, keys...)
+}
+
+func (c *swapClient) BZPopMin(timeout time.Duration, keys ...string) *redis.ZWithKeyCmd {
+	return c.current().BZPopMin(timeout, keys...)
+}
+
+func (c *swapClient) BgRewriteAOF() *redis.StatusCmd {
+	return c.current().BgRewriteAOF()
+}
+
+func (c *swapClient) BgSave() *redis.StatusCmd {
+	return c.current().BgSave()
+}
+
+func (c *swapClient) BitCount(key string, bitCount *redis.BitCount) *redis.IntCmd {
+	return c.current().BitCount(key, bitCount)
+}
+
+func (c *swapClient) BitField(key string, args ...interface{}) *redis.IntSliceCmd {
+	return c.current().BitField(key, args...)
+}
+
+func (c *swapClient) BitOpAnd(destKey string, keys ...string) *redis.IntCmd {
+	return c.current().BitOpAnd(destKey, keys...)
+}
+
+func (c *swapClient) BitOpNot(destKey string, key string) *redis.IntCmd {
+	return c.current().BitOpNot(destKey, key)
+}
+
+func (c *swapClient) BitOpOr(destKey string, keys ...string) *redis.IntCmd {
+	return c.current().BitOpOr(destKey, keys...)
+}
+
+func (c *swapClient) BitOpXor(destKey string, keys ...string) *redis.IntCmd {
+	return c.current().BitOpXor(destKey, keys...)
+}
+
+func (c *swapClient) BitPos(key string, bit int64, pos ...int64) *redis.IntCmd {
+	return c.current().BitPos(key, bit, pos...)
+}
+
+func (c *swapClient) ClientGetName() *redis.StringCmd {
+	return c.current().ClientGetName()
+}
+
+func (c *swapClient) ClientID() *redis.IntCmd {
+	return c.current().ClientID()
+}
+
+func (c *swapClient) ClientKill(ipPort string) *redis.StatusCmd {
+	return c.current().ClientKill(ipPort)
+}
+
+func (c *swapClient) ClientKillByFilter(keys ...string) *redis.IntCmd {
+	return c.current().ClientKillByFilter(keys...)
+}
+
+func (c *swapClient) ClientList() *redis.StringCmd {
+	return c.current().ClientList()
+}
+
+func (c *swapClient) ClientPause(dur time.Duration) *redis.BoolCmd {
+	return c.current().ClientPause(dur)
+}
+
+func (c *swapClient) ClusterAddSlots(slots ...int) *redis.StatusCmd {
+	return c.current().ClusterAddSlots(slots...)
+}
+
+func (c *swapClient) ClusterAddSlotsRange(min int, max int) *redis.StatusCmd {
+	return c.current().ClusterAddSlotsRange(min, max)
+}
+
+func (c *swapClient) ClusterCountFailureReports(nodeID string) *redis.IntCmd {
+	return c.current().ClusterCountFailureReports(nodeID)
+}
+
+func (c *swapClient) ClusterCountKeysInSlot(slot int) *redis.IntCmd {
+	return c.current().ClusterCountKeysInSlot(slot)
+}
+
+func (c *swapClient) ClusterDelSlots(slots ...int) *redis.StatusCmd {
+	return c.current().ClusterDelSlots(slots...)
+}
+
+func (c *swapClient) ClusterDelSlotsRange(min int, max int) *redis.StatusCmd {
+	return c.current().ClusterDelSlotsRange(min, max)
+}
+
+func (c *swapClient) ClusterFailover() *redis.StatusCmd {
+	return c.current().ClusterFailover()
+}
+
+func (c *swapClient) ClusterForget(nodeID string) *redis.StatusCmd {
+	return c.current().ClusterForget(nodeID)
+}
+
+func (c *swapClient) ClusterGetKeysInSlot(slot int, count int) *redis.StringSliceCmd {
+	return c.current().ClusterGetKeysInSlot(slot, count)
+}
+
+func (c *swapClient) ClusterInfo() *redis.StringCmd {
+	return c.current().ClusterInfo()
+}
+
+func (c *swapClient) ClusterKeySlot(key string) *redis.IntCmd {
+	return c.current().ClusterKeySlot(key)
+}
+
+func (c *swapClient) ClusterMeet(host string, port string) *redis.StatusCmd {
+	return c.current().ClusterMeet(host, port)
+}
+
+func (c *swapClient) ClusterNodes() *redis.StringCmd {
+	return c.current().ClusterNodes()
+}
+
+func (c *swapClient) ClusterReplicate(nodeID string) *redis.StatusCmd {
+	return c.current().ClusterReplicate(nodeID)
+}
+
+func (c *swapClient) ClusterResetHard() *redis.StatusCmd {
+	return c.current().ClusterResetHard()
+}
+
+func (c *swapClient) ClusterResetSoft() *redis.StatusCmd {
+	return c.current().ClusterResetSoft()
+}
+
+func (c *swapClient) ClusterSaveConfig() *redis.StatusCmd {
+	return c.current().ClusterSaveConfig()
+}
+
+func (c *swapClient) ClusterSlaves(nodeID string) *redis.StringSliceCmd {
+	return c.current().ClusterSlaves(nodeID)
+}
+
+func (c *swapClient) ClusterSlots() *redis.ClusterSlotsCmd {
+	return c.current().ClusterSlots()
+}
+
+func (c *swapClient) Command() *redis.CommandsInfoCmd {
+	return c.current().Command()
+}
+
+func (c *swapClient) ConfigGet(parameter string) *redis.SliceCmd {
+	return c.current().ConfigGet(parameter)
+}
+
+func (c *swapClient) ConfigResetStat() *redis.StatusCmd {
+	return c.current().ConfigResetStat()
+}
+
+func (c *swapClient) ConfigRewrite() *redis.StatusCmd {
+	return c.current().ConfigRewrite()
+}
+
+func (c *swapClient) ConfigSet(parameter string, value string) *redis.StatusCmd {
+	return c.current().ConfigSet(parameter, value)
+}
+
+func (c *swapClient) Context() context.Context {
+	return c.current().Context()
+}
+
+func (c *swapClient) DBSize() *redis.IntCmd {
+	return c.current().DBSize()
+}
+
+func (c *swapClient) DebugObject(key string) *redis.StringCmd {
+	return c.current().DebugObject(key)
+}
+
+func (c *swapClient) Decr(key string) *redis.IntCmd {
+	return c.current().Decr(key)
+}
+
+func (c *swapClient) DecrBy(key string, decrement int64) *redis.IntCmd {
+	return c.current().DecrBy(key, decrement)
+}
+
+func (c *swapClient) Del(keys ...string) *redis.IntCmd {
+	return c.current().Del(keys...)
+}
+
+func (c *swapClient) Do(args ...interface{}) *redis.Cmd {
+	return c.current().Do(args...)
+}
+
+func (c *swapClient) DoContext(ctx context.Context, args ...interface{}) *redis.Cmd {
+	return c.current().DoContext(ctx, args...)
+}
+
+func (c *swapClient) Dump(key string) *redis.StringCmd {
+	return c.current().Dump(key)
+}
+
+func (c *swapClient) Echo(message interface{}) *redis.StringCmd {
+	return c.current().Echo(message)
+}
+
+func (c *swapClient) Eval(script string, keys []string, args ...interface{}) *redis.Cmd {
+	return c.current().Eval(script, keys, args...)
+}
+
+func (c *swapClient) EvalSha(sha1 string, keys []string, args ...interface{}) *redis.Cmd {
+	return c.current().EvalSha(sha1, keys, args...)
+}
+
+func (c *swapClient) Exists(keys ...string) *redis.IntCmd {
+	return c.current().Exists(keys...)
+}
+
+func (c *swapClient) Expire(key string, expiration time.Duration) *redis.BoolCmd {
+	return c.current().Expire(key, expiration)
+}
+
+func (c *swapClient) ExpireAt(key string, tm time.Time) *redis.BoolCmd {
+	return c.current().ExpireAt(key, tm)
+}
+
+func (c *swapClient) FlushAll() *redis.StatusCmd {
+	return c.current().FlushAll()
+}
+
+func (c *swapClient) FlushAllAsync() *redis.StatusCmd {
+	return c.current().FlushAllAsync()
+}
+
+func (c *swapClient) FlushDB() *redis.StatusCmd {
+	return c.current().FlushDB()
+}
+
+func (c *swapClient) FlushDBAsync() *redis.StatusCmd {
+	return c.current().FlushDBAsync()
+}
+
+func (c *swapClient) GeoAdd(key string, geoLocation ...*redis.GeoLocation) *redis.IntCmd {
+	return c.current().GeoAdd(key, geoLocation...)
+}
+
+func (c *swapClient) GeoDist(key string, member1 string, member2 string, unit string) *redis.FloatCmd {
+	return c.current().GeoDist(key, member1, member2, unit)
+}
+
+func (c *swapClient) GeoHash(key string, members ...string) *redis.StringSliceCmd {
+	return c.current().GeoHash(key, members...)
+}
+
+func (c *swapClient) GeoPos(key string, members ...string) *redis.GeoPosCmd {
+	return c.current().GeoPos(key, members...)
+}
+
+func (c *swapClient) GeoRadius(key string, longitude float64, latitude float64, query *redis.GeoRadiusQuery) *redis.GeoLocationCmd {
+	return c.current().GeoRadius(key, longitude, latitude, query)
+}
+
+func (c *swapClient) GeoRadiusByMember(key string, member string, query *redis.GeoRadiusQuery) *redis.GeoLocationCmd {
+	return c.current().GeoRadiusByMember(key, member, query)
+}
+
+func (c *swapClient) GeoRadiusByMemberStore(key string, member string, query *redis.GeoRadiusQuery) *redis.IntCmd {
+	return c.current().GeoRadiusByMemberStore(key, member, query)
+}
+
+func (c *swapClient) GeoRadiusStore(key string, longitude float64, latitude float64, query *redis.GeoRadiusQuery) *redis.IntCmd {
+	return c.current().GeoRadiusStore(key, longitude, latitude, query)
+}
+
+func (c *swapClient) Get(key string) *redis.StringCmd {
+	return c.current().Get(key)
+}
+
+func (c *swapClient) GetBit(key string, offset int64) *redis.IntCmd {
+	return c.current().GetBit(key, offset)
+}
+
+func (c *swapClient) GetRange(key string, start int64, end int64) *redis.StringCmd {
+	return c.current().GetRange(key, start, end)
+}
+
+func (c *swapClient) GetSet(key string, value interface{}) *redis.StringCmd {
+	return c.current().GetSet(key, value)
+}
+
+func (c *swapClient) HDel(key string, fields ...string) *redis.IntCmd {
+	return c.current().HDel(key, fields...)
+}
+
+func (c *swapClient) HExists(key string, field string) *redis.BoolCmd {
+	return c.current().HExists(key, field)
+}
+
+func (c *swapClient) HGet(key string, field string) *redis.StringCmd {
+	return c.current().HGet(key, field)
+}
+
+func (c *swapClient) HGetAll(key string) *redis.StringStringMapCmd {
+	return c.current().HGetAll(key)
+}
+
+func (c *swapClient) HIncrBy(key string, field string, incr int64) *redis.IntCmd {
+	return c.current().HIncrBy(key, field, incr)
+}
+
+func (c *swapClient) HIncrByFloat(key string, field string, incr float64) *redis.FloatCmd {
+	return c.current().HIncrByFloat(key, field, incr)
+}
+
+func (c *swapClient) HKeys(key string) *redis.StringSliceCmd {
+	return c.current().HKeys(key)
+}
+
+func (c *swapClient) HLen(key string) *redis.IntCmd {
+	return c.current().HLen(key)
+}
+
+func (c *swapClient) HMGet(key string, fields ...string) *redis.SliceCmd {
+	return c.current().HMGet(key, fields...)
+}
+
+func (c *swapClient) HMSet(key string, values ...interface{}) *redis.BoolCmd {
+	return c.current().HMSet(key, values...)
+}
+
+func (c *swapClient) HScan(key string, cursor uint64, match string, count int64) *redis.ScanCmd {
+	return c.current().HScan(key, cursor, match, count)
+}
+
+func (c *swapClient) HSet(key string, values ...interface{}) *redis.IntCmd {
+	return c.current().HSet(key, values...)
+}
+
+func (c *swapClient) HSetNX(key string, field string, value interface{}) *redis.BoolCmd {
+	return c.current().HSetNX(key, field, value)
+}
+
+func (c *swapClient) HVals(key string) *redis.StringSliceCmd {
+	return c.current().HVals(key)
+}
+
+func (c *swapClient) Incr(key string) *redis.IntCmd {
+	return c.current().Incr(key)
+}
+
+func (c *swapClient) IncrBy(key string, value int64) *redis.IntCmd {
+	return c.current().IncrBy(key, value)
+}
+
+func (c *swapClient) IncrByFloat(key string, value float64) *redis.FloatCmd {
+	return c.current().IncrByFloat(key, value)
+}
+
+func (c *swapClient) Info(section ...string) *redis.StringCmd {
+	return c.current().Info(section...)
+}
+
+func (c *swapClient) Keys(pattern string) *redis.StringSliceCmd {
+	return c.current().Keys(pattern)
+}
+
+func (c *swapClient) LIndex(key string, index int64) *redis.StringCmd {
+	return c.current().LIndex(key, index)
+}
+
+func (c *swapClient) LInsert(key string, op string, pivot interface{}, value interface{}) *redis.IntCmd {
+	return c.current().LInsert(key, op, pivot, value)
+}
+
+func (c *swapClient) LInsertAfter(key string, pivot interface{}, value interface{}) *redis.IntCmd {
+	return c.current().LInsertAfter(key, pivot, value)
+}
+
+func (c *swapClient) LInsertBefore(key string, pivot interface{}, value interface{}) *redis.IntCmd {
+	return c.current().LInsertBefore(key, pivot, value)
+}
+
+func (c *swapClient) LLen(key string) *redis.IntCmd {
+	return c.current().LLen(key)
+}
+
+func (c *swapClient) LPop(key string) *redis.StringCmd {
+	return c.current().LPop(key)
+}
+
+func (c *swapClient) LPush(key string, values ...interface{}) *redis.IntCmd {
+	return c.current().LPush(key, values...)
+}
+
+func (c *swapClient) LPushX(key string, values ...interface{}) *redis.IntCmd {
+	return c.current().LPushX(key, values...)
+}
+
+func (c *swapClient) LRange(key string, start int64, stop int64) *redis.StringSliceCmd {
+	return c.current().LRange(key, start, stop)
+}
+
+func (c *swapClient) LRem(key string, count int64, value interface{}) *redis.IntCmd {
+	return c.current().LRem(key, count, value)
+}
+
+func (c *swapClient) LSet(key string, index int64, value interface{}) *redis.StatusCmd {
+	return c.current().LSet(key, index, value)
+}
+
+func (c *swapClient) LTrim(key string, start int64, stop int64) *redis.StatusCmd {
+	return c.current().LTrim(key, start, stop)
+}
+
+func (c *swapClient) LastSave() *redis.IntCmd {
+	return c.current().LastSave()
+}
+
+func (c *swapClient) MGet(keys ...string) *redis.SliceCmd {
+	return c.current().MGet(keys...)
+}
+
+func (c *swapClient) MSet(values ...interface{}) *redis.StatusCmd {
+	return c.current().MSet(values...)
+}
+
+func (c *swapClient) MSetNX(values ...interface{}) *redis.BoolCmd {
+	return c.current().MSetNX(values...)
+}
+
+func (c *swapClient) MemoryUsage(key string, samples ...int) *redis.IntCmd {
+	return c.current().MemoryUsage(key, samples...)
+}
+
+func (c *swapClient) Migrate(host string, port string, key string, db int, timeout time.Duration) *redis.StatusCmd {
+	return c.current().Migrate(host, port, key, db, timeout)
+}
+
+func (c *swapClient) Move(key string, db int) *redis.BoolCmd {
+	return c.current().Move(key, db)
+}
+
+func (c *swapClient) ObjectEncoding(key string) *redis.StringCmd {
+	return c.current().ObjectEncoding(key)
+}
+
+func (c *swapClient) ObjectIdleTime(key string) *redis.DurationCmd {
+	return c.current().ObjectIdleTime(key)
+}
+
+func (c *swapClient) ObjectRefCount(key string) *redis.IntCmd {
+	return c.current().ObjectRefCount(key)
+}
+
+func (c *swapClient) PExpire(key string, expiration time.Duration) *redis.BoolCmd {
+	return c.current().PExpire(key, expiration)
+}
+
+func (c *swapClient) PExpireAt(key string, tm time.Time) *redis.BoolCmd {
+	return c.current().PExpireAt(key, tm)
+}
+
+func (c *swapClient) PFAdd(key string, els ...interface{}) *redis.IntCmd {
+	return c.current().PFAdd(key, els...)
+}
+
+func (c *swapClient) PFCount(keys ...string) *redis.IntCmd {
+	return c.current().PFCount(keys...)
+}
+
+func (c *swapClient) PFMerge(dest string, keys ...string) *redis.StatusCmd {
+	return c.current().PFMerge(dest, keys...)
+}
+
+func (c *swapClient) PSubscribe(channels ...string) *redis.PubSub {
+	return c.current().PSubscribe(channels...)
+}
+
+func (c *swapClient) PTTL(key string) *redis.DurationCmd {
+	return c.current().PTTL(key)
+}
+
+func (c *swapClient) Persist(key string) *redis.BoolCmd {
+	return c.current().Persist(key)
+}
+
+func (c *swapClient) Ping() *redis.StatusCmd {
+	return c.current().Ping()
+}
+
+func (c *swapClient) Pipeline() redis.Pipeliner {
+	return c.current().Pipeline()
+}
+
+func (c *swapClient) Pipelined(fn func(redis.Pipeliner) error) ([]redis.Cmder, error) {
+	return c.current().Pipelined(fn)
+}
+
+func (c *swapClient) Process(cmd redis.Cmder) error {
+	return c.current().Process(cmd)
+}
+
+func (c *swapClient) ProcessContext(ctx context.Context, cmd redis.Cmder) error {
+	return c.current().ProcessContext(ctx, cmd)
+}
+
+func (c *swapClient) PubSubChannels(pattern string) *redis.StringSliceCmd {
+	return c.current().PubSubChannels(pattern)
+}
+
+func (c *swapClient) PubSubNumPat() *redis.IntCmd {
+	return c.current().PubSubNumPat()
+}
+
+func (c *swapClient) PubSubNumSub(channels ...string) *redis.StringIntMapCmd {
+	return c.current().PubSubNumSub(channels...)
+}
+
+func (c *swapClient) Publish(channel string, message interface{}) *redis.IntCmd {
+	return c.current().Publish(channel, message)
+}
+
+func (c *swapClient) Quit() *redis.StatusCmd {
+	return c.current().Quit()
+}
+
+func (c *swapClient) RPop(key string) *redis.StringCmd {
+	return c.current().RPop(key)
+}
+
+func (c *swapClient) RPopLPush(source string, destination string) *redis.StringCmd {
+	return c.current().RPopLPush(source, destination)
+}
+
+func (c *swapClient) RPush(key string, values ...interface{}) *redis.IntCmd {
+	return c.current().RPush(key, values...)
+}
+
+func (c *swapClient) RPushX(key string, values ...interface{}) *redis.IntCmd {
+	return c.current().RPushX(key, values...)
+}
+
+func (c *swapClient) RandomKey() *redis.StringCmd {
+	return c.current().RandomKey()
+}
+
+func (c *swapClient) ReadOnly() *redis.StatusCmd {
+	return c.current().ReadOnly()
+}
+
+func (c *swapClient) ReadWrite() *redis.StatusCmd {
+	return c.current().ReadWrite()
+}
+
+func (c *swapClient) Rename(key string, newkey string) *redis.StatusCmd {
+	return c.current().Rename(key, newkey)
+}
+
+func (c *swapClient) RenameNX(key string, newkey string) *redis.BoolCmd {
+	return c.current().RenameNX(key, newkey)
+}
+
+func (c *swapClient) Restore(key string, ttl time.Duration, value string) *redis.StatusCmd {
+	return c.current().Restore(key, ttl, value)
+}
+
+func (c *swapClient) RestoreReplace(key string, ttl time.Duration, value string) *redis.StatusCmd {
+	return c.current().RestoreReplace(key, ttl, value)
+}
+
+func (c *swapClient) SAdd(key string, members ...interface{}) *redis.IntCmd {
+	return c.current().SAdd(key, members...)
+}
+
+func (c *swapClient) SCard(key string) *redis.IntCmd {
+	return c.current().SCard(key)
+}
+
+func (c *swapClient) SDiff(keys ...string) *redis.StringSliceCmd {
+	return c.current().SDiff(keys...)
+}
+
+func (c *swapClient) SDiffStore(destination string, keys ...string) *redis.IntCmd {
+	return c.current().SDiffStore(destination, keys...)
+}
+
+func (c *swapClient) SInter(keys ...string) *redis.StringSliceCmd {
+	return c.current().SInter(keys...)
+}
+
+func (c *swapClient) SInterStore(destination string, keys ...string) *redis.IntCmd {
+	return c.current().SInterStore(destination, keys...)
+}
+
+func (c *swapClient) SIsMember(key string, member interface{}) *redis.BoolCmd {
+	return c.current().SIsMember(key, member)
+}
+
+func (c *swapClient) SMembers(key string) *redis.StringSliceCmd {
+	return c.current().SMembers(key)
+}
+
+func (c *swapClient) SMembersMap(key string) *redis.StringStructMapCmd {
+	return c.current().SMembersMap(key)
+}
+
+func (c *swapClient) SMove(source string, destination string, member interface{}) *redis.BoolCmd {
+	return c.current().SMove(source, destination, member)
+}
+
+func (c *swapClient) SPop(key string) *redis.StringCmd {
+	return c.current().SPop(key)
+}
+
+func (c *swapClient) SPopN(key string, count int64) *redis.StringSliceCmd {
+	return c.current().SPopN(key, count)
+}
+
+func (c *swapClient) SRandMember(key string) *redis.StringCmd {
+	return c.current().SRandMember(key)
+}
+
+func (c *swapClient) SRandMemberN(key string, count int64) *redis.StringSliceCmd {
+	return c.current().SRandMemberN(key, count)
+}
+
+func (c *swapClient) SRem(key string, members ...interface{}) *redis.IntCmd {
+	return c.current().SRem(key, members...)
+}
+
+func (c *swapClient) SScan(key string, cursor uint64, match string, count int64) *redis.ScanCmd {
+	return c.current().SScan(key, cursor, match, count)
+}
+
+func (c *swapClient) SUnion(keys ...string) *redis.StringSliceCmd {
+	return c.current().SUnion(keys...)
+}
+
+func (c *swapClient) SUnionStore(destination string, keys ...string) *redis.IntCmd {
+	return c.current().SUnionStore(destination, keys...)
+}
+
+func (c *swapClient) Save() *redis.StatusCmd {
+	return c.current().Save()
+}
+
+func (c *swapClient) Scan(cursor uint64, match string, count int64) *redis.ScanCmd {
+	return c.current().Scan(cursor, match, count)
+}
+
+func (c *swapClient) ScriptExists(hashes ...string) *redis.BoolSliceCmd {
+	return c.current().ScriptExists(hashes...)
+}
+
+func (c *swapClient) ScriptFlush() *redis.StatusCmd {
+	return c.current().ScriptFlush()
+}
+
+func (c *swapClient) ScriptKill() *redis.StatusCmd {
+	return c.current().ScriptKill()
+}
+
+func (c *swapClient) ScriptLoad(script string) *redis.StringCmd {
+	return c.current().ScriptLoad(script)
+}
+
+func (c *swapClient) Set(key string, value interface{}, expiration time.Duration) *redis.StatusCmd {
+	return c.current().Set(key, value, expiration)
+}
+
+func (c *swapClient) SetBit(key string, offset int64, value int) *redis.IntCmd {
+	return c.current().SetBit(key, offset, value)
+}
+
+func (c *swapClient) SetNX(key string, value interface{}, expiration time.Duration) *redis.BoolCmd {
+	return c.current().SetNX(key, value, expiration)
+}
+
+func (c *swapClient) SetRange(key string, offset int64, value string) *redis.IntCmd {
+	return c.current().SetRange(key, offset, value)
+}
+
+func (c *swapClient) SetXX(key string, value interface{}, expiration time.Duration) *redis.BoolCmd {
+	return c.current().SetXX(key, value, expiration)
+}
+
+func (c *swapClient) Shutdown() *redis.StatusCmd {
+	return c.current().Shutdown()
+}
+
+func (c *swapClient) ShutdownNoSave() *redis.StatusCmd {
+	return c.current().ShutdownNoSave()
+}
+
+func (c *swapClient) ShutdownSave() *redis.StatusCmd {
+	return c.current().ShutdownSave()
+}
+
+func (c *swapClient) SlaveOf(host string, port string) *redis.StatusCmd {
+	return c.current().SlaveOf(host, port)
+}
+
+func (c *swapClient) Sort(key string, sort *redis.Sort) *redis.StringSliceCmd {
+	return c.current().Sort(key, sort)
+}
+
+func (c *swapClient) SortInterfaces(key string, sort *redis.Sort) *redis.SliceCmd {
+	return c.current().SortInterfaces(key, sort)
+}
+
+func (c *swapClient) SortStore(key string, store string, sort *redis.Sort) *redis.IntCmd {
+	return c.current().SortStore(key, store, sort)
+}
+
+func (c *swapClient) StrLen(key string) *redis.IntCmd {
+	return c.current().StrLen(key)
+}
+
+func (c *swapClient) Subscribe(channels ...string) *redis.PubSub {
+	return c.current().Subscribe(channels...)
+}
+
+func (c *swapClient) TTL(key string) *redis.DurationCmd {
+	return c.current().TTL(key)
+}
+
+func (c *swapClient) Time() *redis.TimeCmd {
+	return c.current().Time()
+}
+
+func (c *swapClient) Touch(keys ...string) *redis.IntCmd {
+	return c.current().Touch(keys...)
+}
+
+func (c *swapClient) TxPipeline() redis.Pipeliner {
+	return c.current().TxPipeline()
+}
+
+func (c *swapClient) TxPipelined(fn func(redis.Pipeliner) error) ([]redis.Cmder, error) {
+	return c.current().TxPipelined(fn)
+}
+
+func (c *swapClient) Type(key string) *redis.StatusCmd {
+	return c.current().Type(key)
+}
+
+func (c *swapClient) Unlink(keys ...string) *redis.IntCmd {
+	return c.current().Unlink(keys...)
+}
+
+func (c *swapClient) Watch(fn func(*redis.Tx) error, keys ...string) error {
+	return c.current().Watch(fn, keys...)
+}
+
+func (c *swapClient) XAck(stream string, group string, ids ...string) *redis.IntCmd {
+	return c.current().XAck(stream, group, ids...)
+}
+
+func (c *swapClient) XAdd(a *redis.XAddArgs) *redis.StringCmd {
+	return c.current().XAdd(a)
+}
+
+func (c *swapClient) XClaim(a *redis.XClaimArgs) *redis.XMessageSliceCmd {
+	return c.current().XClaim(a)
+}
+
+func (c *swapClient) XClaimJustID(a *redis.XClaimArgs) *redis.StringSliceCmd {
+	return c.current().XClaimJustID(a)
+}
+
+func (c *swapClient) XDel(stream string, ids ...string) *redis.IntCmd {
+	return c.current().XDel(stream, ids...)
+}
+
+func (c *swapClient) XGroupCreate(stream string, group string, start string) *redis.StatusCmd {
+	return c.current().XGroupCreate(stream, group, start)
+}
+
+func (c *swapClient) XGroupCreateMkStream(stream string, group string, start string) *redis.StatusCmd {
+	return c.current().XGroupCreateMkStream(stream, group, start)
+}
+
+func (c *swapClient) XGroupDelConsumer(stream string, group string, consumer string) *redis.IntCmd {
+	return c.current().XGroupDelConsumer(stream, group, consumer)
+}
+
+func (c *swapClient) XGroupDestroy(stream string, group string) *redis.IntCmd {
+	return c.current().XGroupDestroy(stream, group)
+}
+
+func (c *swapClient) XGroupSetID(stream string, group string, start string) *redis.StatusCmd {
+	return c.current().XGroupSetID(stream, group, start)
+}
+
+func (c *swapClient) XInfoGroups(key string) *redis.XInfoGroupsCmd {
+	return c.current().XInfoGroups(key)
+}
+
+func (c *swapClient) XLen(stream string) *redis.IntCmd {
+	return c.current().XLen(stream)
+}
+
+func (c *swapClient) XPending(stream string, group string) *redis.XPendingCmd {
+	return c.current().XPending(stream, group)
+}
+
+func (c *swapClient) XPendingExt(a *redis.XPendingExtArgs) *redis.XPendingExtCmd {
+	return c.current().XPendingExt(a)
+}
+
+func (c *swapClient) XRange(stream string, start string, stop string) *redis.XMessageSliceCmd {
+	return c.current().XRange(stream, start, stop)
+}
+
+func (c *swapClient) XRangeN(stream string, start string, stop string, count int64) *redis.XMessageSliceCmd {
+	return c.current().XRangeN(stream, start, stop, count)
+}
+
+func (c *swapClient) XRead(a *redis.XReadArgs) *redis.XStreamSliceCmd {
+	return c.current().XRead(a)
+}
+
+func (c *swapClient) XReadGroup(a *redis.XReadGroupArgs) *redis.XStreamSliceCmd {
+	return c.current().XReadGroup(a)
+}
+
+func (c *swapClient) XReadStreams(streams ...string) *redis.XStreamSliceCmd {
+	return c.current().XReadStreams(streams...)
+}
+
+func (c *swapClient) XRevRange(stream string, start string, stop string) *redis.XMessageSliceCmd {
+	return c.current().XRevRange(stream, start, stop)
+}
+
+func (c *swapClient) XRevRangeN(stream string, start string, stop string, count int64) *redis.XMessageSliceCmd {
+	return c.current().XRevRangeN(stream, start, stop, count)
+}
+
+func (c *swapClient) XTrim(key string, maxLen int64) *redis.IntCmd {
+	return c.current().XTrim(key, maxLen)
+}
+
+func (c *swapClient) XTrimApprox(key string, maxLen int64) *redis.IntCmd {
+	return c.current().XTrimApprox(key, maxLen)
+}
+
+func (c *swapClient) ZAdd(key string, members ...*redis.Z) *redis.IntCmd {
+	return c.current().ZAdd(key, members...)
+}
+
+func (c *swapClient) ZAddCh(key string, members ...*redis.Z) *redis.IntCmd {
+	return c.current().ZAddCh(key, members...)
+}
+
+func (c *swapClient) ZAddNX(key string, members ...*redis.Z) *redis.IntCmd {
+	return c.current().ZAddNX(key, members...)
+}
+
+func (c *swapClient) ZAddNXCh(key string, members ...*redis.Z) *redis.IntCmd {
+	return c.current().ZAddNXCh(key, members...)
+}
+
+func (c *swapClient) ZAddXX(key string, members ...*redis.Z) *redis.IntCmd {
+	return c.current().ZAddXX(key, members...)
+}
+
+func (c *swapClient) ZAddXXCh(key string, members ...*redis.Z) *redis.IntCmd {
+	return c.current().ZAddXXCh(key, members...)
+}
+
+func (c *swapClient) ZCard(key string) *redis.IntCmd {
+	return c.current().ZCard(key)
+}
+
+func (c *swapClient) ZCount(key string, min string, max string) *redis.IntCmd {
+	return c.current().ZCount(key, min, max)
+}
+
+func (c *swapClient) ZIncr(key string, member *redis.Z) *redis.FloatCmd {
+	return c.current().ZIncr(key, member)
+}
+
+func (c *swapClient) ZIncrBy(key string, increment float64, member string) *redis.FloatCmd {
+	return c.current().ZIncrBy(key, increment, member)
+}
+
+func (c *swapClient) ZIncrNX(key string, member *redis.Z) *redis.FloatCmd {
+	return c.current().ZIncrNX(key, member)
+}
+
+func (c *swapClient) ZIncrXX(key string, member *redis.Z) *redis.FloatCmd {
+	return c.current().ZIncrXX(key, member)
+}
+
+func (c *swapClient) ZInterStore(destination string, store *redis.ZStore) *redis.IntCmd {
+	return c.current().ZInterStore(destination, store)
+}
+
+func (c *swapClient) ZLexCount(key string, min string, max string) *redis.IntCmd {
+	return c.current().ZLexCount(key, min, max)
+}
+
+func (c *swapClient) ZPopMax(key string, count ...int64) *redis.ZSliceCmd {
+	return c.current().ZPopMax(key, count...)
+}
+
+func (c *swapClient) ZPopMin(key string, count ...int64) *redis.ZSliceCmd {
+	return c.current().ZPopMin(key, count...)
+}
+
+func (c *swapClient) ZRange(key string, start int64, stop int64) *redis.StringSliceCmd {
+	return c.current().ZRange(key, start, stop)
+}
+
+func (c *swapClient) ZRangeByLex(key string, opt *redis.ZRangeBy) *redis.StringSliceCmd {
+	return c.current().ZRangeByLex(key, opt)
+}
+
+func (c *swapClient) ZRangeByScore(key string, opt *redis.ZRangeBy) *redis.StringSliceCmd {
+	return c.current().ZRangeByScore(key, opt)
+}
+
+func (c *swapClient) ZRangeByScoreWithScores(key string, opt *redis.ZRangeBy) *redis.ZSliceCmd {
+	return c.current().ZRangeByScoreWithScores(key, opt)
+}
+
+func (c *swapClient) ZRangeWithScores(key string, start int64, stop int64) *redis.ZSliceCmd {
+	return c.current().ZRangeWithScores(key, start, stop)
+}
+
+func (c *swapClient) ZRank(key string, member string) *redis.IntCmd {
+	return c.current().ZRank(key, member)
+}
+
+func (c *swapClient) ZRem(key string, members ...interface{}) *redis.IntCmd {
+	return c.current().ZRem(key, members...)
+}
+
+func (c *swapClient) ZRemRangeByLex(key string, min string, max string) *redis.IntCmd {
+	return c.current().ZRemRangeByLex(key, min, max)
+}
+
+func (c *swapClient) ZRemRangeByRank(key string, start int64, stop int64) *redis.IntCmd {
+	return c.current().ZRemRangeByRank(key, start, stop)
+}
+
+func (c *swapClient) ZRemRangeByScore(key string, min string, max string) *redis.IntCmd {
+	return c.current().ZRemRangeByScore(key, min, max)
+}
+
+func (c *swapClient) ZRevRange(key string, start int64, stop int64) *redis.StringSliceCmd {
+	return c.current().ZRevRange(key, start, stop)
+}
+
+func (c *swapClient) ZRevRangeByLex(key string, opt *redis.ZRangeBy) *redis.StringSliceCmd {
+	return c.current().ZRevRangeByLex(key, opt)
+}
+
+func (c *swapClient) ZRevRangeByScore(key string, opt *redis.ZRangeBy) *redis.StringSliceCmd {
+	return c.current().ZRevRangeByScore(key, opt)
+}
+
+func (c *swapClient) ZRevRangeByScoreWithScores(key string, opt *redis.ZRangeBy) *redis.ZSliceCmd {
+	return c.current().ZRevRangeByScoreWithScores(key, opt)
+}
+
+func (c *swapClient) ZRevRangeWithScores(key string, start int64, stop int64) *redis.ZSliceCmd {
+	return c.current().ZRevRangeWithScores(key, start, stop)
+}
+
+func (c *swapClient) ZRevRank(key string, member string) *redis.IntCmd {
+	return c.current().ZRevRank(key, member)
+}
+
+func (c *swapClient) ZScan(key string, cursor uint64, match string, count int64) *redis.ScanCmd {
+	return c.current().ZScan(key, cursor, match, count)
+}
+
+func (c *swapClient) ZScore(key string, member string) *redis.FloatCmd {
+	return c.current().ZScore(key, member)
+}
+
+func (c *swapClient) ZUnionStore(dest string, store *redis.ZStore) *redis.IntCmd {
+	return c.current().ZUnionStore(dest, store)
+}
@@ -0,0 +1,115 @@
+package redis
+
+import (
+	"context"
+	"encoding/binary"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+// staleMagic prefix of values with a soft ttl, followed by the soft expiration in unix ms
+const staleMagic = "\xffS"
+
+// revalidateTimeout timeout of background refreshes, peers do not refresh the key meanwhile
+const revalidateTimeout = 30 * time.Second
+
+// GetOrLoadStale stale-while-revalidate: get value of key into v, load and store it when missed.
+// Values are kept until hard ttl, reads after soft ttl return the stale value immediately and
+// refresh it by loader in background, once per key across instances. Values of key must be written by it.
+func (c *Cache) GetOrLoadStale(ctx context.Context, key string, v interface{}, soft, hard time.Duration, loader Loader) error {
+	if hard < soft {
+		hard = soft
+	}
+
+	text, err := c.redis.DoContext(ctx, "get", c.key(key)).Text()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+
+	if err == nil {
+		data, softAt := staleEnvelope([]byte(text))
+		if err := c.decode(key, data, v); err != nil {
+			return err
+		}
+
+		if softAt > 0 && unixMilli(time.Now()) >= softAt {
+			c.revalidate(key, soft, hard, loader)
+		}
+
+		return nil
+	}
+
+	if err := c.redis.waitLoad(ctx); err != nil {
+		return err
+	}
+
+	data, err := c.storeStale(ctx, key, loader, soft, hard)
+	if err != nil {
+		return err
+	}
+
+	return c.decode(key, data, v)
+}
+
+// revalidate refresh key in background unless it is being refreshed by this or another instance
+func (c *Cache) revalidate(key string, soft, hard time.Duration, loader Loader) {
+	if _, loaded := c.revalidating.LoadOrStore(key, struct{}{}); loaded {
+		return
+	}
+
+	go func() {
+		defer c.revalidating.Delete(key)
+
+		ctx, cancel := context.WithTimeout(context.Background(), revalidateTimeout)
+		defer cancel()
+
+		claim := c.key(key) + ":revalidate"
+		if err := c.redis.DoContext(ctx, "set", claim, 1, "nx", "px", int64(revalidateTimeout/time.Millisecond)).Err(); err != nil {
+			return
+		}
+		defer c.redis.DoContext(ctx, "del", claim)
+
+		if _, err := c.storeStale(ctx, key, loader, soft, hard); err != nil {
+			c.redis.logf("revalidate %s: %v", c.key(key), err)
+		}
+	}()
+}
+
+// storeStale load value and store it with hard ttl and the soft expiration, the wrapped data is returned
+func (c *Cache) storeStale(ctx context.Context, key string, loader Loader, soft, hard time.Duration) ([]byte, error) {
+	val, err := loader(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := c.encode(key, val)
+	if err != nil {
+		return nil, err
+	}
+
+	hard = c.ttl(hard)
+	if soft > hard {
+		soft = hard
+	}
+
+	envelope := make([]byte, len(staleMagic)+8, len(staleMagic)+8+len(data))
+	copy(envelope, staleMagic)
+	binary.BigEndian.PutUint64(envelope[len(staleMagic):], uint64(unixMilli(time.Now().Add(soft))))
+	envelope = append(envelope, data...)
+
+	if err := c.redis.ProcessContext(ctx, redis.NewStatusCmd(setArgs(c.key(key), envelope, hard)...)); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// staleEnvelope data and soft expiration of stored value, softAt is 0 for values without a soft ttl
+func staleEnvelope(raw []byte) (data []byte, softAt int64) {
+	if len(raw) < len(staleMagic)+8 || string(raw[:len(staleMagic)]) != staleMagic {
+		return raw, 0
+	}
+
+	return raw[len(staleMagic)+8:], int64(binary.BigEndian.Uint64(raw[len(staleMagic):]))
+}
@@ -0,0 +1,155 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	// SetOption option of Cache.Set
+	SetOption func(*setOptions)
+
+	setOptions struct {
+		tags []string
+	}
+)
+
+// tagLua add key to the tag set, the set lives as long as its longest-lived key, so it expires once all keys may be expired
+const tagLua = `
+local function tag(set, key, ttl)
+	local fresh = redis.call('exists', set) == 0
+	redis.call('sadd', set, key)
+	if ttl == 0 then
+		redis.call('persist', set)
+		return
+	end
+	local current = redis.call('pttl', set)
+	if fresh or (current > 0 and current < ttl) then
+		redis.call('pexpire', set, ttl)
+	end
+end
+`
+
+var (
+	// set value with tags. KEYS[1]: key, KEYS[2..]: tag sets. ARGV: value, ttl in ms (0 is no ttl)
+	setTaggedScript = newScript(tagLua + `
+local ttl = tonumber(ARGV[2])
+if ttl > 0 then
+	redis.call('set', KEYS[1], ARGV[1], 'px', ttl)
+else
+	redis.call('set', KEYS[1], ARGV[1])
+end
+for i = 2, #KEYS do
+	tag(KEYS[i], KEYS[1], ttl)
+end
+return 1
+`)
+
+	// tag a key. KEYS[1]: tag set. ARGV: key, ttl in ms (0 is no ttl)
+	tagScript = newScript(tagLua + `
+tag(KEYS[1], ARGV[1], tonumber(ARGV[2]))
+return 1
+`)
+
+	// delete keys of tags and the tag sets. KEYS: tag sets
+	invalidateTagScript = newScript(`
+local n = 0
+for _, set in ipairs(KEYS) do
+	local members = redis.call('smembers', set)
+	for i = 1, #members, 500 do
+		n = n + redis.call('del', unpack(members, i, math.min(i + 499, #members)))
+	end
+	redis.call('del', set)
+end
+return n
+`)
+)
+
+// WithTags tag the value, all keys carrying a tag are deleted by InvalidateTag
+func WithTags(tags ...string) SetOption {
+	return func(o *setOptions) {
+		o.tags = append(o.tags, tags...)
+	}
+}
+
+// InvalidateTag delete keys carrying tags and the tag sets atomically.
+// In cluster mode keys are usually in other slots than the tag set, they are deleted one by one instead,
+// and only the deleted keys are removed from the tag set so keys tagged meanwhile are kept.
+func (c *Cache) InvalidateTag(ctx context.Context, tags ...string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	if !c.redis.isCluster() {
+		sets := make([]string, len(tags))
+		for i, tag := range tags {
+			sets[i] = c.tagKey(tag)
+		}
+
+		return invalidateTagScript.run(ctx, c.redis, sets).Err()
+	}
+
+	for _, tag := range tags {
+		set := c.tagKey(tag)
+
+		members, err := c.redis.DoContext(ctx, "smembers", set).Result()
+		if err != nil {
+			return err
+		}
+
+		keys, _ := members.([]interface{})
+		if len(keys) == 0 {
+			continue
+		}
+
+		_, err = c.redis.Pipelined(func(pipe redis.Pipeliner) error {
+			for _, key := range keys {
+				pipe.Do("del", key)
+			}
+			pipe.Do(append([]interface{}{"srem", set}, keys...)...)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setTagged store data of key with ttl and add key to the tag sets, atomically unless in cluster mode
+func (c *Cache) setTagged(ctx context.Context, key string, data []byte, ttl time.Duration, tags []string) error {
+	ms := int64(ttl / time.Millisecond)
+	if ttl > 0 && ms == 0 {
+		ms = 1
+	}
+
+	if !c.redis.isCluster() {
+		keys := make([]string, 0, len(tags)+1)
+		keys = append(keys, c.key(key))
+		for _, tag := range tags {
+			keys = append(keys, c.tagKey(tag))
+		}
+
+		return setTaggedScript.run(ctx, c.redis, keys, data, ms).Err()
+	}
+
+	if err := c.redis.ProcessContext(ctx, redis.NewStatusCmd(setArgs(c.key(key), data, ttl)...)); err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		if err := tagScript.run(ctx, c.redis, []string{c.tagKey(tag)}, c.key(key), ms).Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// tagKey key of the tag set, members are full keys
+func (c *Cache) tagKey(tag string) string {
+	return c.key("tag:" + tag)
+}
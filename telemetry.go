@@ -0,0 +1,31 @@
+package redis
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// InstanceHeader response header of admin endpoints carrying the instance
+	InstanceHeader = "X-Redis-Instance"
+)
+
+// Instance telemetry dimension of the instance, it is the box name (e.g. "redis.sessions").
+// Metrics carry it as the "redis_instance" label (not "instance", the target label of prometheus), logs are prefixed by it, admin responses carry it in InstanceHeader.
+func (r *Redis) Instance() string {
+	return r.name
+}
+
+// Labels const labels identifying the instance, for collectors of subsystems built on the instance
+func (r *Redis) Labels() prometheus.Labels {
+	return prometheus.Labels{"redis_instance": r.Instance()}
+}
+
+// instanceHandler set InstanceHeader of responses
+func (r *Redis) instanceHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set(InstanceHeader, r.Instance())
+		h.ServeHTTP(w, req)
+	})
+}
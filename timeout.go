@@ -0,0 +1,103 @@
+package redis
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	// timeoutHook set context deadline of commands by the timeouts policy, added only when timeouts are configured
+	timeoutHook struct {
+		r *Redis
+	}
+
+	timeoutCancelKey struct{}
+)
+
+// Command classes of timeouts policy
+const (
+	TimeoutRead     = "read"
+	TimeoutWrite    = "write"
+	TimeoutBlocking = "blocking"
+)
+
+var (
+	// blockingCommands wait for data on server side, they are unlimited unless the blocking class is configured
+	blockingCommands = map[string]bool{
+		"blpop": true, "brpop": true, "brpoplpush": true, "bzpopmin": true, "bzpopmax": true,
+		"xread": true, "xreadgroup": true, "wait": true,
+	}
+)
+
+// commandTimeout timeout of command name: by name, then by class, zero is unlimited
+func (r *Redis) commandTimeout(name string) time.Duration {
+	name = strings.ToLower(name)
+
+	if timeout, ok := r.Timeouts[name]; ok {
+		return timeout
+	}
+
+	switch {
+	case blockingCommands[name]:
+		return r.Timeouts[TimeoutBlocking]
+	case readOnlyCommands[name]:
+		return r.Timeouts[TimeoutRead]
+	default:
+		return r.Timeouts[TimeoutWrite]
+	}
+}
+
+func (h timeoutHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return h.before(ctx, h.r.commandTimeout(cmd.Name())), nil
+}
+
+func (h timeoutHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	return h.after(ctx)
+}
+
+// BeforeProcessPipeline pipeline is limited by the longest timeout of its commands
+func (h timeoutHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	var max time.Duration
+	for _, cmd := range cmds {
+		timeout := h.r.commandTimeout(cmd.Name())
+		if timeout <= 0 {
+			return ctx, nil
+		}
+		if timeout > max {
+			max = timeout
+		}
+	}
+
+	return h.before(ctx, max), nil
+}
+
+func (h timeoutHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	return h.after(ctx)
+}
+
+// before deadline of the caller is kept when it is earlier
+func (h timeoutHook) before(ctx context.Context, timeout time.Duration) context.Context {
+	if timeout <= 0 {
+		return ctx
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+
+	return context.WithValue(ctx, timeoutCancelKey{}, cancel)
+}
+
+func (h timeoutHook) after(ctx context.Context) error {
+	releaseTimeout(ctx)
+
+	return nil
+}
+
+// releaseTimeout cancel the timeout of ctx set by timeoutHook, if any
+func releaseTimeout(ctx context.Context) {
+	if cancel, ok := ctx.Value(timeoutCancelKey{}).(context.CancelFunc); ok {
+		cancel()
+	}
+}
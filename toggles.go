@@ -0,0 +1,235 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	// toggles runtime adjustable settings by name.
+	// Appliers are called without holding mu, so they may read toggles, applying of a toggle is serialized by its lock.
+	toggles struct {
+		mu       sync.RWMutex
+		values   map[string]string
+		appliers map[string]func(value string) error
+		locks    map[string]*sync.Mutex
+	}
+)
+
+const (
+	// ToggleMetricsSampleRate fraction (0-1) of commands observed by latency summaries
+	ToggleMetricsSampleRate = "metricsSampleRate"
+	// ToggleSlowThreshold commands slower than it are logged, e.g. 100ms, 0 disables
+	ToggleSlowThreshold = "slowThreshold"
+	// ToggleMaxInFlight in-flight commands beyond it are shed, registered when maxInFlight is configured
+	ToggleMaxInFlight = "maxInFlight"
+	// ToggleConsumerPause true pauses stream and partitioned consumers of this process
+	ToggleConsumerPause = "consumerPause"
+)
+
+// RegisterToggle register a runtime toggle, apply is called with every new value and rejects invalid ones by error.
+// Subsystems register their own toggles next to the builtin ones.
+func (r *Redis) RegisterToggle(name, value string, apply func(value string) error) error {
+	r.toggles.mu.Lock()
+	if r.toggles.values == nil {
+		r.toggles.values = map[string]string{}
+		r.toggles.appliers = map[string]func(string) error{}
+		r.toggles.locks = map[string]*sync.Mutex{}
+	}
+	r.toggles.appliers[name] = apply
+	if r.toggles.locks[name] == nil {
+		r.toggles.locks[name] = &sync.Mutex{}
+	}
+	r.toggles.mu.Unlock()
+
+	return r.SetToggle(name, value)
+}
+
+// SetToggle set toggle value of this process
+func (r *Redis) SetToggle(name, value string) error {
+	r.toggles.mu.RLock()
+	apply, ok := r.toggles.appliers[name]
+	lock := r.toggles.locks[name]
+	r.toggles.mu.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("redis: unknown toggle %s", name)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	if err := apply(value); err != nil {
+		return fmt.Errorf("redis: invalid toggle %s=%s: %w", name, value, err)
+	}
+
+	r.toggles.mu.Lock()
+	r.toggles.values[name] = value
+	r.toggles.mu.Unlock()
+
+	return nil
+}
+
+// Toggle current value of toggle
+func (r *Redis) Toggle(name string) (string, bool) {
+	r.toggles.mu.RLock()
+	defer r.toggles.mu.RUnlock()
+
+	value, ok := r.toggles.values[name]
+
+	return value, ok
+}
+
+// Toggles current values of all toggles
+func (r *Redis) Toggles() map[string]string {
+	r.toggles.mu.RLock()
+	defer r.toggles.mu.RUnlock()
+
+	values := make(map[string]string, len(r.toggles.values))
+	for name, value := range r.toggles.values {
+		values[name] = value
+	}
+
+	return values
+}
+
+// PublishToggle store toggle value at controlKey, all instances apply it on next refresh
+func (r *Redis) PublishToggle(ctx context.Context, name, value string) error {
+	if r.ControlKey == "" {
+		return fmt.Errorf("redis: controlKey is not configured")
+	}
+
+	if err := r.SetToggle(name, value); err != nil {
+		return err
+	}
+
+	return r.DoContext(ctx, "hset", r.ControlKey, name, value).Err()
+}
+
+// registerBuiltinToggles toggles of the core client
+func (r *Redis) registerBuiltinToggles() {
+	r.RegisterToggle(ToggleMetricsSampleRate, "1", func(value string) error {
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("out of range [0, 1]")
+		}
+
+		atomic.StoreUint64(&r.sampleRate, math.Float64bits(rate))
+		return nil
+	})
+
+	r.RegisterToggle(ToggleSlowThreshold, r.SlowThreshold.String(), func(value string) error {
+		threshold, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+
+		atomic.StoreInt64(&r.slowThreshold, int64(threshold))
+		return nil
+	})
+
+	if r.limiter != nil {
+		r.RegisterToggle(ToggleMaxInFlight, strconv.Itoa(r.MaxInFlight), func(value string) error {
+			max, err := strconv.Atoi(value)
+			if err != nil {
+				return err
+			}
+			if max < 1 {
+				return fmt.Errorf("must be positive")
+			}
+
+			if current, _ := r.Toggle(ToggleMaxInFlight); current != value {
+				r.limiter.resize(max)
+			}
+			return nil
+		})
+	}
+
+	r.RegisterToggle(ToggleConsumerPause, "false", func(value string) error {
+		paused, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+
+		if paused {
+			atomic.StoreInt32(&r.consumersPaused, 1)
+		} else {
+			atomic.StoreInt32(&r.consumersPaused, 0)
+		}
+		return nil
+	})
+
+	if r.Chaos {
+		if err := r.RegisterToggle(ToggleChaosProfile, r.ChaosProfile, r.applyChaosProfile); err != nil {
+			r.logf("%v", err)
+			r.applyChaosProfile("")
+		}
+	}
+}
+
+// refreshToggles apply toggles stored at controlKey periodically until shutdown
+func (r *Redis) refreshToggles(done <-chan struct{}) {
+	interval := r.ControlRefresh
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		r.loadToggles()
+
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Redis) loadToggles() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cmd := redis.NewStringStringMapCmd("hgetall", r.ControlKey)
+	if err := r.ProcessContext(ctx, cmd); err != nil {
+		return
+	}
+
+	values := cmd.Val()
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if current, _ := r.Toggle(name); current == values[name] {
+			continue
+		}
+
+		if err := r.SetToggle(name, values[name]); err != nil {
+			r.logf("control key %s: %v", r.ControlKey, err)
+		}
+	}
+}
+
+func (r *Redis) metricsSampled() bool {
+	rate := math.Float64frombits(atomic.LoadUint64(&r.sampleRate))
+
+	return rate >= 1 || rand.Float64() < rate
+}
@@ -0,0 +1,231 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type (
+	// TopologyEvent failover or slot migration observed by the box
+	TopologyEvent struct {
+		Type   string    `json:"type"`
+		Master string    `json:"master,omitempty"` // master name of sentinel clients
+		From   string    `json:"from"`             // old master address
+		To     string    `json:"to"`               // new master address
+		Slots  int       `json:"slots,omitempty"`  // slots moved from From to To of cluster clients
+		At     time.Time `json:"at"`
+	}
+
+	// topology watchers of topology events
+	topology struct {
+		mu        sync.Mutex
+		callbacks []func(TopologyEvent)
+		master    string         // last switched master of sentinel clients
+		slots     map[int]string // master by slot of cluster clients
+		counter   *prometheus.CounterVec
+		emitting  sync.Mutex
+	}
+)
+
+// Topology event types
+const (
+	TopologySwitchMaster = "switch-master"
+	TopologySlotsMoved   = "slots-moved"
+)
+
+// OnTopologyChange call fn on each failover of sentinel clients (+switch-master) and slot ownership change of cluster clients,
+// e.g. to flush local caches or alert. Requires topologyEvents, fn is never called concurrently.
+func (r *Redis) OnTopologyChange(fn func(TopologyEvent)) {
+	r.topology.mu.Lock()
+	defer r.topology.mu.Unlock()
+
+	r.topology.callbacks = append(r.topology.callbacks, fn)
+}
+
+// watchTopology subscribe +switch-master of sentinels, or compare CLUSTER SLOTS every topologyCheck, until shutdown
+func (r *Redis) watchTopology(done <-chan struct{}) {
+	if r.Metrics {
+		r.topology.counter = mustRegister(prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: r.metrics.Namespace,
+				Subsystem: r.metrics.Subsystem,
+				Name:      "redis_topology_events_total",
+				Help:      "redis failovers and slot ownership changes total",
+			},
+			[]string{"redis_instance", "event"},
+		)).(*prometheus.CounterVec)
+	}
+
+	switch {
+	case r.MasterName != "":
+		r.watchSentinels(done)
+	case r.isCluster():
+		r.watchSlots(done)
+	}
+}
+
+func (r *Redis) watchSentinels(done <-chan struct{}) {
+	password, _ := r.reload.password.Load().(string)
+
+	sentinels := make([]*redis.SentinelClient, 0, len(r.Address))
+	for _, addr := range r.Address {
+		sentinel := redis.NewSentinelClient(&redis.Options{
+			Addr:        addr,
+			Password:    password,
+			TLSConfig:   r.tlsConfig,
+			DialTimeout: r.dialTimeout,
+		})
+		sentinels = append(sentinels, sentinel)
+
+		// every sentinel publishes the switch, events are deduplicated by the new address
+		ps := sentinel.Subscribe("+switch-master")
+		go func() {
+			for msg := range ps.Channel() {
+				r.switchMaster(msg.Payload)
+			}
+		}()
+	}
+
+	<-done
+
+	for _, sentinel := range sentinels {
+		sentinel.Close()
+	}
+}
+
+// switchMaster handle "<master name> <old ip> <old port> <new ip> <new port>"
+func (r *Redis) switchMaster(payload string) {
+	fields := strings.Fields(payload)
+	if len(fields) < 5 || fields[0] != r.MasterName {
+		return
+	}
+
+	event := TopologyEvent{
+		Type:   TopologySwitchMaster,
+		Master: fields[0],
+		From:   fields[1] + ":" + fields[2],
+		To:     fields[3] + ":" + fields[4],
+		At:     time.Now(),
+	}
+
+	r.topology.mu.Lock()
+	duplicated := r.topology.master == event.To
+	r.topology.master = event.To
+	r.topology.mu.Unlock()
+
+	if duplicated {
+		return
+	}
+
+	r.emitTopology(event)
+}
+
+func (r *Redis) watchSlots(done <-chan struct{}) {
+	interval := r.TopologyCheck
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		slots, err := r.clusterSlots(ctx)
+		cancel()
+
+		if err != nil {
+			r.logf("topology: %v", err)
+		} else {
+			r.compareSlots(slots)
+		}
+
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// compareSlots emit an event per (from, to) pair of slots changed owner since last check
+func (r *Redis) compareSlots(slots map[int]string) {
+	r.topology.mu.Lock()
+	previous := r.topology.slots
+	r.topology.slots = slots
+	r.topology.mu.Unlock()
+
+	if previous == nil {
+		return
+	}
+
+	moved := map[[2]string]int{}
+	for slot, to := range slots {
+		if from, ok := previous[slot]; ok && from != to {
+			moved[[2]string{from, to}]++
+		}
+	}
+
+	now := time.Now()
+	for pair, n := range moved {
+		r.emitTopology(TopologyEvent{Type: TopologySlotsMoved, From: pair[0], To: pair[1], Slots: n, At: now})
+	}
+}
+
+// clusterSlots master address by slot
+func (r *Redis) clusterSlots(ctx context.Context) (map[int]string, error) {
+	v, err := r.DoContext(ctx, "cluster", "slots").Result()
+	if err != nil {
+		return nil, err
+	}
+
+	slots := map[int]string{}
+	ranges, _ := v.([]interface{})
+	for _, rng := range ranges {
+		// [start, end, [ip, port, id], replicas...]
+		fields, _ := rng.([]interface{})
+		if len(fields) < 3 {
+			continue
+		}
+
+		start, _ := fields[0].(int64)
+		end, _ := fields[1].(int64)
+		master, _ := fields[2].([]interface{})
+		if len(master) < 2 {
+			continue
+		}
+
+		addr := fmt.Sprintf("%v:%v", master[0], master[1])
+		for slot := start; slot <= end; slot++ {
+			slots[int(slot)] = addr
+		}
+	}
+
+	return slots, nil
+}
+
+func (r *Redis) emitTopology(event TopologyEvent) {
+	r.logf("topology event=%s master=%s from=%s to=%s slots=%d", event.Type, event.Master, event.From, event.To, event.Slots)
+
+	if r.topology.counter != nil {
+		r.topology.counter.WithLabelValues(r.Instance(), event.Type).Inc()
+	}
+
+	r.topology.mu.Lock()
+	callbacks := make([]func(TopologyEvent), len(r.topology.callbacks))
+	copy(callbacks, r.topology.callbacks)
+	r.topology.mu.Unlock()
+
+	r.topology.emitting.Lock()
+	defer r.topology.emitting.Unlock()
+
+	for _, fn := range callbacks {
+		fn(event)
+	}
+}
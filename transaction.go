@@ -0,0 +1,54 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+// Transaction run fn in WATCH/MULTI/EXEC on keys, retry on redis.TxFailedErr
+// at most txMaxAttempts times with jittered exponential backoff.
+// fn should read watched keys by tx and queue writes by tx.TxPipelined.
+// Retries are taken from the retry budget of ctx.
+func (r *Redis) Transaction(ctx context.Context, fn func(tx *redis.Tx) error, keys ...string) error {
+	attempts := r.TxMaxAttempts
+	if attempts <= 0 {
+		attempts = 3
+	}
+
+	backoff := r.TxRetryBackoff
+	if backoff <= 0 {
+		backoff = 10 * time.Millisecond
+	}
+
+	budget := RetryBudgetFrom(ctx)
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if !budget.take() {
+				return fmt.Errorf("%w: %v", ErrRetryBudgetExhausted, err)
+			}
+
+			wait := backoff << uint(attempt-1)
+			wait = wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+
+		// Watch unwatch and release the connection whatever fn returns
+		err = r.Watch(fn, keys...)
+		if err != redis.TxFailedErr {
+			return err
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,105 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+// GetValue decode value of key into v by the codec of its namespace (JSON by default), ok is false when key is missed.
+// When key is missed and def is given, v is set to def. It is the non-generic form of Get[T],
+// the module is kept at go 1.14 so type parameters are not available.
+func GetValue(ctx context.Context, r *Redis, key string, v interface{}, def ...interface{}) (bool, error) {
+	ok, err := NewCache(r, "", nil).Get(ctx, key, v)
+	if err != nil || ok || len(def) == 0 {
+		return ok, err
+	}
+
+	return false, setDefault(v, def[0])
+}
+
+// SetValue encode v by the codec of the namespace of key and store it with ttl.
+func SetValue(ctx context.Context, r *Redis, key string, v interface{}, ttl time.Duration) error {
+	return NewCache(r, "", nil).Set(ctx, key, v, ttl)
+}
+
+// GetSlice decode elements of list key into v, a pointer to a slice, ok is false when key is missed.
+func GetSlice(ctx context.Context, r *Redis, key string, v interface{}) (bool, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return false, fmt.Errorf("redis: GetSlice of %s: %T is not a pointer to slice", key, v)
+	}
+
+	items, err := r.DoContext(ctx, "lrange", key, 0, -1).Result()
+	if err != nil {
+		return false, err
+	}
+
+	values, _ := items.([]interface{})
+	slice := reflect.MakeSlice(rv.Elem().Type(), len(values), len(values))
+
+	c := NewCache(r, "", nil)
+	for i, item := range values {
+		if err := c.decode(key, argBytes(item), slice.Index(i).Addr().Interface()); err != nil {
+			return false, fmt.Errorf("redis: GetSlice of %s[%d]: %w", key, i, err)
+		}
+	}
+	rv.Elem().Set(slice)
+
+	return len(values) > 0, nil
+}
+
+// GetMap decode fields of hash key into v, a pointer to a map of string keys, ok is false when key is missed.
+func GetMap(ctx context.Context, r *Redis, key string, v interface{}) (bool, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Map || rv.Elem().Type().Key().Kind() != reflect.String {
+		return false, fmt.Errorf("redis: GetMap of %s: %T is not a pointer to map of string keys", key, v)
+	}
+
+	cmd := redis.NewStringStringMapCmd("hgetall", key)
+	if err := r.ProcessContext(ctx, cmd); err != nil {
+		return false, err
+	}
+
+	fields := cmd.Val()
+	mapType := rv.Elem().Type()
+	m := reflect.MakeMapWithSize(mapType, len(fields))
+
+	c := NewCache(r, "", nil)
+	for field, value := range fields {
+		elem := reflect.New(mapType.Elem())
+		if err := c.decode(key, []byte(value), elem.Interface()); err != nil {
+			return false, fmt.Errorf("redis: GetMap of %s.%s: %w", key, field, err)
+		}
+		m.SetMapIndex(reflect.ValueOf(field).Convert(mapType.Key()), elem.Elem())
+	}
+	rv.Elem().Set(m)
+
+	return len(fields) > 0, nil
+}
+
+func setDefault(v, def interface{}) error {
+	dest := reflect.ValueOf(v)
+	if dest.Kind() != reflect.Ptr || dest.IsNil() {
+		return fmt.Errorf("redis: default of non-pointer %T", v)
+	}
+
+	value := reflect.ValueOf(def)
+	if !value.IsValid() {
+		// nil default, the zero value
+		dest.Elem().Set(reflect.Zero(dest.Elem().Type()))
+		return nil
+	}
+	if !value.Type().AssignableTo(dest.Elem().Type()) {
+		if !value.Type().ConvertibleTo(dest.Elem().Type()) {
+			return fmt.Errorf("redis: default %T is not assignable to %T", def, v)
+		}
+		value = value.Convert(dest.Elem().Type())
+	}
+	dest.Elem().Set(value)
+
+	return nil
+}
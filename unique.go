@@ -0,0 +1,136 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type (
+	// UniqueCounter approximate unique counter on HyperLogLog, counted by time buckets.
+	// Bucket keys of a name share a hash tag, so they can be counted and merged in cluster.
+	UniqueCounter struct {
+		redis     *Redis
+		prefix    string
+		period    Period
+		retention int
+	}
+
+	// Period bucket size of UniqueCounter
+	Period time.Duration
+)
+
+// Periods of UniqueCounter
+const (
+	Hourly = Period(time.Hour)
+	Daily  = Period(24 * time.Hour)
+)
+
+var (
+	// KEYS[1]: bucket. ARGV[1]: ttl ms, ARGV[2...]: members. returns 1 when the estimate changed
+	uniqueAddScript = newScript(`
+local changed = redis.call('pfadd', KEYS[1], unpack(ARGV, 2))
+redis.call('pexpire', KEYS[1], ARGV[1])
+return changed
+`)
+)
+
+// NewUniqueCounter new a unique counter, keys are prefixed by prefix.
+// Buckets expire after retention periods, retention is 1 if it is not positive.
+func NewUniqueCounter(r *Redis, prefix string, period Period, retention int) *UniqueCounter {
+	if retention <= 0 {
+		retention = 1
+	}
+
+	return &UniqueCounter{
+		redis:     r,
+		prefix:    prefix,
+		period:    period,
+		retention: retention,
+	}
+}
+
+// Add members to the bucket of now, changed is true when the estimate changed
+func (uc *UniqueCounter) Add(ctx context.Context, name string, members ...string) (changed bool, err error) {
+	return uc.AddAt(ctx, name, time.Now(), members...)
+}
+
+// AddAt add members to the bucket of t
+func (uc *UniqueCounter) AddAt(ctx context.Context, name string, t time.Time, members ...string) (changed bool, err error) {
+	if len(members) == 0 {
+		return false, nil
+	}
+
+	bucket := uc.bucket(t)
+	ttl := bucket.Add(time.Duration(uc.period) * time.Duration(uc.retention)).Sub(time.Now())
+	if ttl <= 0 {
+		return false, nil
+	}
+
+	args := make([]interface{}, 0, len(members)+1)
+	args = append(args, ttl.Milliseconds())
+	for _, member := range members {
+		args = append(args, member)
+	}
+
+	n, err := uniqueAddScript.run(ctx, uc.redis, []string{uc.key(name, bucket)}, args...).Int64()
+
+	return n == 1, err
+}
+
+// Count unique members of buckets from from to to, both included
+func (uc *UniqueCounter) Count(ctx context.Context, name string, from, to time.Time) (int64, error) {
+	keys := uc.keys(name, from, to)
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	args := append([]interface{}{"pfcount"}, keys...)
+
+	return uc.redis.DoContext(ctx, args...).Int64()
+}
+
+// Merge buckets from from to to into dest, dest expires after ttl (zero is no expiration).
+// dest is stored under the name's hash tag, count it by CountMerged.
+func (uc *UniqueCounter) Merge(ctx context.Context, name, dest string, from, to time.Time, ttl time.Duration) error {
+	destKey := uc.mergedKey(name, dest)
+	args := append([]interface{}{"pfmerge", destKey}, uc.keys(name, from, to)...)
+
+	if err := uc.redis.DoContext(ctx, args...).Err(); err != nil {
+		return err
+	}
+
+	if ttl > 0 {
+		return uc.redis.DoContext(ctx, "pexpire", destKey, ttl.Milliseconds()).Err()
+	}
+
+	return nil
+}
+
+// CountMerged unique members of dest created by Merge
+func (uc *UniqueCounter) CountMerged(ctx context.Context, name, dest string) (int64, error) {
+	return uc.redis.DoContext(ctx, "pfcount", uc.mergedKey(name, dest)).Int64()
+}
+
+// bucket start of bucket containing t, daily buckets start at UTC midnight
+func (uc *UniqueCounter) bucket(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Duration(uc.period))
+}
+
+func (uc *UniqueCounter) keys(name string, from, to time.Time) []interface{} {
+	keys := make([]interface{}, 0)
+	for bucket := uc.bucket(from); !bucket.After(to); bucket = bucket.Add(time.Duration(uc.period)) {
+		keys = append(keys, uc.key(name, bucket))
+	}
+
+	return keys
+}
+
+// keys use hash tag so that buckets of a name are in the same cluster slot.
+func (uc *UniqueCounter) key(name string, bucket time.Time) string {
+	return fmt.Sprintf("%s:{%s}:%d", uc.prefix, name, bucket.Unix())
+}
+
+func (uc *UniqueCounter) mergedKey(name, dest string) string {
+	return uc.prefix + ":{" + name + "}:merged:" + dest
+}
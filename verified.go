@@ -0,0 +1,117 @@
+package redis
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+type (
+	// VerifyOptions options of WriteVerified
+	VerifyOptions struct {
+		Replicas int           // replicas acknowledging the write by WAIT, default is 1
+		Timeout  time.Duration // WAIT timeout, default is 1s, clamped like the timeout of WriteDurable
+		Attempts int           // read back attempts on a replica, default is 3
+		Backoff  time.Duration // backoff between read back attempts, default is 50ms
+	}
+)
+
+var (
+	// ErrNotVerified write is not acknowledged by enough replicas or not read back from a replica
+	ErrNotVerified = errors.New("redis: write not verified on replicas")
+)
+
+// WriteVerified set key on its master, wait until opts.Replicas replicas acknowledged it by WAIT,
+// then read it back from a replica of read splitting, for a small set of critical keys like kill switches and maintenance flags.
+// Without read splitting the write is verified by WAIT only. ErrNotVerified is returned (wrapped) when verification failed,
+// the value is written to the master anyway.
+func (r *Redis) WriteVerified(ctx context.Context, key string, value interface{}, ttl time.Duration, opts ...VerifyOptions) (err error) {
+	opt := VerifyOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	if opt.Replicas <= 0 {
+		opt.Replicas = 1
+	}
+	if opt.Timeout <= 0 {
+		opt.Timeout = time.Second
+	}
+	if opt.Attempts <= 0 {
+		opt.Attempts = 3
+	}
+	if opt.Backoff <= 0 {
+		opt.Backoff = 50 * time.Millisecond
+	}
+
+	result := "ok"
+	defer func() {
+		if r.verifiedWrites != nil {
+			r.verifiedWrites.WithLabelValues(r.Instance(), result).Inc()
+		}
+	}()
+
+	err = r.WriteDurable(ctx, func(pipe redis.Pipeliner) error {
+		return pipe.Process(redis.NewStatusCmd(setArgs(key, value, ttl)...))
+	}, opt.Replicas, opt.Timeout, key)
+
+	var durability *DurabilityError
+	if errors.As(err, &durability) {
+		result = "wait_timeout"
+		return fmt.Errorf("%w: %s acknowledged by %d of %d replicas", ErrNotVerified, key, durability.Acked, durability.Replicas)
+	}
+	if err != nil {
+		result = "error"
+		return err
+	}
+
+	if len(r.replicas) == 0 {
+		return nil
+	}
+
+	expected := argBytes(value)
+	for attempt := 0; attempt < opt.Attempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				result = "error"
+				return ctx.Err()
+			case <-time.After(opt.Backoff):
+			}
+		}
+
+		data, err := r.Reader(ctx).Get(key).Bytes()
+		if err == nil && bytes.Equal(data, expected) {
+			return nil
+		}
+	}
+
+	result = "mismatch"
+	return fmt.Errorf("%w: %s is not read back from replica", ErrNotVerified, key)
+}
+
+// masterFor client of the master serving key, the client itself unless it is a cluster client
+func (r *Redis) masterFor(ctx context.Context, key string) (redis.UniversalClient, error) {
+	if !r.isCluster() {
+		return r.UniversalClient, nil
+	}
+
+	addr := r.slotNode(ctx, keySlot(key))
+
+	var master redis.UniversalClient
+	r.forEachMaster(func(node redis.UniversalClient, nodeAddr string) error {
+		if nodeAddr == addr {
+			master = node
+		}
+		return nil
+	})
+	if master == nil {
+		return nil, fmt.Errorf("redis: master of %s not found", key)
+	}
+
+	return master, nil
+}
@@ -0,0 +1,258 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+type (
+	// Workers pool of workers consuming several priority queues as a box, so one deployment serves several job types.
+	// Queues of a higher priority are served first while they have items, queues of the same priority share workers
+	// by their weights, a queue is not served by more workers than its concurrency cap.
+	Workers struct {
+		Concurrency int           `config:"concurrency" default:"10" help:"Workers of the pool, default is 10"`
+		MinPoll     time.Duration `config:"minPoll" default:"10ms" help:"Poll interval of a worker after an item, doubled while all queues are empty, default is 10ms"`
+		MaxPoll     time.Duration `config:"maxPoll" default:"1s" help:"Max poll interval of a worker, default is 1s"`
+
+		Component
+		mu     sync.Mutex
+		queues []*workerQueue
+		done   chan struct{}
+		wg     sync.WaitGroup
+	}
+
+	// WorkerQueueOptions options of a queue consumed by Workers
+	WorkerQueueOptions struct {
+		Priority       int // queues of a higher priority are served first, default is 0
+		Weight         int // share of workers among queues of the same priority, default is 1
+		MaxConcurrency int // items of the queue handled at once, default is 0 (up to the concurrency of the pool)
+	}
+
+	// QueueHandler handle an item of a priority queue
+	QueueHandler func(ctx context.Context, item PriorityItem) error
+
+	workerQueue struct {
+		queue    *PriorityQueue
+		handler  QueueHandler
+		opt      WorkerQueueOptions
+		current  int // smooth weighted round robin state
+		inflight int
+	}
+)
+
+var (
+	// ErrNoQueues workers are served without queues
+	ErrNoQueues = errors.New("redis: workers without queues")
+)
+
+// NewWorkers new a workers box named name, queues are added by Handle
+func NewWorkers(name string, r *Redis) *Workers {
+	return &Workers{
+		Component: NewComponent(name, r),
+	}
+}
+
+// Handle consume queue by handler with opt, call it before serving
+func (w *Workers) Handle(queue *PriorityQueue, handler QueueHandler, opts ...WorkerQueueOptions) {
+	opt := WorkerQueueOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Weight <= 0 {
+		opt.Weight = 1
+	}
+
+	w.mu.Lock()
+	w.queues = append(w.queues, &workerQueue{queue: queue, handler: handler, opt: opt})
+	sort.SliceStable(w.queues, func(i, j int) bool {
+		return w.queues[i].opt.Priority > w.queues[j].opt.Priority
+	})
+	w.mu.Unlock()
+}
+
+// ConfigWillLoad config will load
+func (w *Workers) ConfigWillLoad(context.Context) {
+
+}
+
+// ConfigDidLoad set defaults
+func (w *Workers) ConfigDidLoad(context.Context) {
+	if w.Concurrency <= 0 {
+		w.Concurrency = 10
+	}
+	if w.MinPoll <= 0 {
+		w.MinPoll = 10 * time.Millisecond
+	}
+	if w.MaxPoll < w.MinPoll {
+		w.MaxPoll = w.MinPoll
+	}
+}
+
+// Serve start workers
+func (w *Workers) Serve(context.Context) error {
+	w.mu.Lock()
+	empty := len(w.queues) == 0
+	w.mu.Unlock()
+	if empty {
+		return ErrNoQueues
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w.done = make(chan struct{})
+	go func(done <-chan struct{}) {
+		<-done
+		cancel()
+	}(w.done)
+
+	for i := 0; i < w.Concurrency; i++ {
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			w.run(ctx)
+		}()
+	}
+
+	return nil
+}
+
+// Shutdown stop workers, the current items are finished first
+func (w *Workers) Shutdown(context.Context) error {
+	if w.done != nil {
+		close(w.done)
+		w.done = nil
+	}
+
+	w.wg.Wait()
+
+	return nil
+}
+
+// run handle items until ctx is done, the poll interval grows while all queues are empty
+func (w *Workers) run(ctx context.Context) {
+	r := w.Component.redis
+	poll := w.MinPoll
+
+	for ctx.Err() == nil {
+		if err := r.waitConsumersResumed(ctx); err != nil {
+			return
+		}
+
+		if w.work(ctx) {
+			poll = w.MinPoll
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(poll):
+		}
+
+		if poll *= 2; poll > w.MaxPoll {
+			poll = w.MaxPoll
+		}
+	}
+}
+
+// work handle an item of the first queue with one in the order of pick, false when none had an item
+func (w *Workers) work(ctx context.Context) bool {
+	r := w.Component.redis
+
+	for _, q := range w.pick() {
+		if !w.acquire(q) {
+			continue
+		}
+
+		item, err := q.queue.TryDequeue(ctx)
+		if err != nil {
+			w.release(q)
+			if err != ErrQueueEmpty && ctx.Err() == nil {
+				r.logf("workers %s queue %s: %v", w.name, q.queue.name, err)
+			}
+			continue
+		}
+
+		carrier, ready := decodeTrace(item.trace)
+		itemCtx, end := r.startConsumer(ctx, q.queue.name, carrier, ready)
+		err = q.handler(itemCtx, item)
+		end(err)
+		w.release(q)
+
+		if err != nil {
+			r.logf("workers %s queue %s item %s failed: %v", w.name, q.queue.name, item.ID, err)
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// pick queues to try in order: by priority, queues of the same priority by smooth weighted round robin.
+// Queues at their concurrency cap are skipped.
+func (w *Workers) pick() []*workerQueue {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	order := make([]*workerQueue, 0, len(w.queues))
+	for i := 0; i < len(w.queues); {
+		j := i
+		for j < len(w.queues) && w.queues[j].opt.Priority == w.queues[i].opt.Priority {
+			j++
+		}
+
+		var (
+			group []*workerQueue
+			total int
+			best  *workerQueue
+		)
+		for _, q := range w.queues[i:j] {
+			if q.opt.MaxConcurrency > 0 && q.inflight >= q.opt.MaxConcurrency {
+				continue
+			}
+
+			group = append(group, q)
+			total += q.opt.Weight
+			q.current += q.opt.Weight
+			if best == nil || q.current > best.current {
+				best = q
+			}
+		}
+
+		if best != nil {
+			best.current -= total
+			order = append(order, best)
+			for _, q := range group {
+				if q != best {
+					order = append(order, q)
+				}
+			}
+		}
+
+		i = j
+	}
+
+	return order
+}
+
+// acquire a worker of q, false when q is at its concurrency cap
+func (w *Workers) acquire(q *workerQueue) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if q.opt.MaxConcurrency > 0 && q.inflight >= q.opt.MaxConcurrency {
+		return false
+	}
+	q.inflight++
+
+	return true
+}
+
+func (w *Workers) release(q *workerQueue) {
+	w.mu.Lock()
+	q.inflight--
+	w.mu.Unlock()
+}